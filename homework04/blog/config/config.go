@@ -0,0 +1,587 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// minJWTSecretLength HS256 密钥的最小长度（字节）
+const minJWTSecretLength = 32
+
+// weakJWTSecrets 示例配置中的占位密钥，不允许在运行时使用
+var weakJWTSecrets = map[string]bool{
+	"your-super-secret-jwt-key-here":                    true,
+	"your_jwt_secret_key":                               true,
+	"change-me-to-a-random-string-of-at-least-32-bytes": true,
+}
+
+// Config 应用配置
+type Config struct {
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	JWT      JWTConfig      `yaml:"jwt" toml:"jwt"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	Mail     MailConfig     `yaml:"mail" toml:"mail"`
+	Search   SearchConfig   `yaml:"search" toml:"search"`
+}
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	Port            string   `yaml:"port" toml:"port"`
+	Mode            string   `yaml:"mode" toml:"mode"`
+	ReadTimeout     Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// TrustedProxies 可信的反向代理地址（IP 或 CIDR），只有来自这些地址的请求才采信 X-Forwarded-For 等头部；
+	// 为空时不信任任何代理，客户端 IP 一律取连接的对端地址
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver         string `yaml:"driver" toml:"driver"`
+	Host           string `yaml:"host" toml:"host"`
+	Port           string `yaml:"port" toml:"port"`
+	User           string `yaml:"user" toml:"user"`
+	Password       string `yaml:"password" toml:"password"`
+	Name           string `yaml:"name" toml:"name"`
+	SSLMode        string `yaml:"sslmode" toml:"sslmode"`
+	TimeZone       string `yaml:"timezone" toml:"timezone"`
+	Path           string `yaml:"path" toml:"path"`
+	AutoMigrate    bool   `yaml:"auto_migrate" toml:"auto_migrate"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" toml:"migrate_on_start"`
+}
+
+// JWT 签名算法
+const (
+	JWTAlgorithmHS256 = "HS256"
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// JWTConfig JWT 配置
+// Expiration 为访问令牌有效期，应尽量短；RefreshExpiration 为刷新令牌有效期
+// Algorithm 为 RS256/EdDSA 时签名密钥保存在数据库中（私钥用 Secret 加密），每隔 KeyRotation 轮换一次，
+// 公钥通过 /.well-known/jwks.json 发布；为 HS256 时直接用 Secret 签名，不发布公钥也不轮换
+type JWTConfig struct {
+	Secret            string   `yaml:"secret" toml:"secret"`
+	Issuer            string   `yaml:"issuer" toml:"issuer"`
+	Audience          string   `yaml:"audience" toml:"audience"`
+	Algorithm         string   `yaml:"algorithm" toml:"algorithm"`
+	KeyRotation       Duration `yaml:"key_rotation" toml:"key_rotation"`
+	Expiration        Duration `yaml:"expiration" toml:"expiration"`
+	RefreshExpiration Duration `yaml:"refresh_expiration" toml:"refresh_expiration"`
+}
+
+// Asymmetric 是否使用非对称签名
+func (c JWTConfig) Asymmetric() bool {
+	return c.Algorithm == JWTAlgorithmRS256 || c.Algorithm == JWTAlgorithmEdDSA
+}
+
+// AuthConfig 账号相关配置
+// VerificationExpiration、PasswordResetExpiration 为邮箱验证链接和重置密码链接的有效期
+// TOTPIssuer 为验证器应用中显示的名称，TwoFactorChallengeExpiration 为输入密码后完成两步验证的时限
+// BcryptCost 为密码哈希的 bcrypt cost，修改后已有用户在下次登录或修改密码时按新的 cost 重新哈希
+type AuthConfig struct {
+	BcryptCost                   int            `yaml:"bcrypt_cost" toml:"bcrypt_cost"`
+	VerificationExpiration       Duration       `yaml:"verification_expiration" toml:"verification_expiration"`
+	PasswordResetExpiration      Duration       `yaml:"password_reset_expiration" toml:"password_reset_expiration"`
+	TOTPIssuer                   string         `yaml:"totp_issuer" toml:"totp_issuer"`
+	TwoFactorChallengeExpiration Duration       `yaml:"two_factor_challenge_expiration" toml:"two_factor_challenge_expiration"`
+	Lockout                      LockoutConfig  `yaml:"lockout" toml:"lockout"`
+	AccountDeletion              DeletionConfig `yaml:"account_deletion" toml:"account_deletion"`
+}
+
+// 注销账号时对其文章、评论的处理方式
+const (
+	// DeletionAnonymize 保留内容，作者显示为已注销用户
+	DeletionAnonymize = "anonymize"
+	// DeletionDelete 随账号一起删除
+	DeletionDelete = "delete"
+)
+
+// DeletionConfig 注销账号时分别对文章和评论采用的处理方式，anonymize 或 delete
+type DeletionConfig struct {
+	Posts    string `yaml:"posts" toml:"posts"`
+	Comments string `yaml:"comments" toml:"comments"`
+}
+
+func validDeletionPolicy(policy string) bool {
+	return policy == DeletionAnonymize || policy == DeletionDelete
+}
+
+// 登录失败计数的存储位置
+const (
+	LockoutStoreDatabase = "database"
+	LockoutStoreMemory   = "memory"
+)
+
+// LockoutConfig 登录防暴力破解：按用户名和 IP 分别统计连续失败次数
+// 超过 FreeAttempts 次后每次失败都要等待 BaseDelay、2×BaseDelay……（不超过 MaxDelay）才能再试，
+// 用户名失败 MaxAttempts 次、IP 失败 IPMaxAttempts 次后锁定 Duration；距上次失败超过 Window 后重新计数
+// Store 为 memory 时计数只保存在本实例内存中，多实例部署应使用 database
+type LockoutConfig struct {
+	Store         string   `yaml:"store" toml:"store"`
+	MaxAttempts   int      `yaml:"max_attempts" toml:"max_attempts"`
+	IPMaxAttempts int      `yaml:"ip_max_attempts" toml:"ip_max_attempts"`
+	FreeAttempts  int      `yaml:"free_attempts" toml:"free_attempts"`
+	BaseDelay     Duration `yaml:"base_delay" toml:"base_delay"`
+	MaxDelay      Duration `yaml:"max_delay" toml:"max_delay"`
+	Window        Duration `yaml:"window" toml:"window"`
+	Duration      Duration `yaml:"duration" toml:"duration"`
+}
+
+// 邮件发送方式
+const (
+	MailDriverLog  = "log"
+	MailDriverFile = "file"
+	MailDriverSMTP = "smtp"
+)
+
+// MailConfig 邮件配置
+// Driver 为 log 时只把邮件写入日志，file 时把邮件保存为 Dir 下的 .eml 文件，均用于本地开发；生产环境使用 smtp
+// BaseURL 为邮件中链接指向的前端地址，前端页面从链接中取出 token 再调用接口
+type MailConfig struct {
+	Driver  string     `yaml:"driver" toml:"driver"`
+	From    string     `yaml:"from" toml:"from"`
+	Dir     string     `yaml:"dir" toml:"dir"`
+	BaseURL string     `yaml:"base_url" toml:"base_url"`
+	SMTP    SMTPConfig `yaml:"smtp" toml:"smtp"`
+}
+
+// SMTPConfig SMTP 服务器配置，服务器支持时使用 STARTTLS；Username 为空时不认证
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+}
+
+// 全文检索引擎
+const (
+	SearchEngineAuto   = "auto"
+	SearchEngineMemory = "memory"
+	SearchEngineMySQL  = "mysql"
+)
+
+// SearchConfig 全文检索配置
+// Engine 为 auto 时 MySQL 使用 FULLTEXT 索引，其他数据库使用内存倒排索引
+type SearchConfig struct {
+	Engine string `yaml:"engine" toml:"engine"`
+}
+
+// SearchEngine 解析 auto 后实际使用的检索引擎
+func (c *Config) SearchEngine() string {
+	if c.Search.Engine == SearchEngineAuto {
+		if c.Database.Driver == DriverMySQL {
+			return SearchEngineMySQL
+		}
+		return SearchEngineMemory
+	}
+	return c.Search.Engine
+}
+
+// Duration 支持 "24h"、"15m" 这类写法的时长
+type Duration time.Duration
+
+// UnmarshalText 解析时长字符串，YAML 和 TOML 共用
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText 输出时长字符串
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Std 转换为 time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			Mode:            "debug",
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(30 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			ShutdownTimeout: Duration(20 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Driver:   DriverMySQL,
+			Host:     "localhost",
+			User:     "root",
+			Name:     "blog",
+			SSLMode:  "disable",
+			TimeZone: "Local",
+			Path:     "blog.db",
+		},
+		JWT: JWTConfig{
+			Issuer:            "blog",
+			Audience:          "blog-api",
+			Algorithm:         JWTAlgorithmEdDSA,
+			KeyRotation:       Duration(30 * 24 * time.Hour),
+			Expiration:        Duration(15 * time.Minute),
+			RefreshExpiration: Duration(30 * 24 * time.Hour),
+		},
+		Auth: AuthConfig{
+			BcryptCost:                   bcrypt.DefaultCost,
+			VerificationExpiration:       Duration(48 * time.Hour),
+			PasswordResetExpiration:      Duration(time.Hour),
+			TOTPIssuer:                   "Blog",
+			TwoFactorChallengeExpiration: Duration(5 * time.Minute),
+			Lockout: LockoutConfig{
+				Store:         LockoutStoreDatabase,
+				MaxAttempts:   10,
+				IPMaxAttempts: 50,
+				FreeAttempts:  3,
+				BaseDelay:     Duration(time.Second),
+				MaxDelay:      Duration(30 * time.Second),
+				Window:        Duration(15 * time.Minute),
+				Duration:      Duration(15 * time.Minute),
+			},
+			AccountDeletion: DeletionConfig{
+				Posts:    DeletionAnonymize,
+				Comments: DeletionAnonymize,
+			},
+		},
+		Mail: MailConfig{
+			Driver:  MailDriverLog,
+			From:    "Blog <no-reply@localhost>",
+			Dir:     "mail",
+			BaseURL: "http://localhost:8080",
+			SMTP: SMTPConfig{
+				Port: "587",
+			},
+		},
+		Search: SearchConfig{
+			Engine: SearchEngineAuto,
+		},
+	}
+}
+
+// Load 按 默认值 -> 配置文件 -> 环境变量 的顺序加载配置并校验
+// path 为空时不读取配置文件；文件扩展名决定格式（.yaml/.yml/.toml）
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.Database.Driver = strings.ToLower(cfg.Database.Driver)
+	cfg.Mail.Driver = strings.ToLower(cfg.Mail.Driver)
+	cfg.Search.Engine = strings.ToLower(cfg.Search.Engine)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 读取配置文件
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv 用环境变量覆盖配置
+func (c *Config) applyEnv() error {
+	stringVars := map[string]*string{
+		"PORT":          &c.Server.Port,
+		"GIN_MODE":      &c.Server.Mode,
+		"DB_DRIVER":     &c.Database.Driver,
+		"DB_HOST":       &c.Database.Host,
+		"DB_PORT":       &c.Database.Port,
+		"DB_USER":       &c.Database.User,
+		"DB_PASSWORD":   &c.Database.Password,
+		"DB_NAME":       &c.Database.Name,
+		"DB_SSLMODE":    &c.Database.SSLMode,
+		"DB_TIMEZONE":   &c.Database.TimeZone,
+		"DB_PATH":       &c.Database.Path,
+		"JWT_SECRET":    &c.JWT.Secret,
+		"JWT_ISSUER":    &c.JWT.Issuer,
+		"JWT_AUDIENCE":  &c.JWT.Audience,
+		"JWT_ALGORITHM": &c.JWT.Algorithm,
+		"MAIL_DRIVER":   &c.Mail.Driver,
+		"MAIL_FROM":     &c.Mail.From,
+		"MAIL_DIR":      &c.Mail.Dir,
+		"MAIL_BASE_URL": &c.Mail.BaseURL,
+		"SMTP_HOST":     &c.Mail.SMTP.Host,
+		"SMTP_PORT":     &c.Mail.SMTP.Port,
+		"SMTP_USERNAME": &c.Mail.SMTP.Username,
+		"SMTP_PASSWORD": &c.Mail.SMTP.Password,
+		"SEARCH_ENGINE": &c.Search.Engine,
+
+		"AUTH_TOTP_ISSUER":   &c.Auth.TOTPIssuer,
+		"AUTH_LOCKOUT_STORE": &c.Auth.Lockout.Store,
+
+		"AUTH_ACCOUNT_DELETION_POSTS":    &c.Auth.AccountDeletion.Posts,
+		"AUTH_ACCOUNT_DELETION_COMMENTS": &c.Auth.AccountDeletion.Comments,
+	}
+	for key, target := range stringVars {
+		if value, ok := os.LookupEnv(key); ok {
+			*target = value
+		}
+	}
+
+	// 逗号分隔的列表
+	lists := map[string]*[]string{
+		"SERVER_TRUSTED_PROXIES": &c.Server.TrustedProxies,
+	}
+	for key, target := range lists {
+		if value, ok := os.LookupEnv(key); ok {
+			*target = splitList(value)
+		}
+	}
+
+	bools := map[string]*bool{
+		"DB_AUTO_MIGRATE":     &c.Database.AutoMigrate,
+		"DB_MIGRATE_ON_START": &c.Database.MigrateOnStart,
+	}
+	for key, target := range bools {
+		if value, ok := os.LookupEnv(key); ok {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*target = parsed
+		}
+	}
+
+	ints := map[string]*int{
+		"AUTH_BCRYPT_COST":             &c.Auth.BcryptCost,
+		"AUTH_LOCKOUT_MAX_ATTEMPTS":    &c.Auth.Lockout.MaxAttempts,
+		"AUTH_LOCKOUT_IP_MAX_ATTEMPTS": &c.Auth.Lockout.IPMaxAttempts,
+		"AUTH_LOCKOUT_FREE_ATTEMPTS":   &c.Auth.Lockout.FreeAttempts,
+	}
+	for key, target := range ints {
+		if value, ok := os.LookupEnv(key); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*target = parsed
+		}
+	}
+
+	durations := map[string]*Duration{
+		"SERVER_READ_TIMEOUT":     &c.Server.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":    &c.Server.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":     &c.Server.IdleTimeout,
+		"SERVER_SHUTDOWN_TIMEOUT": &c.Server.ShutdownTimeout,
+		"JWT_EXPIRATION":          &c.JWT.Expiration,
+		"JWT_REFRESH_EXPIRATION":  &c.JWT.RefreshExpiration,
+		"JWT_KEY_ROTATION":        &c.JWT.KeyRotation,
+
+		"AUTH_VERIFICATION_EXPIRATION":   &c.Auth.VerificationExpiration,
+		"AUTH_PASSWORD_RESET_EXPIRATION": &c.Auth.PasswordResetExpiration,
+
+		"AUTH_TWO_FACTOR_CHALLENGE_EXPIRATION": &c.Auth.TwoFactorChallengeExpiration,
+		"AUTH_LOCKOUT_BASE_DELAY":              &c.Auth.Lockout.BaseDelay,
+		"AUTH_LOCKOUT_MAX_DELAY":               &c.Auth.Lockout.MaxDelay,
+		"AUTH_LOCKOUT_WINDOW":                  &c.Auth.Lockout.Window,
+		"AUTH_LOCKOUT_DURATION":                &c.Auth.Lockout.Duration,
+	}
+	for key, target := range durations {
+		if value, ok := os.LookupEnv(key); ok {
+			if err := target.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// splitList 按逗号拆分列表，去掉空白和空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Validate 校验配置，返回所有发现的问题
+func (c *Config) Validate() error {
+	var errs []error
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port <= 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port: invalid port %q", c.Server.Port))
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("server.mode: must be debug, release or test, got %q", c.Server.Mode))
+	}
+	if c.Server.ReadTimeout.Std() <= 0 || c.Server.WriteTimeout.Std() <= 0 || c.Server.IdleTimeout.Std() <= 0 {
+		errs = append(errs, errors.New("server: read_timeout, write_timeout and idle_timeout must be positive"))
+	}
+	if c.Server.ShutdownTimeout.Std() <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout: must be positive"))
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				errs = append(errs, fmt.Errorf("server.trusted_proxies: invalid IP or CIDR %q", proxy))
+			}
+		}
+	}
+
+	switch c.Database.Driver {
+	case DriverMySQL, DriverPostgres:
+		if c.Database.Host == "" {
+			errs = append(errs, errors.New("database.host: required"))
+		}
+		if c.Database.Name == "" {
+			errs = append(errs, errors.New("database.name: required"))
+		}
+	case DriverSQLite:
+		if c.Database.Path == "" {
+			errs = append(errs, errors.New("database.path: required for sqlite"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("database.driver: unsupported driver %q (expected mysql, postgres or sqlite)", c.Database.Driver))
+	}
+
+	switch {
+	case c.JWT.Secret == "":
+		errs = append(errs, errors.New("jwt.secret: required (set JWT_SECRET)"))
+	case weakJWTSecrets[c.JWT.Secret]:
+		errs = append(errs, errors.New("jwt.secret: placeholder value from the example config must be replaced"))
+	case len(c.JWT.Secret) < minJWTSecretLength:
+		errs = append(errs, fmt.Errorf("jwt.secret: must be at least %d bytes", minJWTSecretLength))
+	}
+	if c.JWT.Issuer == "" {
+		errs = append(errs, errors.New("jwt.issuer: required"))
+	}
+	if c.JWT.Audience == "" {
+		errs = append(errs, errors.New("jwt.audience: required"))
+	}
+	switch c.JWT.Algorithm {
+	case JWTAlgorithmHS256:
+	case JWTAlgorithmRS256, JWTAlgorithmEdDSA:
+		if c.JWT.KeyRotation.Std() < c.JWT.Expiration.Std() {
+			errs = append(errs, errors.New("jwt.key_rotation: must not be shorter than jwt.expiration"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("jwt.algorithm: must be HS256, RS256 or EdDSA, got %q", c.JWT.Algorithm))
+	}
+	if c.JWT.Expiration.Std() <= 0 {
+		errs = append(errs, errors.New("jwt.expiration: must be positive"))
+	}
+	if c.JWT.RefreshExpiration.Std() <= c.JWT.Expiration.Std() {
+		errs = append(errs, errors.New("jwt.refresh_expiration: must be longer than jwt.expiration"))
+	}
+
+	if c.Auth.VerificationExpiration.Std() <= 0 || c.Auth.PasswordResetExpiration.Std() <= 0 {
+		errs = append(errs, errors.New("auth: verification_expiration and password_reset_expiration must be positive"))
+	}
+	if c.Auth.TOTPIssuer == "" || strings.Contains(c.Auth.TOTPIssuer, ":") {
+		errs = append(errs, errors.New("auth.totp_issuer: must be non-empty and must not contain ':'"))
+	}
+	if c.Auth.TwoFactorChallengeExpiration.Std() <= 0 {
+		errs = append(errs, errors.New("auth.two_factor_challenge_expiration: must be positive"))
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("auth.bcrypt_cost: must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	deletion := c.Auth.AccountDeletion
+	if !validDeletionPolicy(deletion.Posts) || !validDeletionPolicy(deletion.Comments) {
+		errs = append(errs, fmt.Errorf("auth.account_deletion: posts and comments must be %s or %s", DeletionAnonymize, DeletionDelete))
+	}
+	lockout := c.Auth.Lockout
+	if lockout.Store != LockoutStoreDatabase && lockout.Store != LockoutStoreMemory {
+		errs = append(errs, fmt.Errorf("auth.lockout.store: must be %s or %s", LockoutStoreDatabase, LockoutStoreMemory))
+	}
+	if lockout.MaxAttempts <= 0 || lockout.IPMaxAttempts <= 0 || lockout.FreeAttempts < 0 {
+		errs = append(errs, errors.New("auth.lockout: max_attempts and ip_max_attempts must be positive, free_attempts must not be negative"))
+	}
+	if lockout.BaseDelay.Std() < 0 || lockout.MaxDelay.Std() < lockout.BaseDelay.Std() {
+		errs = append(errs, errors.New("auth.lockout: base_delay must not be negative and max_delay must not be shorter than base_delay"))
+	}
+	if lockout.Window.Std() <= 0 || lockout.Duration.Std() <= 0 {
+		errs = append(errs, errors.New("auth.lockout: window and duration must be positive"))
+	}
+
+	switch c.Mail.Driver {
+	case MailDriverLog:
+	case MailDriverFile:
+		if c.Mail.Dir == "" {
+			errs = append(errs, errors.New("mail.dir: required for the file driver"))
+		}
+	case MailDriverSMTP:
+		if c.Mail.SMTP.Host == "" {
+			errs = append(errs, errors.New("mail.smtp.host: required for the smtp driver"))
+		}
+		if port, err := strconv.Atoi(c.Mail.SMTP.Port); err != nil || port <= 0 || port > 65535 {
+			errs = append(errs, fmt.Errorf("mail.smtp.port: invalid port %q", c.Mail.SMTP.Port))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mail.driver: must be log, file or smtp, got %q", c.Mail.Driver))
+	}
+	if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+		errs = append(errs, fmt.Errorf("mail.from: invalid address %q", c.Mail.From))
+	}
+	if u, err := url.Parse(c.Mail.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("mail.base_url: must be an absolute http(s) URL, got %q", c.Mail.BaseURL))
+	}
+
+	switch c.Search.Engine {
+	case SearchEngineAuto, SearchEngineMemory:
+	case SearchEngineMySQL:
+		if c.Database.Driver != DriverMySQL {
+			errs = append(errs, errors.New("search.engine: mysql requires database.driver mysql"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("search.engine: must be auto, memory or mysql, got %q", c.Search.Engine))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSecret = "test-secret-0123456789abcdefghijklmnopqrstuvwxyz"
+
+// validConfig 能通过校验的最小配置
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.Driver = DriverSQLite
+	cfg.Database.Path = ":memory:"
+	cfg.JWT.Secret = testSecret
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"empty secret", func(c *Config) { c.JWT.Secret = "" }, "jwt.secret: required"},
+		{"placeholder secret", func(c *Config) { c.JWT.Secret = "change-me-to-a-random-string-of-at-least-32-bytes" }, "jwt.secret: placeholder value"},
+		{"short secret", func(c *Config) { c.JWT.Secret = strings.Repeat("x", minJWTSecretLength-1) }, "jwt.secret: must be at least"},
+		{"unknown driver", func(c *Config) { c.Database.Driver = "oracle" }, "database.driver: unsupported driver"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := validConfig()
+			tc.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("got %v, want error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestLoadEnvOverridesFile 环境变量优先于配置文件，未设置的环境变量不影响文件中的值
+func TestLoadEnvOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := `
+server:
+  port: "9000"
+  trusted_proxies: ["10.0.0.1"]
+database:
+  driver: sqlite
+  path: file.db
+jwt:
+  secret: file-secret-0123456789abcdefghijklmnopqrstuvwxyz
+  issuer: file-issuer
+`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("PORT", "9100")
+	t.Setenv("DB_PATH", "env.db")
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.2, 10.1.0.0/16")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != "9100" || cfg.Database.Path != "env.db" || cfg.JWT.Secret != testSecret {
+		t.Fatalf("env not applied: port %q, path %q, secret %q", cfg.Server.Port, cfg.Database.Path, cfg.JWT.Secret)
+	}
+	if want := []string{"10.0.0.2", "10.1.0.0/16"}; !reflect.DeepEqual(cfg.Server.TrustedProxies, want) {
+		t.Fatalf("trusted proxies: got %v, want %v", cfg.Server.TrustedProxies, want)
+	}
+	if cfg.Database.Driver != DriverSQLite || cfg.JWT.Issuer != "file-issuer" {
+		t.Fatalf("file values lost: driver %q, issuer %q", cfg.Database.Driver, cfg.JWT.Issuer)
+	}
+}
@@ -4,39 +4,100 @@ import (
 	"blog/models"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-// LoadEnv 加载环境变量
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// LoadEnv 加载 .env 中的环境变量，作为配置的覆盖来源
 func LoadEnv() {
 	godotenv.Load(".env")
 }
 
-// GetEnv 获取环境变量，如果不存在则返回默认值
-func GetEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// buildDialector 根据驱动构造对应的 DSN 和 GORM Dialector
+func buildDialector(cfg DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.Driver {
+	case DriverMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			cfg.User,
+			cfg.Password,
+			cfg.Host,
+			portOrDefault(cfg.Port, "3306"),
+			cfg.Name,
+		)
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(postgresDSN(cfg)), nil
+	case DriverSQLite:
+		return sqlite.Open(SQLiteDSN(cfg.Path)), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q (expected mysql, postgres or sqlite)", cfg.Driver)
 	}
-	return value
 }
 
-// InitDB 初始化数据库连接
-func InitDB() (*gorm.DB, error) {
-	// 数据库连接配置
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		GetEnv("DB_USER", "root"),
-		GetEnv("DB_PASSWORD", "demo12!@"),
-		GetEnv("DB_HOST", "localhost"),
-		GetEnv("DB_PORT", "3306"),
-		GetEnv("DB_NAME", "blog"),
-	)
+// postgresDSN 构造 postgres:// 形式的 DSN；各部分经过 URL 编码，
+// 密码为空或包含空格、引号等字符时不会破坏其他参数
+func postgresDSN(cfg DatabaseConfig) string {
+	user := url.User(cfg.User)
+	if cfg.Password != "" {
+		user = url.UserPassword(cfg.User, cfg.Password)
+	}
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+	query.Set("TimeZone", cfg.TimeZone)
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     user,
+		Host:     net.JoinHostPort(cfg.Host, portOrDefault(cfg.Port, "5432")),
+		Path:     "/" + cfg.Name,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
+}
+
+// portOrDefault 端口未配置时使用驱动的默认端口
+func portOrDefault(port, defaultPort string) string {
+	if port == "" {
+		return defaultPort
+	}
+	return port
+}
+
+// SQLiteDSN 构造 SQLite 的 DSN，":memory:" 表示内存数据库
+func SQLiteDSN(path string) string {
+	pragmas := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	if path == ":memory:" {
+		// 使用共享缓存，使连接池中的所有连接看到同一个内存数据库
+		return "file::memory:?cache=shared&" + pragmas
+	}
+	if strings.Contains(path, "?") {
+		return path + "&" + pragmas
+	}
+	return path + "?" + pragmas
+}
+
+// OpenDB 打开数据库连接，不做任何表结构变更
+func OpenDB(cfg DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := buildDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// 配置GORM日志
 	newLogger := logger.New(
@@ -49,23 +110,49 @@ func InitDB() (*gorm.DB, error) {
 	)
 
 	// 连接数据库
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: newLogger,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// 自动迁移数据库表
-	err = db.AutoMigrate(
-		&models.User{},
-		&models.Post{},
-		&models.Comment{},
-	)
+	log.Printf("Database (%s) connected successfully", cfg.Driver)
+	return db, nil
+}
+
+// InitDB 初始化数据库连接
+// 表结构由 migrations 包的版本化迁移管理；开启 auto_migrate 时额外执行 AutoMigrate，仅用于开发环境
+func InitDB(cfg DatabaseConfig) (*gorm.DB, error) {
+	db, err := OpenDB(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
+		return nil, err
+	}
+
+	if cfg.AutoMigrate {
+		err = db.AutoMigrate(
+			&models.User{},
+			&models.Post{},
+			&models.PostSlug{},
+			&models.PostRevision{},
+			&models.Category{},
+			&models.Tag{},
+			&models.Comment{},
+			&models.RefreshToken{},
+			&models.RevokedToken{},
+			&models.Session{},
+			&models.SigningKey{},
+			&models.RecoveryCode{},
+			&models.RolePolicy{},
+			&models.LoginCounter{},
+			&models.LoginEvent{},
+			&models.PersonalAccessToken{},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+		log.Println("Database auto-migrated (development mode)")
 	}
 
-	log.Println("Database connected and migrated successfully")
 	return db, nil
 }
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestPostgresDSN(t *testing.T) {
+	for _, password := range []string{"", "secret", "p@ss w/rd 'x' dbname=other"} {
+		cfg := DatabaseConfig{
+			Host: "db.internal", User: "blog", Password: password, Name: "blog",
+			SSLMode: "disable", TimeZone: "Asia/Shanghai",
+		}
+		parsed, err := pgconn.ParseConfig(postgresDSN(cfg))
+		if err != nil {
+			t.Fatalf("password %q: parse %s: %v", password, postgresDSN(cfg), err)
+		}
+		if parsed.Password != password || parsed.Database != "blog" || parsed.User != "blog" ||
+			parsed.Host != "db.internal" || parsed.Port != 5432 || parsed.RuntimeParams["TimeZone"] != "Asia/Shanghai" {
+			t.Fatalf("password %q: got %+v", password, parsed)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package diff 比较两段文本，按行或按词给出插入、删除和未改变的片段。
+package diff
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 片段类型
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// maxEdits 编辑距离超过该值时不再寻找最短编辑序列，把剩余部分整体作为删除和插入，
+// 限制两段文本差异很大时的时间和内存
+const maxEdits = 1000
+
+// Chunk 连续的同类片段；按顺序拼接 equal 和 delete 得到原文，拼接 equal 和 insert 得到新文本
+type Chunk struct {
+	Op   string
+	Text string
+}
+
+// Result 比较结果；Insertions、Deletions 为插入和删除的行数或词数（不含空白）
+type Result struct {
+	Chunks     []Chunk
+	Insertions int
+	Deletions  int
+}
+
+// wordPattern 词的切分：连续的字母和数字、单个汉字（及假名、谚文）、连续空白、单个其他字符
+var wordPattern = regexp.MustCompile(`[\p{Han}\p{Hiragana}\p{Katakana}\p{Hangul}]|[\p{L}\p{N}_']+|\s+|.`)
+
+// Lines 按行比较，每行包括行尾的换行符
+func Lines(a, b string) Result {
+	return compare(splitLines(a), splitLines(b))
+}
+
+// Words 按词比较
+func Words(a, b string) Result {
+	return compare(wordPattern.FindAllString(a, -1), wordPattern.FindAllString(b, -1))
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func compare(a, b []string) Result {
+	var result Result
+	add := func(op string, tokens []string) {
+		for _, token := range tokens {
+			if strings.TrimFunc(token, unicode.IsSpace) != "" {
+				switch op {
+				case OpInsert:
+					result.Insertions++
+				case OpDelete:
+					result.Deletions++
+				}
+			}
+			if n := len(result.Chunks); n > 0 && result.Chunks[n-1].Op == op {
+				result.Chunks[n-1].Text += token
+			} else {
+				result.Chunks = append(result.Chunks, Chunk{Op: op, Text: token})
+			}
+		}
+	}
+
+	// 去掉相同的开头和结尾，只比较中间部分
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	add(OpEqual, a[:prefix])
+	for _, e := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		add(e.op, e.tokens)
+	}
+	add(OpEqual, a[len(a)-suffix:])
+	return result
+}
+
+type edit struct {
+	op     string
+	tokens []string
+}
+
+// myers 用 Myers 算法求 a 到 b 的最短编辑序列
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] 保存第 d 步开始前的 v[offset-d : offset+d+1]，回溯时使用
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+	return []edit{{OpDelete, a}, {OpInsert, b}}
+}
+
+// backtrack 从终点沿 trace 回溯得到编辑序列
+func backtrack(a, b []string, trace [][]int) []edit {
+	var edits []edit
+	prepend := func(op string, tokens []string) {
+		if len(tokens) == 0 {
+			return
+		}
+		if len(edits) > 0 && edits[0].op == op {
+			edits[0].tokens = append(append([]string(nil), tokens...), edits[0].tokens...)
+			return
+		}
+		edits = append([]edit{{op, tokens}}, edits...)
+	}
+
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		// trace[d] 是第 d-1 步结束时的 v，下标 0 对应 k = -d
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		// 对角线上相同的部分
+		startX, startY := prevX, prevY
+		if prevK == k+1 {
+			startY++
+		} else {
+			startX++
+		}
+		prepend(OpEqual, a[startX:x])
+		if prevK == k+1 {
+			prepend(OpInsert, b[prevY:startY])
+		} else {
+			prepend(OpDelete, a[prevX:startX])
+		}
+		x, y = prevX, prevY
+	}
+	prepend(OpEqual, a[:x])
+	return edits
+}
@@ -0,0 +1,115 @@
+package diff
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// apply 由比较结果还原两段文本
+func apply(chunks []Chunk) (string, string) {
+	var a, b strings.Builder
+	for _, c := range chunks {
+		if c.Op != OpInsert {
+			a.WriteString(c.Text)
+		}
+		if c.Op != OpDelete {
+			b.WriteString(c.Text)
+		}
+	}
+	return a.String(), b.String()
+}
+
+func TestLines(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\n"
+	b := "one\n2\nthree\nfour\nfive\n"
+	got := Lines(a, b)
+	want := Result{
+		Chunks: []Chunk{
+			{OpEqual, "one\n"},
+			{OpDelete, "two\n"},
+			{OpInsert, "2\n"},
+			{OpEqual, "three\nfour\n"},
+			{OpInsert, "five\n"},
+		},
+		Insertions: 2,
+		Deletions:  1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestWords(t *testing.T) {
+	got := Words("The quick brown fox", "The slow brown fox 跳了")
+	want := []Chunk{
+		{OpEqual, "The "},
+		{OpDelete, "quick"},
+		{OpInsert, "slow"},
+		{OpEqual, " brown fox"},
+		{OpInsert, " 跳了"},
+	}
+	if !reflect.DeepEqual(got.Chunks, want) || got.Insertions != 3 || got.Deletions != 1 {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	cases := [][2]string{
+		{"", ""},
+		{"", "new\ntext"},
+		{"old\ntext", ""},
+		{"a\nb\nc\nd\ne\n", "x\nb\ny\nd\nz"},
+		{"same", "same"},
+		{"abcabba", "cbabac"},
+		{strings.Repeat("a\n", 3000), strings.Repeat("b\n", 3000)},
+	}
+	for _, tc := range cases {
+		for _, result := range []Result{Lines(tc[0], tc[1]), Words(tc[0], tc[1])} {
+			if a, b := apply(result.Chunks); a != tc[0] || b != tc[1] {
+				t.Fatalf("diff of %q and %q does not round trip: got %q and %q", tc[0], tc[1], a, b)
+			}
+		}
+	}
+}
+
+// lcs 最长公共子序列的长度，用于检查编辑序列是否最短
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else if cur[j] > prev[j+1] {
+				cur[j+1] = cur[j]
+			} else {
+				cur[j+1] = prev[j+1]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		tokens := make([]string, rng.Intn(12))
+		for i := range tokens {
+			tokens[i] = string(rune('a' + rng.Intn(3)))
+		}
+		return tokens
+	}
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		result := compare(a, b)
+		if x, y := apply(result.Chunks); x != strings.Join(a, "") || y != strings.Join(b, "") {
+			t.Fatalf("diff of %v and %v does not round trip", a, b)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); result.Insertions+result.Deletions != want {
+			t.Fatalf("diff of %v and %v: %d edits, want %d", a, b, result.Insertions+result.Deletions, want)
+		}
+	}
+}
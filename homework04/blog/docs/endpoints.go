@@ -0,0 +1,321 @@
+package docs
+
+import (
+	"blog/handlers"
+	"blog/rbac"
+	"blog/utils"
+	"net/http"
+)
+
+// Endpoints 所有对外路由的文档描述，routes.SetupRoutes 中新增路由时需要在这里补充
+func Endpoints() []Endpoint {
+	return []Endpoint{
+		// 认证
+		{
+			Method: http.MethodPost, Path: "/api/auth/register", Tag: "auth", Summary: "用户注册",
+			Request: handlers.RegisterRequest{}, Response: handlers.UserResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/login", Tag: "auth", Summary: "用户登录；启用了两步验证时只返回 challenge_token；连续失败过多时返回 429 和 Retry-After",
+			Request: handlers.LoginRequest{}, Response: handlers.LoginResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/login/2fa", Tag: "auth", Summary: "两步登录：提交 challenge_token 和验证码（或恢复码）换取令牌",
+			Request: handlers.TwoFactorLoginRequest{}, Response: handlers.LoginResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/refresh", Tag: "auth", Summary: "刷新令牌：旧刷新令牌失效并换发新的，重复使用会吊销整个登录会话",
+			Request: handlers.RefreshRequest{}, Response: handlers.TokenResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/logout", Tag: "auth", Summary: "登出：结束当前登录会话，吊销访问令牌和刷新令牌", Auth: true,
+			Request: handlers.LogoutRequest{}, Response: handlers.MessageResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/auth/profile", Tag: "auth", Summary: "获取当前用户信息", Auth: true, Scope: rbac.ScopeRead,
+			Response: handlers.ProfileResponse{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/auth/profile", Tag: "auth", Summary: "修改邮箱、显示名称、简介和头像；修改邮箱需要当前密码，并重新验证邮箱", Auth: true,
+			Request: handlers.UpdateProfileRequest{}, Response: handlers.ProfileResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/password", Tag: "auth", Summary: "验证当前密码后修改密码，其他登录会话随之退出", Auth: true,
+			Request: handlers.ChangePasswordRequest{}, Response: handlers.MessageResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/auth/account", Tag: "auth", Summary: "验证密码后注销账号，按配置删除或匿名保留其文章和评论", Auth: true,
+			Request: handlers.DeleteAccountRequest{}, Response: handlers.MessageResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/verify", Tag: "auth", Summary: "用验证邮件中的令牌验证邮箱，令牌只能使用一次",
+			Request: handlers.VerifyEmailRequest{}, Response: handlers.UserResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/verify/resend", Tag: "auth", Summary: "重新发送验证邮件", Auth: true,
+			Response: handlers.MessageResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/forgot-password", Tag: "auth", Summary: "发送重置密码邮件，无论邮箱是否注册都返回相同的响应",
+			Request: handlers.ForgotPasswordRequest{}, Response: handlers.MessageResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/reset-password", Tag: "auth", Summary: "用重置密码邮件中的令牌设置新密码，所有登录会话随之失效，已签发的访问令牌立即不可用",
+			Request: handlers.ResetPasswordRequest{}, Response: handlers.MessageResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/2fa/setup", Tag: "auth", Summary: "生成 TOTP 密钥，用验证码确认后才启用两步验证", Auth: true,
+			Response: handlers.TwoFactorSetupResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/2fa/confirm", Tag: "auth", Summary: "用验证码确认密钥并启用两步验证，返回只显示一次的恢复码", Auth: true,
+			Request: handlers.TwoFactorCodeRequest{}, Response: handlers.RecoveryCodesResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/2fa/recovery-codes", Tag: "auth", Summary: "重新生成恢复码，旧的恢复码全部作废", Auth: true,
+			Request: handlers.TwoFactorCodeRequest{}, Response: handlers.RecoveryCodesResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/auth/2fa", Tag: "auth", Summary: "关闭两步验证（角色要求两步验证时不能关闭）", Auth: true,
+			Request: handlers.DisableTwoFactorRequest{}, Response: handlers.MessageResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/auth/tokens", Tag: "auth", Summary: "列出当前用户的个人访问令牌", Auth: true,
+			Response: []handlers.PersonalTokenResponse{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/auth/tokens", Tag: "auth", Summary: "创建个人访问令牌，令牌明文只返回这一次", Auth: true,
+			Request: handlers.CreatePersonalTokenRequest{}, Response: handlers.CreatePersonalTokenResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/auth/tokens/:id", Tag: "auth", Summary: "吊销个人访问令牌", Auth: true,
+			Response: handlers.MessageResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/auth/sessions", Tag: "auth", Summary: "列出当前用户的登录会话（设备、IP、最近活动时间）", Auth: true,
+			Response: []handlers.SessionResponse{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/auth/sessions/:id", Tag: "auth", Summary: "吊销一个登录会话，其访问令牌和刷新令牌立即失效", Auth: true,
+			StringParams: []string{"id"},
+			Response:     handlers.MessageResponse{},
+			Errors:       []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/auth/sessions", Tag: "auth", Summary: "吊销所有登录会话，keep_current=true 时只退出其他设备", Auth: true,
+			Query: handlers.RevokeSessionsQuery{}, Response: handlers.MessageResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+
+		// 文章
+		{
+			Method: http.MethodGet, Path: "/api/posts", Tag: "posts", Summary: "获取文章列表（分页、排序、过滤）；登录后还包括自己的草稿、定时和已归档文章",
+			Auth: true, OptionalAuth: true, Scope: rbac.ScopeRead,
+			Query: handlers.ListPostsQuery{}, Response: []handlers.PostResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/posts/:id", Tag: "posts", Summary: "获取文章详情（含评论、渲染后的 HTML、目录、字数和阅读时间）；未发布的文章只有作者本人可以获取",
+			Auth: true, OptionalAuth: true, Scope: rbac.ScopeRead,
+			Response: handlers.PostDetailResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/posts/by-slug/:slug", Tag: "posts", Summary: "按 slug 获取文章详情；文章改名前的旧 slug 返回 301，Location 指向当前的 slug",
+			Auth: true, OptionalAuth: true, Scope: rbac.ScopeRead, StringParams: []string{"slug"},
+			Response: handlers.PostDetailResponse{},
+			Errors:   []int{http.StatusMovedPermanently, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/posts", Tag: "posts", Summary: "创建文章（需要 post:create 权限，reader 不能发文）；可以保存为草稿或定时发布", Auth: true, Scope: rbac.ScopePostsWrite,
+			Request: handlers.CreatePostRequest{}, Response: handlers.CreatePostResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/posts/:id", Tag: "posts", Summary: "更新文章（作者本人，或拥有 post:update:any 权限的编辑、管理员）", Auth: true, Scope: rbac.ScopePostsWrite,
+			Request: handlers.UpdatePostRequest{}, Response: handlers.UpdatePostResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/posts/:id", Tag: "posts", Summary: "删除文章（作者本人，或拥有 post:delete:any 权限的编辑、管理员）", Auth: true, Scope: rbac.ScopePostsWrite,
+			Response: handlers.MessageResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/posts/:id/revisions", Tag: "posts", Summary: "获取文章的版本历史（可以修改该文章的用户）", Auth: true, Scope: rbac.ScopeRead,
+			Response: []handlers.RevisionResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/posts/:id/revisions/diff", Tag: "posts", Summary: "按行或按词比较文章的两个版本", Auth: true, Scope: rbac.ScopeRead,
+			Query: handlers.DiffRevisionsQuery{}, Response: handlers.RevisionDiffResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/posts/:id/revisions/:revision/restore", Tag: "posts", Summary: "把文章恢复为指定版本，保存为一个新版本", Auth: true, Scope: rbac.ScopePostsWrite,
+			Request: handlers.RestoreRevisionRequest{}, Response: handlers.UpdatePostResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+
+		// 分类和标签
+		{
+			Method: http.MethodGet, Path: "/api/categories", Tag: "taxonomy", Summary: "分类树，已发布的文章数包括子分类下的文章",
+			Auth: true, OptionalAuth: true, Scope: rbac.ScopeRead,
+			Response: []handlers.CategoryResponse{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/categories", Tag: "taxonomy", Summary: "创建分类（需要 category:manage 权限）", Auth: true,
+			Request: handlers.CategoryRequest{}, Response: handlers.CategoryResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/categories/:id", Tag: "taxonomy", Summary: "修改分类名称或父分类（需要 category:manage 权限）", Auth: true,
+			Request: handlers.CategoryRequest{}, Response: handlers.CategoryResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/categories/:id", Tag: "taxonomy", Summary: "删除没有子分类的分类，其下文章变为未分类（需要 category:manage 权限）", Auth: true,
+			Response: handlers.MessageResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/tags", Tag: "taxonomy", Summary: "有已发布文章使用的标签，按文章数倒序",
+			Auth: true, OptionalAuth: true, Scope: rbac.ScopeRead,
+			Response: []handlers.TagResponse{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+
+		// 评论
+		{
+			Method: http.MethodGet, Path: "/api/posts/:id/comments", Tag: "comments", Summary: "获取文章评论；未发布文章的评论只有作者本人可以获取",
+			Auth: true, OptionalAuth: true, Scope: rbac.ScopeRead,
+			Response: []handlers.CommentResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/posts/:id/comments", Tag: "comments", Summary: "发表评论，只能评论已发布的文章", Auth: true, Scope: rbac.ScopeCommentsWrite,
+			Request: handlers.CreateCommentRequest{}, Response: handlers.CreateCommentResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/posts/:id/comments/:commentId", Tag: "comments", Summary: "删除评论（评论者本人，或拥有 comment:delete:any 权限的编辑、管理员）", Auth: true, Scope: rbac.ScopeCommentsWrite,
+			Response: handlers.MessageResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+
+		// 管理
+		{
+			Method: http.MethodPut, Path: "/api/admin/users/:id/role", Tag: "admin", Summary: "修改用户角色（需要 user:manage 权限，不能修改自己的角色）", Auth: true,
+			Request: handlers.SetRoleRequest{}, Response: handlers.UserResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/admin/users/:id/status", Tag: "admin", Summary: "禁用或启用用户（需要 user:manage 权限，不能禁用自己）；被禁用的用户不能登录，已签发的令牌随即失效", Auth: true,
+			Request: handlers.SetStatusRequest{}, Response: handlers.UserResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPost, Path: "/api/admin/users/:id/unlock", Tag: "admin", Summary: "解除用户的登录锁定并清零失败次数（需要 user:manage 权限）", Auth: true,
+			Response: handlers.UserResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/admin/login-events", Tag: "admin", Summary: "最近的登录锁定和解锁事件（需要 user:manage 权限）", Auth: true,
+			Query: handlers.LoginEventsQuery{}, Response: []handlers.LoginEventResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/admin/roles", Tag: "admin", Summary: "列出角色的权限和安全策略（需要 user:manage 权限）", Auth: true,
+			Response: []handlers.RolePolicyResponse{},
+			Errors:   []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodPut, Path: "/api/admin/roles/:role", Tag: "admin", Summary: "设置角色是否要求两步验证（需要 user:manage 权限）；要求后该角色未启用两步验证的用户只能访问启用两步验证的接口", Auth: true,
+			Request: handlers.SetRolePolicyRequest{}, Response: handlers.RolePolicyResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/admin/posts/:id", Tag: "admin", Summary: "下架任意文章（需要 post:delete:any 权限）", Auth: true,
+			Response: handlers.MessageResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodDelete, Path: "/api/admin/comments/:commentId", Tag: "admin", Summary: "删除任意评论（需要 comment:delete:any 权限）", Auth: true,
+			Response: handlers.MessageResponse{},
+			Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		},
+
+		// 检索
+		{
+			Method: http.MethodGet, Path: "/api/search", Tag: "search", Summary: "全文检索文章和评论，按相关度排序并高亮命中词",
+			Auth: true, OptionalAuth: true, Scope: rbac.ScopeRead,
+			Query: handlers.SearchQuery{}, Response: []handlers.SearchHitResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError},
+		},
+		// 系统
+		{
+			Method: http.MethodGet, Path: "/health", Tag: "system", Summary: "健康检查（兼容旧版本）",
+			Response: handlers.HealthResponse{}, Raw: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/livez", Tag: "system", Summary: "存活检查",
+			Response: handlers.LivenessResponse{}, Raw: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/readyz", Tag: "system", Summary: "就绪检查：数据库、迁移版本、连接池和后台任务",
+			Response: handlers.ReadinessResponse{}, Raw: true,
+			Errors: []int{http.StatusServiceUnavailable},
+		},
+
+		// 密钥
+		{
+			Method: http.MethodGet, Path: "/.well-known/jwks.json", Tag: "auth", Summary: "JWKS：验证访问令牌的公钥，令牌头中的 kid 对应 keys[].kid",
+			Response: utils.JWKSet{}, Raw: true,
+		},
+
+		// 文档
+		{
+			Method: http.MethodGet, Path: "/api/openapi", Tag: "docs", Summary: "OpenAPI 文档，Accept 或 ?format= 选择 JSON/YAML",
+			Raw: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/openapi.json", Tag: "docs", Summary: "OpenAPI 文档（JSON）",
+			Raw: true,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/openapi.yaml", Tag: "docs", Summary: "OpenAPI 文档（YAML）",
+			Raw: true, ContentType: contentYAML,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/docs", Tag: "docs", Summary: "Swagger UI",
+			Raw: true, ContentType: contentHTML,
+		},
+		{
+			Method: http.MethodGet, Path: "/api/docs/assets/:file", Tag: "docs", Summary: "Swagger UI 的脚本和样式",
+			Raw: true, ContentType: "application/octet-stream", StringParams: []string{"file"},
+			Errors: []int{http.StatusNotFound},
+		},
+	}
+}
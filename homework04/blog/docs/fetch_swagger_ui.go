@@ -0,0 +1,114 @@
+//go:build ignore
+
+// fetch_swagger_ui 从 npm 下载 swagger-ui-dist，校验 integrity 后把页面用到的文件解压到 swagger-ui/；
+// 由 go generate ./docs 调用，升级版本时修改 version 并重新生成，生成的文件随代码提交
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	version = "5.11.0"
+	dir     = "swagger-ui"
+)
+
+// files 需要提交的文件，swagger.html 引用前两个
+var files = []string{"swagger-ui.css", "swagger-ui-bundle.js", "LICENSE", "NOTICE"}
+
+func main() {
+	var meta struct {
+		Dist struct {
+			Tarball   string `json:"tarball"`
+			Integrity string `json:"integrity"`
+		} `json:"dist"`
+	}
+	data, err := get("https://registry.npmjs.org/swagger-ui-dist/" + version)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		log.Fatalf("failed to parse package metadata: %v", err)
+	}
+
+	tarball, err := get(meta.Dist.Tarball)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sum := sha512.Sum512(tarball)
+	if got := "sha512-" + base64.StdEncoding.EncodeToString(sum[:]); got != meta.Dist.Integrity {
+		log.Fatalf("integrity mismatch: got %s, want %s", got, meta.Dist.Integrity)
+	}
+
+	if err := extract(tarball); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "VERSION"), []byte(version+"\n"), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func get(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// extract 解压 tarball 中 package/ 目录下需要的文件
+func extract(tarball []byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return fmt.Errorf("failed to read tarball: %w", err)
+	}
+	wanted := map[string]bool{}
+	for _, name := range files {
+		wanted["package/"+name] = true
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tarball: %w", err)
+		}
+		if !wanted[header.Name] {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		name := strings.TrimPrefix(header.Name, "package/")
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+		delete(wanted, header.Name)
+	}
+	for name := range wanted {
+		// NOTICE 不是每个版本都有
+		if name != "package/NOTICE" {
+			return fmt.Errorf("%s not found in tarball", name)
+		}
+	}
+	return nil
+}
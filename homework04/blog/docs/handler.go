@@ -0,0 +1,113 @@
+package docs
+
+import (
+	"blog/utils"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+//go:generate go run fetch_swagger_ui.go
+
+//go:embed swagger.html
+var swaggerHTML []byte
+
+// swaggerUI 本地提供的 swagger-ui-dist 文件，页面不从 CDN 加载脚本；版本见 swagger-ui/VERSION
+//
+//go:embed swagger-ui
+var swaggerUI embed.FS
+
+// Handler 提供 OpenAPI 文档和 Swagger UI
+type Handler struct {
+	json []byte
+	yaml []byte
+}
+
+// NewHandler 预先序列化文档，请求时直接返回
+func NewHandler(doc *Document) (*Handler, error) {
+	jsonData, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	yamlData, err := jsonToYAML(jsonData)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{json: jsonData, yaml: yamlData}, nil
+}
+
+// Spec 根据 ?format= 或 Accept 头返回 JSON 或 YAML
+func (h *Handler) Spec(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" && strings.Contains(c.GetHeader("Accept"), "yaml") {
+		format = "yaml"
+	}
+	if format == "yaml" {
+		h.YAML(c)
+		return
+	}
+	h.JSON(c)
+}
+
+// JSON 返回 JSON 格式的文档
+func (h *Handler) JSON(c *gin.Context) {
+	c.Data(http.StatusOK, contentJSON+"; charset=utf-8", h.json)
+}
+
+// YAML 返回 YAML 格式的文档
+func (h *Handler) YAML(c *gin.Context) {
+	c.Data(http.StatusOK, contentYAML+"; charset=utf-8", h.yaml)
+}
+
+// UI 返回 Swagger UI 页面
+func (h *Handler) UI(c *gin.Context) {
+	c.Data(http.StatusOK, contentHTML+"; charset=utf-8", swaggerHTML)
+}
+
+// Asset 返回 Swagger UI 页面使用的脚本和样式；文件随版本固定，允许客户端缓存一天
+func (h *Handler) Asset(c *gin.Context) {
+	name := c.Param("file")
+	data, err := swaggerUI.ReadFile(path.Join("swagger-ui", name))
+	if err != nil {
+		utils.NotFound(c, "Asset not found")
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// jsonToYAML JSON 是 YAML 的子集：按 YAML 解析后清除流式风格，保留键的顺序输出块风格 YAML
+func jsonToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearStyle(&node)
+
+	var out bytes.Buffer
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// clearStyle 清除节点风格；"200" 这类会被误解析的字符串，编码器会自动加引号
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
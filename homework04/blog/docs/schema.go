@@ -0,0 +1,248 @@
+package docs
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaRegistry 通过反射把 Go 类型转换为 Schema，具名结构体放入 components/schemas
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// schemaFor 返回类型 t 的 Schema，具名结构体返回 $ref
+// request 为 true 时按请求结构处理：只有 binding:"required" 的字段是必填；
+// 否则按响应结构处理：没有 omitempty 的非指针字段总会出现，视为必填
+func (r *schemaRegistry) schemaFor(t reflect.Type, request bool) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64", Minimum: float(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaFor(t.Elem(), request)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaFor(t.Elem(), request)}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t, request)
+		}
+		return refTo(r.register(t, request))
+	default:
+		return &Schema{}
+	}
+}
+
+// register 将具名结构体加入 components/schemas，返回其名称
+func (r *schemaRegistry) register(t reflect.Type, request bool) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := r.schemas[name]; taken {
+		// 不同包中的同名类型，加上包名区分
+		pkg := t.PkgPath()
+		if i := strings.LastIndex(pkg, "/"); i >= 0 {
+			pkg = pkg[i+1:]
+		}
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+
+	// 先占位，支持自引用的结构体
+	r.names[t] = name
+	r.schemas[name] = &Schema{}
+	*r.schemas[name] = *r.structSchema(t, request)
+	return name
+}
+
+// structSchema 根据 json 和 binding 标签生成结构体的 Schema
+func (r *schemaRegistry) structSchema(t reflect.Type, request bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(schema, t, request, false)
+	return schema
+}
+
+// optional 为 true 时字段来自嵌入的结构体指针，响应中可能整体缺失，都不是必填
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type, request, optional bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitempty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		// 匿名嵌入且没有 json 名称的结构体，字段提升到外层
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			ft, embeddedPtr := field.Type, false
+			if ft.Kind() == reflect.Ptr {
+				ft, embeddedPtr = ft.Elem(), true
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(schema, ft, request, optional || embeddedPtr)
+				continue
+			}
+		}
+
+		isPtr := field.Type.Kind() == reflect.Ptr
+		property := r.schemaFor(field.Type, request)
+		required := applyBinding(property, field)
+		if isPtr && property.Ref == "" {
+			property.Nullable = true
+		}
+		if !request {
+			required = !omitempty && !isPtr
+		}
+		if desc := field.Tag.Get("doc"); desc != "" {
+			property = withDescription(property, desc)
+		}
+
+		schema.Properties[name] = property
+		if required && !optional {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// withDescription $ref 不能与其他属性并列，使用 allOf 包一层
+func withDescription(schema *Schema, desc string) *Schema {
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Description: desc}
+	}
+	schema.Description = desc
+	return schema
+}
+
+// jsonName 解析 json 标签
+func jsonName(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+// applyBinding 把 gin binding 标签中的校验规则映射到 Schema，返回字段是否必填
+// dive 之后的规则作用于数组元素
+func applyBinding(schema *Schema, field reflect.StructField) bool {
+	tag := field.Tag.Get("binding")
+	if tag == "" {
+		return false
+	}
+
+	required, dived := false, false
+	elem := field.Type
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	kind := elem.Kind()
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			if schema.Items == nil {
+				return required
+			}
+			schema, elem, dived = schema.Items, elem.Elem(), true
+			kind = elem.Kind()
+		case "required":
+			required = !dived
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "min", "gte":
+			setBound(schema, kind, value, true)
+		case "max", "lte":
+			setBound(schema, kind, value, false)
+		case "len":
+			setBound(schema, kind, value, true)
+			setBound(schema, kind, value, false)
+		}
+	}
+	return required
+}
+
+// setBound 根据字段类型设置长度、数值或元素个数的上下限
+func setBound(schema *Schema, kind reflect.Kind, value string, lower bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+	switch kind {
+	case reflect.String:
+		if lower {
+			schema.MinLength = intPtr(int(n))
+		} else {
+			schema.MaxLength = intPtr(int(n))
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if lower {
+			schema.MinItems = intPtr(int(n))
+		} else {
+			schema.MaxItems = intPtr(int(n))
+		}
+	default:
+		if lower {
+			schema.Minimum = float(n)
+		} else {
+			schema.Maximum = float(n)
+		}
+	}
+}
+
+func intPtr(n int) *int { return &n }
+
+func float(n float64) *float64 { return &n }
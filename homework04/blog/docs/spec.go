@@ -0,0 +1,225 @@
+package docs
+
+import (
+	"blog/rbac"
+	"blog/utils"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	contentJSON = "application/json"
+	contentYAML = "application/yaml"
+	contentHTML = "text/html"
+
+	bearerAuth    = "bearerAuth"
+	personalToken = "personalToken"
+)
+
+// Endpoint 描述一个路由，Build 根据它生成 OpenAPI 操作
+type Endpoint struct {
+	Method  string
+	Path    string // gin 风格路径，如 /api/posts/:id
+	Summary string
+	Tag     string
+	Auth    bool
+	// OptionalAuth 与 Auth 一起使用：也可以不登录访问，登录后返回的内容不同
+	OptionalAuth bool
+	// Scope 接受个人访问令牌时要求的 scope，为空表示只接受 JWT
+	Scope rbac.Scope
+
+	// Request 请求体结构体的零值，nil 表示没有请求体
+	Request interface{}
+	// Query 查询参数结构体的零值，字段使用 form 标签
+	Query interface{}
+	// Response 成功时 data 字段的类型；Raw 为 true 时是整个响应体的类型
+	Response interface{}
+	// Raw 响应不使用 utils.Response 统一结构
+	Raw bool
+	// ContentType 成功响应的内容类型，默认 application/json
+	ContentType string
+	// StringParams 类型为字符串的路径参数，其余路径参数均为整数 ID
+	StringParams []string
+	// Errors 可能返回的错误状态码
+	Errors []int
+}
+
+// Key 路由的唯一标识，如 "GET /api/posts/:id"
+func (e Endpoint) Key() string {
+	return e.Method + " " + e.Path
+}
+
+// Build 根据所有 Endpoint 生成 OpenAPI 文档
+func Build() *Document {
+	schemas := newSchemaRegistry()
+	envelope := schemas.schemaFor(reflect.TypeOf(utils.Response{}), false)
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Blog API",
+			Description: "个人博客系统后端接口。除健康检查和文档接口外，响应均使用 {code, message, data} 统一结构。",
+			Version:     "1.0.0",
+		},
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			Schemas: schemas.schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				personalToken: {Type: "http", Scheme: "bearer", BearerFormat: "blog_pat_",
+					Description: "个人访问令牌，只能访问声明了 scope 的接口"},
+			},
+		},
+	}
+
+	for _, endpoint := range Endpoints() {
+		path, params := openAPIPath(endpoint)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(endpoint.Method)] = buildOperation(schemas, envelope, endpoint, params)
+	}
+	return doc
+}
+
+// Has 判断文档中是否包含 gin 风格的 method + path
+func (d *Document) Has(method, path string) bool {
+	item, ok := d.Paths[toOpenAPIPath(path)]
+	if !ok {
+		return false
+	}
+	_, ok = (*item)[strings.ToLower(method)]
+	return ok
+}
+
+func buildOperation(schemas *schemaRegistry, envelope *Schema, e Endpoint, params []Parameter) *Operation {
+	op := &Operation{
+		Summary:     e.Summary,
+		OperationID: operationID(e),
+		Parameters:  append(params, queryParameters(schemas, e.Query)...),
+		Responses:   map[string]*Response{},
+	}
+	if e.Tag != "" {
+		op.Tags = []string{e.Tag}
+	}
+	if e.Auth {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+	if e.Scope != "" {
+		op.Security = append(op.Security, map[string][]string{personalToken: {}})
+		op.Description = "个人访问令牌需要 scope " + string(e.Scope)
+	}
+	if e.OptionalAuth {
+		// 空的安全要求表示允许匿名访问
+		op.Security = append(op.Security, map[string][]string{})
+	}
+
+	if e.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				contentJSON: {Schema: schemas.schemaFor(reflect.TypeOf(e.Request), true)},
+			},
+		}
+	}
+
+	contentType := e.ContentType
+	if contentType == "" {
+		contentType = contentJSON
+	}
+	success := &Response{Description: http.StatusText(http.StatusOK)}
+	switch {
+	case e.Response == nil:
+		success.Content = map[string]*MediaType{contentType: {Schema: &Schema{}}}
+	case e.Raw:
+		success.Content = map[string]*MediaType{contentType: {Schema: schemas.schemaFor(reflect.TypeOf(e.Response), false)}}
+	default:
+		data := schemas.schemaFor(reflect.TypeOf(e.Response), false)
+		success.Content = map[string]*MediaType{contentType: {Schema: &Schema{
+			AllOf: []*Schema{envelope, {
+				Type:       "object",
+				Properties: map[string]*Schema{"data": data},
+				Required:   []string{"data"},
+			}},
+		}}}
+	}
+	op.Responses[strconv.Itoa(http.StatusOK)] = success
+
+	for _, code := range e.Errors {
+		resp := &Response{Description: http.StatusText(code)}
+		if !e.Raw {
+			resp.Content = map[string]*MediaType{contentJSON: {Schema: envelope}}
+		} else if e.Response != nil {
+			resp.Content = success.Content
+		}
+		op.Responses[strconv.Itoa(code)] = resp
+	}
+	return op
+}
+
+// openAPIPath 把 gin 路径参数 :id 转换为 {id}，并生成路径参数
+func openAPIPath(e Endpoint) (string, []Parameter) {
+	var params []Parameter
+	for _, segment := range strings.Split(e.Path, "/") {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := segment[1:]
+		schema := &Schema{Type: "integer", Format: "int64", Minimum: float(1)}
+		for _, p := range e.StringParams {
+			if p == name {
+				schema = &Schema{Type: "string"}
+			}
+		}
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	return toOpenAPIPath(e.Path), params
+}
+
+func toOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// queryParameters 根据结构体的 form 标签生成查询参数
+func queryParameters(schemas *schemaRegistry, query interface{}) []Parameter {
+	if query == nil {
+		return nil
+	}
+	t := reflect.TypeOf(query)
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := schemas.schemaFor(field.Type, true)
+		required := applyBinding(schema, field)
+		params = append(params, Parameter{
+			Name:        name,
+			In:          "query",
+			Description: field.Tag.Get("doc"),
+			Required:    required,
+			Schema:      schema,
+		})
+	}
+	sort.SliceStable(params, func(i, j int) bool { return params[i].Required && !params[j].Required })
+	return params
+}
+
+// operationID 由方法和路径生成，如 GET /api/posts/:id -> get_api_posts_id
+func operationID(e Endpoint) string {
+	replacer := strings.NewReplacer("/", "_", ":", "", ".", "_", "-", "_")
+	return strings.ToLower(e.Method) + strings.TrimRight(replacer.Replace(e.Path), "_")
+}
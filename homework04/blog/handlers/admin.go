@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"blog/middleware"
+	"blog/rbac"
+	"blog/repository"
+	"blog/services"
+	"blog/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理员接口，路由上需要 user:manage 权限
+type AdminHandler struct {
+	users     *services.UserService
+	twoFactor *services.TwoFactorService
+	guard     *services.LoginGuard
+}
+
+func NewAdminHandler(users *services.UserService, twoFactor *services.TwoFactorService, guard *services.LoginGuard) *AdminHandler {
+	return &AdminHandler{users: users, twoFactor: twoFactor, guard: guard}
+}
+
+// SetRoleRequest 修改用户角色请求结构体
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin editor author reader"`
+}
+
+// SetStatusRequest 禁用或启用用户请求结构体
+type SetStatusRequest struct {
+	Disabled *bool `json:"disabled" binding:"required" doc:"true 禁用，false 启用"`
+}
+
+// SetRolePolicyRequest 修改角色安全策略请求结构体
+type SetRolePolicyRequest struct {
+	RequireTwoFactor *bool `json:"require_two_factor" binding:"required" doc:"true 要求该角色的用户启用两步验证"`
+}
+
+// LoginEventsQuery 登录锁定事件查询参数
+type LoginEventsQuery struct {
+	Username string `form:"username" doc:"只返回该用户名的事件"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=200" doc:"返回条数，默认 50"`
+}
+
+// SetUserRole 修改用户角色
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.users.SetRole(c.Request.Context(), middleware.GetActorFromContext(c), uint(id), rbac.Role(req.Role))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			utils.NotFound(c, "User not found")
+		case errors.Is(err, services.ErrForbidden):
+			utils.Forbidden(c, "Permission denied")
+		case errors.Is(err, services.ErrInvalidRole):
+			utils.BadRequest(c, "Invalid role")
+		case errors.Is(err, services.ErrOwnRole):
+			utils.BadRequest(c, "You cannot change your own role")
+		default:
+			utils.InternalServerError(c, "Failed to update user role")
+		}
+		return
+	}
+
+	utils.Success(c, newUserResponse(user))
+}
+
+// SetUserStatus 禁用或启用用户
+func (h *AdminHandler) SetUserStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	var req SetStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.users.SetDisabled(c.Request.Context(), middleware.GetActorFromContext(c), uint(id), *req.Disabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			utils.NotFound(c, "User not found")
+		case errors.Is(err, services.ErrForbidden):
+			utils.Forbidden(c, "Permission denied")
+		case errors.Is(err, services.ErrOwnAccount):
+			utils.BadRequest(c, "You cannot disable your own account")
+		default:
+			utils.InternalServerError(c, "Failed to update user status")
+		}
+		return
+	}
+
+	utils.Success(c, newUserResponse(user))
+}
+
+// ListRoles 列出所有角色的权限和安全策略
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	policies, err := h.twoFactor.RolePolicies(c.Request.Context(), middleware.GetActorFromContext(c))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			utils.Forbidden(c, "Permission denied")
+		} else {
+			utils.InternalServerError(c, "Failed to list roles")
+		}
+		return
+	}
+
+	roles := make([]RolePolicyResponse, len(policies))
+	for i, policy := range policies {
+		roles[i] = newRolePolicyResponse(policy)
+	}
+	utils.Success(c, roles)
+}
+
+// SetRolePolicy 修改角色的安全策略
+func (h *AdminHandler) SetRolePolicy(c *gin.Context) {
+	var req SetRolePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	policy, err := h.twoFactor.SetTwoFactorRequired(c.Request.Context(), middleware.GetActorFromContext(c), rbac.Role(c.Param("role")), *req.RequireTwoFactor)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			utils.Forbidden(c, "Permission denied")
+		case errors.Is(err, services.ErrInvalidRole):
+			utils.NotFound(c, "Role not found")
+		default:
+			utils.InternalServerError(c, "Failed to update role policy")
+		}
+		return
+	}
+
+	utils.Success(c, newRolePolicyResponse(*policy))
+}
+
+// UnlockUser 解除用户的登录锁定并清零失败次数
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	user, err := h.guard.Unlock(c.Request.Context(), middleware.GetActorFromContext(c), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			utils.NotFound(c, "User not found")
+		case errors.Is(err, services.ErrForbidden):
+			utils.Forbidden(c, "Permission denied")
+		default:
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to unlock user")
+		}
+		return
+	}
+
+	utils.Success(c, newUserResponse(user))
+}
+
+// LoginEvents 最近的登录锁定和解锁事件
+func (h *AdminHandler) LoginEvents(c *gin.Context) {
+	var query LoginEventsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	events, err := h.guard.Events(c.Request.Context(), middleware.GetActorFromContext(c), repository.LoginEventFilter{
+		Username: query.Username,
+		Limit:    query.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			utils.Forbidden(c, "Permission denied")
+		} else {
+			utils.InternalServerError(c, "Failed to list login events")
+		}
+		return
+	}
+
+	responses := make([]LoginEventResponse, len(events))
+	for i, event := range events {
+		responses[i] = newLoginEventResponse(event)
+	}
+	utils.Success(c, responses)
+}
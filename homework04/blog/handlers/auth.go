@@ -1,19 +1,24 @@
 package handlers
 
 import (
-	"blog/models"
+	"blog/middleware"
+	"blog/services"
 	"blog/utils"
+	"errors"
+	"math"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
-	db *gorm.DB
+	auth     *services.AuthService
+	tokens   *services.TokenService
+	accounts *services.AccountService
 }
 
-func NewAuthHandler(db *gorm.DB) *AuthHandler {
-	return &AuthHandler{db: db}
+func NewAuthHandler(auth *services.AuthService, tokens *services.TokenService, accounts *services.AccountService) *AuthHandler {
+	return &AuthHandler{auth: auth, tokens: tokens, accounts: accounts}
 }
 
 // RegisterRequest 注册请求结构体
@@ -29,6 +34,52 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshRequest 刷新令牌请求结构体
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest 登出请求结构体，提供刷新令牌时一并吊销
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" doc:"可选，同时吊销该刷新令牌所属的登录会话"`
+}
+
+// VerifyEmailRequest 邮箱验证请求结构体
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" doc:"验证邮件链接中的 token 参数"`
+}
+
+// ForgotPasswordRequest 找回密码请求结构体
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求结构体
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" doc:"重置密码邮件链接中的 token 参数"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// UpdateProfileRequest 修改个人资料请求结构体，各字段整体替换
+type UpdateProfileRequest struct {
+	Email       string `json:"email" binding:"required,email,max=100" doc:"修改后需要重新验证，并向新邮箱发送验证邮件"`
+	DisplayName string `json:"display_name" binding:"max=50"`
+	Bio         string `json:"bio" binding:"max=500"`
+	AvatarURL   string `json:"avatar_url" binding:"omitempty,http_url,max=255" doc:"头像图片的 http(s) 地址"`
+	Password    string `json:"password" doc:"修改邮箱时必填，当前密码"`
+}
+
+// ChangePasswordRequest 修改密码请求结构体
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// DeleteAccountRequest 注销账号请求结构体
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required" doc:"当前密码"`
+}
+
 // Register 用户注册
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req RegisterRequest
@@ -37,40 +88,29 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// 检查用户名是否已存在
-	var existingUser models.User
-	if err := h.db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		utils.BadRequest(c, "Username already exists")
-		return
-	}
-
-	// 检查邮箱是否已存在
-	if err := h.db.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		utils.BadRequest(c, "Email already exists")
-		return
-	}
-
-	// 创建用户
-	user := models.User{
+	user, err := h.auth.Register(c.Request.Context(), services.RegisterInput{
 		Username: req.Username,
 		Email:    req.Email,
-	}
-
-	if err := user.HashPassword(req.Password); err != nil {
-		utils.InternalServerError(c, "Failed to hash password")
+		Password: req.Password,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUsernameTaken):
+			utils.BadRequest(c, "Username already exists")
+		case errors.Is(err, services.ErrEmailTaken):
+			utils.BadRequest(c, "Email already exists")
+		default:
+			utils.InternalServerError(c, "Failed to create user")
+		}
 		return
 	}
 
-	if err := h.db.Create(&user).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create user")
-		return
+	// 验证邮件发送失败不影响注册，用户可以登录后重新发送
+	if err := h.accounts.SendVerification(c.Request.Context(), user); err != nil {
+		_ = c.Error(err)
 	}
 
-	utils.Success(c, gin.H{
-		"id":       user.ID,
-		"username": user.Username,
-		"email":    user.Email,
-	})
+	utils.Success(c, newUserResponse(user))
 }
 
 // Login 用户登录
@@ -81,58 +121,301 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 查找用户
-	var user models.User
-	if err := h.db.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	result, err := h.auth.Login(c.Request.Context(), services.LoginInput{
+		Username:   req.Username,
+		Password:   req.Password,
+		ClientInfo: clientInfo(c),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrLoginThrottled):
+			loginThrottled(c, err)
+		case errors.Is(err, services.ErrInvalidCredentials):
 			utils.Unauthorized(c, "Invalid username or password")
-		} else {
+		case errors.Is(err, services.ErrAccountDisabled):
+			utils.Forbidden(c, "Account is disabled")
+		default:
 			utils.InternalServerError(c, "Database error")
 		}
 		return
 	}
 
-	// 验证密码
-	if err := user.CheckPassword(req.Password); err != nil {
-		utils.Unauthorized(c, "Invalid username or password")
+	// 启用了两步验证：不返回令牌和用户信息，客户端用 challenge_token 和验证码调用 /api/auth/login/2fa
+	if result.Challenge != nil {
+		utils.Success(c, LoginResponse{
+			TwoFactorRequired:  true,
+			ChallengeToken:     result.Challenge.Token,
+			ChallengeExpiresIn: int64(result.Challenge.ExpiresIn.Seconds()),
+		})
+		return
+	}
+
+	utils.Success(c, newLoginResponse(result.Tokens, result.User))
+}
+
+// loginThrottled 返回 429 和 Retry-After 头
+func loginThrottled(c *gin.Context, err error) {
+	var throttled *services.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		utils.TooManyRequests(c, "Too many failed login attempts, please try again later")
 		return
 	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	if throttled.Locked {
+		utils.TooManyRequests(c, "Too many failed login attempts, login is temporarily locked")
+	} else {
+		utils.TooManyRequests(c, "Too many failed login attempts, please wait before retrying")
+	}
+}
 
-	// 生成JWT令牌
-	token, err := utils.GenerateToken(user.ID)
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tokens, err := h.tokens.Refresh(c.Request.Context(), req.RefreshToken, clientInfo(c))
 	if err != nil {
-		utils.InternalServerError(c, "Failed to generate token")
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			utils.Unauthorized(c, "Refresh token reuse detected, please log in again")
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			utils.Unauthorized(c, "Invalid refresh token")
+		case errors.Is(err, services.ErrAccountDisabled):
+			utils.Forbidden(c, "Account is disabled")
+		default:
+			utils.InternalServerError(c, "Failed to refresh token")
+		}
 		return
 	}
 
-	utils.Success(c, gin.H{
-		"token": token,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
-	})
+	utils.Success(c, newTokenResponse(tokens))
+}
+
+// Logout 登出：结束当前会话，吊销当前访问令牌和会话的刷新令牌
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims := middleware.GetClaimsFromContext(c)
+	if claims == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req LogoutRequest
+	// 请求体可以为空
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	if err := h.tokens.Logout(c.Request.Context(), claims, req.RefreshToken); err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			utils.BadRequest(c, "Invalid refresh token")
+		} else {
+			utils.InternalServerError(c, "Failed to log out")
+		}
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Logged out successfully"})
 }
 
 // GetProfile 获取用户信息
 func (h *AuthHandler) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
 		utils.Unauthorized(c, "User not authenticated")
 		return
 	}
 
-	var user models.User
-	if err := h.db.First(&user, userID).Error; err != nil {
+	user, err := h.auth.Profile(c.Request.Context(), current.ID)
+	if err != nil {
 		utils.NotFound(c, "User not found")
 		return
 	}
 
-	utils.Success(c, gin.H{
-		"id":         user.ID,
-		"username":   user.Username,
-		"email":      user.Email,
-		"created_at": user.CreatedAt,
+	utils.Success(c, newProfileResponse(user))
+}
+
+// UpdateProfile 修改邮箱、显示名称、简介和头像
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	user, emailChanged, err := h.accounts.UpdateProfile(c.Request.Context(), current.ID, services.ProfileInput{
+		Email:       req.Email,
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		AvatarURL:   req.AvatarURL,
+		Password:    req.Password,
 	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrIncorrectPassword):
+			utils.BadRequest(c, "Current password is required to change email")
+		case errors.Is(err, services.ErrEmailTaken):
+			utils.BadRequest(c, "Email already exists")
+		default:
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to update profile")
+		}
+		return
+	}
+
+	// 与注册相同，验证邮件发送失败不影响修改结果，用户可以稍后重新发送
+	if emailChanged {
+		if err := h.accounts.SendVerification(c.Request.Context(), user); err != nil {
+			_ = c.Error(err)
+		}
+	}
+
+	utils.Success(c, newProfileResponse(user))
+}
+
+// ChangePassword 验证当前密码后修改密码，当前会话保留，其他登录会话全部退出
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	claims := middleware.GetClaimsFromContext(c)
+	if claims == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.accounts.ChangePassword(c.Request.Context(), claims.UserID, claims.SessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, services.ErrIncorrectPassword) {
+			utils.BadRequest(c, "Current password is incorrect")
+		} else {
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to change password")
+		}
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Password changed, other sessions have been logged out"})
+}
+
+// DeleteAccount 验证密码后注销账号，之后所有令牌立即失效
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	claims := middleware.GetClaimsFromContext(c)
+	if claims == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.accounts.DeleteAccount(c.Request.Context(), claims.UserID, req.Password); err != nil {
+		if errors.Is(err, services.ErrIncorrectPassword) {
+			utils.BadRequest(c, "Password is incorrect")
+		} else {
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to delete account")
+		}
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Account deleted"})
+}
+
+// VerifyEmail 用验证邮件中的令牌验证邮箱，令牌只能使用一次
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	user, err := h.accounts.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidActionToken) {
+			utils.BadRequest(c, "Invalid or expired token")
+		} else {
+			utils.InternalServerError(c, "Failed to verify email")
+		}
+		return
+	}
+
+	utils.Success(c, newUserResponse(user))
+}
+
+// ResendVerification 重新发送验证邮件
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.accounts.ResendVerification(c.Request.Context(), current.ID); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			utils.BadRequest(c, "Email already verified")
+		} else {
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to send verification email")
+		}
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Verification email sent"})
+}
+
+// ForgotPassword 发送重置密码邮件；无论邮箱是否注册都返回相同的响应
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.accounts.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		_ = c.Error(err)
+		utils.InternalServerError(c, "Failed to send password reset email")
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "If the email is registered, a password reset link has been sent"})
+}
+
+// ResetPassword 用重置密码邮件中的令牌设置新密码，成功后所有登录会话失效
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.accounts.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidActionToken):
+			utils.BadRequest(c, "Invalid or expired token")
+		case errors.Is(err, services.ErrAccountDisabled):
+			utils.Forbidden(c, "Account is disabled")
+		default:
+			utils.InternalServerError(c, "Failed to reset password")
+		}
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Password has been reset, please log in again"})
 }
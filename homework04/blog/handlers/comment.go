@@ -1,20 +1,21 @@
 package handlers
 
 import (
-	"blog/models"
+	"blog/middleware"
+	"blog/services"
 	"blog/utils"
+	"errors"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type CommentHandler struct {
-	db *gorm.DB
+	comments *services.CommentService
 }
 
-func NewCommentHandler(db *gorm.DB) *CommentHandler {
-	return &CommentHandler{db: db}
+func NewCommentHandler(comments *services.CommentService) *CommentHandler {
+	return &CommentHandler{comments: comments}
 }
 
 // CreateCommentRequest 创建评论请求结构体
@@ -24,8 +25,8 @@ type CreateCommentRequest struct {
 
 // CreateComment 创建评论
 func (h *CommentHandler) CreateComment(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	user := middleware.CurrentUserFromContext(c)
+	if user == nil {
 		utils.Unauthorized(c, "User not authenticated")
 		return
 	}
@@ -36,44 +37,32 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 		return
 	}
 
-	// 检查文章是否存在
-	var post models.Post
-	if err := h.db.First(&post, postID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			utils.NotFound(c, "Post not found")
-		} else {
-			utils.InternalServerError(c, "Failed to fetch post")
-		}
-		return
-	}
-
 	var req CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.BadRequest(c, err.Error())
 		return
 	}
 
-	comment := models.Comment{
-		Content: req.Content,
-		UserID:  userID.(uint),
-		PostID:  uint(postID),
-	}
-
-	if err := h.db.Create(&comment).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create comment")
+	comment, err := h.comments.Create(c.Request.Context(), user.ID, uint(postID), req.Content)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			utils.NotFound(c, "Post not found")
+		} else {
+			utils.InternalServerError(c, "Failed to create comment")
+		}
 		return
 	}
 
-	utils.Success(c, gin.H{
-		"id":         comment.ID,
-		"content":    comment.Content,
-		"user_id":    comment.UserID,
-		"post_id":    comment.PostID,
-		"created_at": comment.CreatedAt,
+	utils.Success(c, CreateCommentResponse{
+		ID:        comment.ID,
+		Content:   comment.Content,
+		UserID:    comment.UserID,
+		PostID:    comment.PostID,
+		CreatedAt: comment.CreatedAt,
 	})
 }
 
-// GetComments 获取文章评论
+// GetComments 获取文章评论，文章未发布时只有作者本人可以获取
 func (h *CommentHandler) GetComments(c *gin.Context) {
 	postID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -81,43 +70,23 @@ func (h *CommentHandler) GetComments(c *gin.Context) {
 		return
 	}
 
-	// 检查文章是否存在
-	var post models.Post
-	if err := h.db.First(&post, postID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	comments, err := h.comments.ListByPost(c.Request.Context(), middleware.CurrentUserID(c), uint(postID))
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
 			utils.NotFound(c, "Post not found")
 		} else {
-			utils.InternalServerError(c, "Failed to fetch post")
+			utils.InternalServerError(c, "Failed to fetch comments")
 		}
 		return
 	}
 
-	var comments []models.Comment
-	if err := h.db.Preload("User").Where("post_id = ?", postID).Order("created_at DESC").Find(&comments).Error; err != nil {
-		utils.InternalServerError(c, "Failed to fetch comments")
-		return
-	}
-
-	var response []gin.H
-	for _, comment := range comments {
-		response = append(response, gin.H{
-			"id":      comment.ID,
-			"content": comment.Content,
-			"user": gin.H{
-				"id":       comment.User.ID,
-				"username": comment.User.Username,
-			},
-			"created_at": comment.CreatedAt,
-		})
-	}
-
-	utils.Success(c, response)
+	utils.Success(c, newCommentResponses(comments))
 }
 
 // DeleteComment 删除评论
 func (h *CommentHandler) DeleteComment(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	user := middleware.CurrentUserFromContext(c)
+	if user == nil {
 		utils.Unauthorized(c, "User not authenticated")
 		return
 	}
@@ -127,28 +96,17 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	var comment models.Comment
-	if err := h.db.First(&comment, commentID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	if err := h.comments.Delete(c.Request.Context(), user.Actor(), uint(commentID)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
 			utils.NotFound(c, "Comment not found")
-		} else {
-			utils.InternalServerError(c, "Failed to fetch comment")
+		case errors.Is(err, services.ErrForbidden):
+			utils.Forbidden(c, "You do not have permission to delete this comment")
+		default:
+			utils.InternalServerError(c, "Failed to delete comment")
 		}
 		return
 	}
 
-	// 检查权限：只能删除自己的评论
-	if comment.UserID != userID {
-		utils.Forbidden(c, "You can only delete your own comments")
-		return
-	}
-
-	if err := h.db.Delete(&comment).Error; err != nil {
-		utils.InternalServerError(c, "Failed to delete comment")
-		return
-	}
-
-	utils.Success(c, gin.H{
-		"message": "Comment deleted successfully",
-	})
+	utils.Success(c, MessageResponse{Message: "Comment deleted successfully"})
 }
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"blog/migrations"
+	"blog/workers"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	statusOK        = "ok"
+	statusUnhealthy = "unhealthy"
+
+	// readinessPingTimeout 数据库 Ping 和读取迁移版本的超时时间
+	readinessPingTimeout = 2 * time.Second
+)
+
+type HealthHandler struct {
+	db      *gorm.DB
+	workers *workers.Manager
+}
+
+func NewHealthHandler(db *gorm.DB, workerManager *workers.Manager) *HealthHandler {
+	return &HealthHandler{db: db, workers: workerManager}
+}
+
+// Check 单项检查结果
+type Check struct {
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Detail interface{} `json:"detail,omitempty"`
+}
+
+// HealthResponse /health 的响应
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// LivenessResponse /livez 的响应
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessResponse /readyz 的响应
+type ReadinessResponse struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks"`
+}
+
+// Health 兼容旧版本的健康检查，始终返回 OK
+func (h *HealthHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{
+		Status:  "OK",
+		Message: "Blog API is running",
+	})
+}
+
+// Livez 存活检查：进程能处理请求即返回 200
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{Status: statusOK})
+}
+
+// Readyz 就绪检查：数据库、迁移版本、连接池和后台任务都正常时返回 200，否则返回 503
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	checks := map[string]Check{
+		"database":   h.checkDatabase(c.Request.Context()),
+		"migrations": h.checkMigrations(c.Request.Context()),
+		"workers":    h.checkWorkers(),
+	}
+
+	status, code := statusOK, http.StatusOK
+	for _, check := range checks {
+		if check.Status != statusOK {
+			status, code = statusUnhealthy, http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	c.JSON(code, ReadinessResponse{
+		Status: status,
+		Checks: checks,
+	})
+}
+
+// checkDatabase Ping 数据库并返回连接池统计
+func (h *HealthHandler) checkDatabase(ctx context.Context) Check {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return Check{Status: statusUnhealthy, Error: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessPingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	pingErr := sqlDB.PingContext(ctx)
+	stats := sqlDB.Stats()
+	detail := gin.H{
+		"latency_ms": time.Since(start).Milliseconds(),
+		"pool": gin.H{
+			"max_open":   stats.MaxOpenConnections,
+			"open":       stats.OpenConnections,
+			"in_use":     stats.InUse,
+			"idle":       stats.Idle,
+			"wait_count": stats.WaitCount,
+		},
+	}
+
+	if pingErr != nil {
+		return Check{Status: statusUnhealthy, Error: pingErr.Error(), Detail: detail}
+	}
+	return Check{Status: statusOK, Detail: detail}
+}
+
+// checkMigrations 比较数据库当前迁移版本与代码中最新的迁移版本
+func (h *HealthHandler) checkMigrations(ctx context.Context) Check {
+	var latest uint
+	if all := migrations.All(); len(all) > 0 {
+		latest = all[len(all)-1].Version
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessPingTimeout)
+	defer cancel()
+
+	current, err := migrations.CurrentVersion(h.db.WithContext(ctx))
+	if err != nil {
+		return Check{Status: statusUnhealthy, Error: err.Error()}
+	}
+
+	detail := gin.H{
+		"current_version": current,
+		"latest_version":  latest,
+	}
+	if current < latest {
+		return Check{Status: statusUnhealthy, Error: "database has pending migrations", Detail: detail}
+	}
+	return Check{Status: statusOK, Detail: detail}
+}
+
+// checkWorkers 任一后台任务失败即不健康
+func (h *HealthHandler) checkWorkers() Check {
+	statuses := h.workers.Statuses()
+	for _, status := range statuses {
+		if status.State == workers.StateFailed {
+			return Check{Status: statusUnhealthy, Error: "worker " + status.Name + " failed", Detail: statuses}
+		}
+	}
+	return Check{Status: statusOK, Detail: statuses}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"blog/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge JWKS 响应的缓存时间（秒）；新密钥生成后立即发布，客户端遇到未知 kid 时应重新获取
+const jwksMaxAge = "300"
+
+type JWKSHandler struct {
+	jwtManager *utils.JWTManager
+}
+
+func NewJWKSHandler(jwtManager *utils.JWTManager) *JWKSHandler {
+	return &JWKSHandler{jwtManager: jwtManager}
+}
+
+// JWKS 发布验证访问令牌所需的公钥（RFC 7517）；使用 HS256 时密钥不能公开，返回空集合
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age="+jwksMaxAge)
+	c.JSON(http.StatusOK, h.jwtManager.JWKS())
+}
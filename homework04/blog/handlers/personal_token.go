@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"blog/middleware"
+	"blog/rbac"
+	"blog/services"
+	"blog/utils"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalTokenHandler 个人访问令牌的创建、列出和吊销
+type PersonalTokenHandler struct {
+	tokens *services.PersonalTokenService
+}
+
+func NewPersonalTokenHandler(tokens *services.PersonalTokenService) *PersonalTokenHandler {
+	return &PersonalTokenHandler{tokens: tokens}
+}
+
+// CreatePersonalTokenRequest 创建个人访问令牌请求结构体
+type CreatePersonalTokenRequest struct {
+	Name      string       `json:"name" binding:"required,max=100" doc:"令牌名称，便于辨认用途"`
+	Scopes    []rbac.Scope `json:"scopes" binding:"required,min=1" doc:"read（只读）、posts:write（文章）、comments:write（评论），写权限包含只读"`
+	ExpiresAt *time.Time   `json:"expires_at" doc:"可选，过期时间（RFC 3339），不填永不过期"`
+}
+
+// List 当前用户的个人访问令牌
+func (h *PersonalTokenHandler) List(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	tokens, err := h.tokens.List(c.Request.Context(), current.ID)
+	if err != nil {
+		_ = c.Error(err)
+		utils.InternalServerError(c, "Failed to list personal access tokens")
+		return
+	}
+
+	response := make([]PersonalTokenResponse, len(tokens))
+	for i := range tokens {
+		response[i] = newPersonalTokenResponse(&tokens[i])
+	}
+	utils.Success(c, response)
+}
+
+// Create 创建个人访问令牌，令牌明文只在响应中出现这一次
+func (h *PersonalTokenHandler) Create(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreatePersonalTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	token, secret, err := h.tokens.Create(c.Request.Context(), current.ID, services.PersonalTokenInput{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidScope):
+			utils.BadRequest(c, "Invalid scope, allowed scopes: read, posts:write, comments:write")
+		case errors.Is(err, services.ErrInvalidExpiration):
+			utils.BadRequest(c, "expires_at must be in the future")
+		default:
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to create personal access token")
+		}
+		return
+	}
+
+	utils.Success(c, CreatePersonalTokenResponse{PersonalTokenResponse: newPersonalTokenResponse(token), Token: secret})
+}
+
+// Revoke 吊销个人访问令牌，之后使用该令牌的请求返回 401
+func (h *PersonalTokenHandler) Revoke(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid token ID")
+		return
+	}
+
+	if err := h.tokens.Revoke(c.Request.Context(), current.ID, uint(id)); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			utils.NotFound(c, "Token not found")
+		} else {
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to revoke personal access token")
+		}
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Token revoked"})
+}
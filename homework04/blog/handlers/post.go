@@ -1,38 +1,155 @@
 package handlers
 
 import (
-	"blog/models"
+	"blog/middleware"
+	"blog/repository"
+	"blog/services"
 	"blog/utils"
+	"errors"
+	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type PostHandler struct {
-	db *gorm.DB
+	posts *services.PostService
 }
 
-func NewPostHandler(db *gorm.DB) *PostHandler {
-	return &PostHandler{db: db}
+func NewPostHandler(posts *services.PostService) *PostHandler {
+	return &PostHandler{posts: posts}
 }
 
 // CreatePostRequest 创建文章请求结构体
 type CreatePostRequest struct {
-	Title   string `json:"title" binding:"required,min=1,max=200"`
-	Content string `json:"content" binding:"required,min=1"`
+	Title         string     `json:"title" binding:"required,min=1,max=200"`
+	Slug          string     `json:"slug" binding:"max=80" doc:"URL 标识，只保留字母、数字和连字符；为空时由标题生成，中文转换为拼音"`
+	Content       string     `json:"content" binding:"required,min=1"`
+	ContentFormat string     `json:"content_format" binding:"omitempty,oneof=markdown plain" doc:"正文格式 markdown（默认）或 plain，服务端渲染为过滤后的 HTML"`
+	CategoryID    uint       `json:"category_id" doc:"分类 ID，为空表示未分类"`
+	Tags          []string   `json:"tags" binding:"max=10,dive,max=30" doc:"标签，不区分大小写，不存在时自动创建"`
+	Status        string     `json:"status" binding:"omitempty,oneof=draft scheduled published archived" doc:"文章状态，默认 published；草稿、定时和已归档的文章只有作者本人可见"`
+	PublishedAt   *time.Time `json:"published_at" doc:"定时发布时间，status 为 scheduled 时必填且必须在将来，其他状态忽略"`
 }
 
-// UpdatePostRequest 更新文章请求结构体
+// UpdatePostRequest 更新文章请求结构体，分类和标签整体替换
 type UpdatePostRequest struct {
-	Title   string `json:"title" binding:"required,min=1,max=200"`
-	Content string `json:"content" binding:"required,min=1"`
+	Title         string     `json:"title" binding:"required,min=1,max=200"`
+	Slug          string     `json:"slug" binding:"max=80" doc:"URL 标识；为空时只有标题改变才重新生成。原来的 slug 继续可用，重定向到新的 slug"`
+	Content       string     `json:"content" binding:"required,min=1"`
+	ContentFormat string     `json:"content_format" binding:"omitempty,oneof=markdown plain" doc:"正文格式 markdown 或 plain，为空时保持不变"`
+	CategoryID    uint       `json:"category_id" doc:"分类 ID，为空表示未分类"`
+	Tags          []string   `json:"tags" binding:"max=10,dive,max=30" doc:"标签，不区分大小写，不存在时自动创建"`
+	Status        string     `json:"status" binding:"omitempty,oneof=draft scheduled published archived" doc:"文章状态，为空时保持不变"`
+	PublishedAt   *time.Time `json:"published_at" doc:"定时发布时间，status 为 scheduled 时必填且必须在将来，其他状态忽略"`
+	Note          string     `json:"note" binding:"max=200" doc:"修改说明，记录在版本历史中"`
+}
+
+// ListPostsQuery 文章列表查询参数
+type ListPostsQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" doc:"页码，从 1 开始；不能与 cursor 同时使用"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" doc:"每页条数，默认 10"`
+	Cursor   string `form:"cursor" doc:"游标，取自上一次响应的 meta.next_cursor 或 meta.prev_cursor"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=created_at updated_at title" doc:"排序字段，默认 created_at"`
+	Order    string `form:"order" binding:"omitempty,oneof=asc desc" doc:"排序方向，默认 desc"`
+	Author   string `form:"author" doc:"作者用户名"`
+	AuthorID uint   `form:"author_id" doc:"作者 ID"`
+	From     string `form:"from" doc:"创建时间下限（含），YYYY-MM-DD 或 RFC3339"`
+	To       string `form:"to" doc:"创建时间上限，YYYY-MM-DD 时包含当天，RFC3339 时不含"`
+	Query    string `form:"q" binding:"max=100" doc:"标题或正文包含的关键字"`
+	Include  string `form:"include" binding:"omitempty,oneof=content" doc:"content：返回完整正文"`
+	Tag      string `form:"tag" doc:"标签名"`
+	Category string `form:"category" doc:"分类名，包括其子分类下的文章"`
+	Status   string `form:"status" binding:"omitempty,oneof=draft scheduled published archived" doc:"文章状态；未发布的文章只返回当前用户自己的"`
+}
+
+// filter 转换为仓储层过滤条件
+func (q ListPostsQuery) filter() (repository.PostFilter, error) {
+	filter := repository.PostFilter{
+		AuthorID:       q.AuthorID,
+		AuthorUsername: q.Author,
+		Keyword:        strings.TrimSpace(q.Query),
+		Tag:            strings.ToLower(strings.TrimSpace(q.Tag)),
+		Status:         q.Status,
+	}
+	var err error
+	filter.CreatedFrom, filter.CreatedTo, err = parseDateRange(q.From, q.To)
+	return filter, err
+}
+
+// parseDateRange 解析 from/to 查询参数，返回 [from, to) 区间；to 只有日期时包含当天
+func parseDateRange(from, to string) (start, end time.Time, err error) {
+	if from != "" {
+		if start, _, err = parseDate(from); err != nil {
+			return start, end, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if to != "" {
+		var dateOnly bool
+		if end, dateOnly, err = parseDate(to); err != nil {
+			return start, end, fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+	return start, end, nil
+}
+
+// parseDate 解析 YYYY-MM-DD 或 RFC3339，dateOnly 表示只有日期部分
+func parseDate(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	return t, false, err
+}
+
+// pageMeta 生成分页信息，链接沿用当前请求的其他查询参数
+func pageMeta(c *gin.Context, page *services.PostPage) *utils.Meta {
+	meta := &utils.Meta{
+		Total:      page.Total,
+		Page:       page.Page,
+		PageSize:   page.PageSize,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+	if page.Page > 0 {
+		if page.HasNext {
+			meta.Links.Next = pageLink(c, "page", strconv.Itoa(page.Page+1))
+		}
+		if page.HasPrev {
+			meta.Links.Prev = pageLink(c, "page", strconv.Itoa(page.Page-1))
+		}
+		return meta
+	}
+	if page.NextCursor != "" {
+		meta.Links.Next = pageLink(c, "cursor", page.NextCursor)
+	}
+	if page.PrevCursor != "" {
+		meta.Links.Prev = pageLink(c, "cursor", page.PrevCursor)
+	}
+	return meta
+}
+
+// pageLink 把当前请求的页码或游标替换为 key=value，保留其他查询参数
+func pageLink(c *gin.Context, key, value string) string {
+	values := c.Request.URL.Query()
+	values.Del("page")
+	values.Del("cursor")
+	values.Set(key, value)
+	u := *c.Request.URL
+	u.RawQuery = values.Encode()
+	return u.RequestURI()
 }
 
 // CreatePost 创建文章
 func (h *PostHandler) CreatePost(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	user := middleware.CurrentUserFromContext(c)
+	if user == nil {
 		utils.Unauthorized(c, "User not authenticated")
 		return
 	}
@@ -43,55 +160,82 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
-	post := models.Post{
-		Title:   req.Title,
-		Content: req.Content,
-		UserID:  userID.(uint),
-	}
-
-	if err := h.db.Create(&post).Error; err != nil {
-		utils.InternalServerError(c, "Failed to create post")
+	post, err := h.posts.Create(c.Request.Context(), user.ID, services.PostInput{
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Content:       req.Content,
+		ContentFormat: req.ContentFormat,
+		CategoryID:    req.CategoryID,
+		Tags:          req.Tags,
+		Status:        req.Status,
+		PublishedAt:   req.PublishedAt,
+	})
+	if err != nil {
+		postError(c, err, "Failed to create post")
 		return
 	}
 
-	utils.Success(c, gin.H{
-		"id":         post.ID,
-		"title":      post.Title,
-		"content":    post.Content,
-		"user_id":    post.UserID,
-		"created_at": post.CreatedAt,
+	utils.Success(c, CreatePostResponse{
+		ID:            post.ID,
+		Title:         post.Title,
+		Slug:          post.Slug,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		ContentHTML:   post.ContentHTML,
+		UserID:        post.UserID,
+		Category:      newCategorySummary(post.Category),
+		Tags:          tagNames(post.Tags),
+		Status:        post.Status,
+		PublishedAt:   post.PublishedAt,
+		CreatedAt:     post.CreatedAt,
 	})
 }
 
-// GetPosts 获取文章列表
+// GetPosts 获取文章列表，支持页码或游标分页、排序和过滤；未发布的文章只返回当前用户自己的
 func (h *PostHandler) GetPosts(c *gin.Context) {
-	var posts []models.Post
+	var query ListPostsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if query.Page > 0 && query.Cursor != "" {
+		utils.BadRequest(c, "page and cursor cannot be used together")
+		return
+	}
+	filter, err := query.filter()
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
 
-	// 预加载用户信息
-	if err := h.db.Preload("User").Order("created_at DESC").Find(&posts).Error; err != nil {
-		utils.InternalServerError(c, "Failed to fetch posts")
+	page, err := h.posts.List(c.Request.Context(), services.PostListInput{
+		Filter:   filter,
+		Viewer:   middleware.CurrentUserID(c),
+		Category: strings.TrimSpace(query.Category),
+		Sort:     query.Sort,
+		Desc:     query.Order != "asc",
+		Page:     query.Page,
+		PageSize: query.PageSize,
+		Cursor:   query.Cursor,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			utils.BadRequest(c, "Invalid cursor")
+		} else {
+			utils.InternalServerError(c, "Failed to fetch posts")
+		}
 		return
 	}
 
-	var response []gin.H
-	for _, post := range posts {
-		response = append(response, gin.H{
-			"id":      post.ID,
-			"title":   post.Title,
-			"content": post.Content,
-			"user": gin.H{
-				"id":       post.User.ID,
-				"username": post.User.Username,
-			},
-			"created_at": post.CreatedAt,
-			"updated_at": post.UpdatedAt,
-		})
+	response := make([]PostResponse, 0, len(page.Posts))
+	for _, post := range page.Posts {
+		response = append(response, newPostResponse(post, query.Include == "content"))
 	}
 
-	utils.Success(c, response)
+	utils.SuccessWithMeta(c, response, pageMeta(c, page))
 }
 
-// GetPost 获取单篇文章
+// GetPost 获取单篇文章，未发布的文章只有作者本人可以获取
 func (h *PostHandler) GetPost(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -99,9 +243,9 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 		return
 	}
 
-	var post models.Post
-	if err := h.db.Preload("User").Preload("Comments.User").First(&post, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	post, err := h.posts.Get(c.Request.Context(), middleware.CurrentUserID(c), uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
 			utils.NotFound(c, "Post not found")
 		} else {
 			utils.InternalServerError(c, "Failed to fetch post")
@@ -109,60 +253,40 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 		return
 	}
 
-	// 构建评论响应
-	var comments []gin.H
-	for _, comment := range post.Comments {
-		comments = append(comments, gin.H{
-			"id":      comment.ID,
-			"content": comment.Content,
-			"user": gin.H{
-				"id":       comment.User.ID,
-				"username": comment.User.Username,
-			},
-			"created_at": comment.CreatedAt,
-		})
-	}
-
-	utils.Success(c, gin.H{
-		"id":      post.ID,
-		"title":   post.Title,
-		"content": post.Content,
-		"user": gin.H{
-			"id":       post.User.ID,
-			"username": post.User.Username,
-		},
-		"comments":   comments,
-		"created_at": post.CreatedAt,
-		"updated_at": post.UpdatedAt,
-	})
+	utils.Success(c, newPostDetailResponse(post))
 }
 
-// UpdatePost 更新文章
-func (h *PostHandler) UpdatePost(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.Unauthorized(c, "User not authenticated")
-		return
-	}
-	id, err := strconv.Atoi(c.Param("id"))
+// GetPostBySlug 按 slug 获取文章；文章用过的旧 slug 返回 301，Location 指向当前的 slug
+func (h *PostHandler) GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	post, err := h.posts.GetBySlug(c.Request.Context(), middleware.CurrentUserID(c), slug)
 	if err != nil {
-		utils.BadRequest(c, "Invalid post ID")
-		return
-	}
-
-	var post models.Post
-	if err := h.db.First(&post, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+		if errors.Is(err, services.ErrNotFound) {
 			utils.NotFound(c, "Post not found")
 		} else {
+			_ = c.Error(err)
 			utils.InternalServerError(c, "Failed to fetch post")
 		}
 		return
 	}
+	if post.Slug != slug {
+		utils.MovedPermanently(c, "/api/posts/by-slug/"+url.PathEscape(post.Slug), SlugRedirectResponse{ID: post.ID, Slug: post.Slug})
+		return
+	}
+
+	utils.Success(c, newPostDetailResponse(post))
+}
 
-	// 检查权限
-	if post.UserID != userID {
-		utils.Forbidden(c, "You can only update your own posts")
+// UpdatePost 更新文章
+func (h *PostHandler) UpdatePost(c *gin.Context) {
+	user := middleware.CurrentUserFromContext(c)
+	if user == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid post ID")
 		return
 	}
 
@@ -172,26 +296,54 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
-	post.Title = req.Title
-	post.Content = req.Content
-
-	if err := h.db.Save(&post).Error; err != nil {
-		utils.InternalServerError(c, "Failed to update post")
+	post, err := h.posts.Update(c.Request.Context(), user.Actor(), uint(id), services.PostInput{
+		Title:         req.Title,
+		Slug:          req.Slug,
+		Content:       req.Content,
+		ContentFormat: req.ContentFormat,
+		CategoryID:    req.CategoryID,
+		Tags:          req.Tags,
+		Status:        req.Status,
+		PublishedAt:   req.PublishedAt,
+		Note:          req.Note,
+	})
+	if err != nil {
+		postError(c, err, "Failed to update post")
 		return
 	}
 
-	utils.Success(c, gin.H{
-		"id":         post.ID,
-		"title":      post.Title,
-		"content":    post.Content,
-		"updated_at": post.UpdatedAt,
-	})
+	utils.Success(c, newUpdatePostResponse(post))
+}
+
+// postError 把创建、更新文章时的错误转换为响应，message 为服务端错误时的提示
+func postError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		utils.NotFound(c, "Post not found")
+	case errors.Is(err, services.ErrForbidden):
+		utils.Forbidden(c, "You do not have permission to update this post")
+	case errors.Is(err, services.ErrCategoryNotFound):
+		utils.BadRequest(c, "Category not found")
+	case errors.Is(err, services.ErrInvalidSchedule):
+		utils.BadRequest(c, "Scheduled posts require a published_at in the future")
+	case errors.Is(err, services.ErrInvalidStatus):
+		utils.BadRequest(c, "Invalid status")
+	case errors.Is(err, services.ErrInvalidSlug):
+		utils.BadRequest(c, "Slug must contain letters or digits")
+	case errors.Is(err, services.ErrSlugTaken):
+		utils.BadRequest(c, "Slug already in use")
+	case errors.Is(err, services.ErrInvalidContentFormat):
+		utils.BadRequest(c, "Invalid content format")
+	default:
+		_ = c.Error(err)
+		utils.InternalServerError(c, message)
+	}
 }
 
 // DeletePost 删除文章
 func (h *PostHandler) DeletePost(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
+	user := middleware.CurrentUserFromContext(c)
+	if user == nil {
 		utils.Unauthorized(c, "User not authenticated")
 		return
 	}
@@ -201,28 +353,17 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		return
 	}
 
-	var post models.Post
-	if err := h.db.First(&post, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
+	if err := h.posts.Delete(c.Request.Context(), user.Actor(), uint(id)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
 			utils.NotFound(c, "Post not found")
-		} else {
-			utils.InternalServerError(c, "Failed to fetch post")
+		case errors.Is(err, services.ErrForbidden):
+			utils.Forbidden(c, "You do not have permission to delete this post")
+		default:
+			utils.InternalServerError(c, "Failed to delete post")
 		}
 		return
 	}
 
-	// 检查权限
-	if post.UserID != userID {
-		utils.Forbidden(c, "You can only delete your own posts")
-		return
-	}
-
-	if err := h.db.Delete(&post).Error; err != nil {
-		utils.InternalServerError(c, "Failed to delete post")
-		return
-	}
-
-	utils.Success(c, gin.H{
-		"message": "Post deleted successfully",
-	})
+	utils.Success(c, MessageResponse{Message: "Post deleted successfully"})
 }
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"blog/middleware"
+	"blog/services"
+	"blog/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiffRevisionsQuery 版本比较参数
+type DiffRevisionsQuery struct {
+	From int    `form:"from" binding:"required,min=1" doc:"原版本号"`
+	To   int    `form:"to" binding:"required,min=1" doc:"新版本号"`
+	By   string `form:"by" binding:"omitempty,oneof=line word" doc:"比较粒度：line（默认）按行，word 按词"`
+}
+
+// RestoreRevisionRequest 恢复版本请求
+type RestoreRevisionRequest struct {
+	Note string `json:"note" binding:"max=200" doc:"修改说明，为空时版本历史中只记录恢复自哪个版本"`
+}
+
+// ListRevisions 获取文章的版本历史
+func (h *PostHandler) ListRevisions(c *gin.Context) {
+	user := middleware.CurrentUserFromContext(c)
+	if user == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid post ID")
+		return
+	}
+
+	revisions, err := h.posts.Revisions(c.Request.Context(), user.Actor(), uint(id))
+	if err != nil {
+		revisionError(c, err, "Failed to fetch revisions")
+		return
+	}
+
+	response := make([]RevisionResponse, len(revisions))
+	for i := range revisions {
+		response[i] = newRevisionResponse(&revisions[i])
+	}
+	utils.Success(c, response)
+}
+
+// DiffRevisions 比较文章的两个版本
+func (h *PostHandler) DiffRevisions(c *gin.Context) {
+	user := middleware.CurrentUserFromContext(c)
+	if user == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid post ID")
+		return
+	}
+	var query DiffRevisionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if query.By == "" {
+		query.By = services.DiffByLine
+	}
+
+	result, err := h.posts.DiffRevisions(c.Request.Context(), user.Actor(), uint(id), query.From, query.To, query.By)
+	if err != nil {
+		revisionError(c, err, "Failed to compare revisions")
+		return
+	}
+
+	utils.Success(c, RevisionDiffResponse{
+		From:       newRevisionResponse(result.From),
+		To:         newRevisionResponse(result.To),
+		By:         query.By,
+		Title:      newDiffChunks(result.Title),
+		Content:    newDiffChunks(result.Content),
+		Insertions: result.Content.Insertions,
+		Deletions:  result.Content.Deletions,
+	})
+}
+
+// RestoreRevision 把文章恢复为指定版本，保存为一个新版本
+func (h *PostHandler) RestoreRevision(c *gin.Context) {
+	user := middleware.CurrentUserFromContext(c)
+	if user == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid post ID")
+		return
+	}
+	number, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || number < 1 {
+		utils.BadRequest(c, "Invalid revision number")
+		return
+	}
+	// 请求体可以为空
+	var req RestoreRevisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	post, err := h.posts.RestoreRevision(c.Request.Context(), user.Actor(), uint(id), number, req.Note)
+	if err != nil {
+		revisionError(c, err, "Failed to restore revision")
+		return
+	}
+
+	utils.Success(c, newUpdatePostResponse(post))
+}
+
+// revisionError 把版本相关的错误转换为响应
+func revisionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrRevisionNotFound):
+		utils.NotFound(c, "Revision not found")
+	case errors.Is(err, services.ErrNotFound):
+		utils.NotFound(c, "Post not found")
+	case errors.Is(err, services.ErrForbidden):
+		utils.Forbidden(c, "You do not have permission to view or restore revisions of this post")
+	default:
+		postError(c, err, message)
+	}
+}
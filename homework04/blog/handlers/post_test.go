@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"blog/middleware"
+	"blog/rbac"
+	"blog/repository"
+	"blog/search"
+	"blog/services"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// newTestPostRouter 基于内存存储构建文章路由，用 X-User-ID 模拟已认证用户：1、2 是作者，3 是编辑
+func newTestPostRouter(t *testing.T) (*gin.Engine, *services.PostService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store := repository.NewMemoryStore()
+	searchService := services.NewSearchService(search.NewMemoryIndex(), store.Users(), store.Posts(), store.Comments(), zap.NewNop())
+	postService := services.NewPostService(store.Posts(), store.Comments(), store.Taxonomy(), searchService)
+	handler := NewPostHandler(postService)
+
+	fakeAuth := func(c *gin.Context) {
+		switch c.GetHeader("X-User-ID") {
+		case "1":
+			middleware.SetCurrentUser(c, &middleware.CurrentUser{ID: 1, Role: rbac.RoleAuthor})
+		case "2":
+			middleware.SetCurrentUser(c, &middleware.CurrentUser{ID: 2, Role: rbac.RoleAuthor})
+		case "3":
+			middleware.SetCurrentUser(c, &middleware.CurrentUser{ID: 3, Role: rbac.RoleEditor})
+		}
+	}
+
+	r := gin.New()
+	r.GET("/posts/:id", handler.GetPost)
+	r.PUT("/posts/:id", fakeAuth, handler.UpdatePost)
+	r.DELETE("/posts/:id", fakeAuth, handler.DeletePost)
+	return r, postService
+}
+
+func TestPostHandler_OwnershipStatusCodes(t *testing.T) {
+	r, postService := newTestPostRouter(t)
+	if _, err := postService.Create(context.Background(), 1, services.PostInput{Title: "t", Content: "c"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	body := `{"title":"new","content":"new content"}`
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		user   string
+		body   string
+		want   int
+	}{
+		{"update without user", http.MethodPut, "/posts/1", "", body, http.StatusUnauthorized},
+		{"update by other user", http.MethodPut, "/posts/1", "2", body, http.StatusForbidden},
+		{"update invalid id", http.MethodPut, "/posts/abc", "1", body, http.StatusBadRequest},
+		{"update missing post", http.MethodPut, "/posts/99", "1", body, http.StatusNotFound},
+		{"update by author", http.MethodPut, "/posts/1", "1", body, http.StatusOK},
+		{"update by editor", http.MethodPut, "/posts/1", "3", body, http.StatusOK},
+		{"delete by other user", http.MethodDelete, "/posts/1", "2", "", http.StatusForbidden},
+		{"delete by author", http.MethodDelete, "/posts/1", "1", "", http.StatusOK},
+		{"get deleted post", http.MethodGet, "/posts/1", "", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.user != "" {
+				req.Header.Set("X-User-ID", tt.user)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("%s %s: got status %d, want %d (body %s)", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,546 @@
+package handlers
+
+import (
+	"blog/diff"
+	"blog/markup"
+	"blog/models"
+	"blog/rbac"
+	"blog/search"
+	"blog/services"
+	"blog/utils"
+	"math"
+	"time"
+)
+
+// excerptLength 列表摘要的最大字符数
+const excerptLength = 200
+
+// UserSummary 嵌入在文章、评论中的作者信息
+type UserSummary struct {
+	ID          uint   `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	Deleted     bool   `json:"deleted,omitempty" doc:"作者已注销账号，此时 id 为 0、username 为空"`
+}
+
+// UserResponse 注册成功返回的用户信息
+type UserResponse struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role" doc:"角色：admin、editor、author 或 reader"`
+	Disabled bool   `json:"disabled,omitempty" doc:"账号已被管理员禁用"`
+	// EmailVerified 是否已通过邮件链接验证邮箱
+	EmailVerified bool `json:"email_verified"`
+}
+
+// TokenResponse 访问令牌和刷新令牌
+type TokenResponse struct {
+	Token        string `json:"token" doc:"访问令牌（JWT），放在 Authorization: Bearer 头中"`
+	RefreshToken string `json:"refresh_token" doc:"刷新令牌，只能使用一次，每次刷新都会换发新的"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in" doc:"访问令牌有效期（秒）"`
+}
+
+// LoginResponse 登录响应；启用了两步验证时只返回 challenge，令牌和用户信息在提交验证码后返回
+type LoginResponse struct {
+	*TokenResponse
+	User               *UserResponse `json:"user,omitempty"`
+	TwoFactorRequired  bool          `json:"two_factor_required,omitempty" doc:"为 true 时需要用 challenge_token 和验证码调用 /api/auth/login/2fa"`
+	ChallengeToken     string        `json:"challenge_token,omitempty"`
+	ChallengeExpiresIn int64         `json:"challenge_expires_in,omitempty" doc:"challenge_token 有效期（秒）"`
+}
+
+// ProfileResponse 用户信息响应
+type ProfileResponse struct {
+	ID          uint   `json:"id"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatar_url"`
+	// EmailVerified 是否已通过邮件链接验证邮箱
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// TwoFactorSetupResponse 新生成的 TOTP 密钥，在验证器应用中添加后用验证码确认
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret" doc:"base32 编码的密钥，手动添加到验证器应用时使用"`
+	OTPAuthURI string `json:"otpauth_uri" doc:"otpauth:// 链接，可生成二维码供验证器应用扫描"`
+}
+
+// RecoveryCodesResponse 恢复码，只在生成时返回一次，每个只能使用一次
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// PersonalTokenResponse 个人访问令牌，不包含令牌本身
+type PersonalTokenResponse struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix" doc:"令牌开头的几个字符，用于辨认令牌"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" doc:"过期时间，为空表示永不过期"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" doc:"最近使用时间，精确到分钟"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatePersonalTokenResponse 新创建的个人访问令牌
+type CreatePersonalTokenResponse struct {
+	PersonalTokenResponse
+	Token string `json:"token" doc:"令牌明文，只在创建时返回一次，放在 Authorization: Bearer 头中使用"`
+}
+
+// SessionResponse 登录会话，每次登录（包括两步验证登录）产生一个会话，刷新令牌时延续
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IP         string    `json:"ip,omitempty" doc:"最近一次请求的 IP"`
+	CreatedAt  time.Time `json:"created_at" doc:"登录时间"`
+	LastSeenAt time.Time `json:"last_seen_at" doc:"最近活动时间，精确到分钟"`
+	ExpiresAt  time.Time `json:"expires_at" doc:"不再刷新时会话的过期时间"`
+	Current    bool      `json:"current" doc:"是否为当前请求所在的会话"`
+}
+
+// LoginEventResponse 登录锁定或解锁事件
+type LoginEventResponse struct {
+	ID          uint       `json:"id"`
+	Type        string     `json:"type" doc:"lockout 锁定，unlock 管理员解锁"`
+	Username    string     `json:"username,omitempty" doc:"被锁定的用户名，按 IP 锁定时为空"`
+	IP          string     `json:"ip,omitempty" doc:"触发锁定的请求 IP"`
+	Failures    int        `json:"failures" doc:"连续失败次数"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	ActorID     *uint      `json:"actor_id,omitempty" doc:"解锁的管理员 ID"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// RolePolicyResponse 角色的权限和安全策略
+type RolePolicyResponse struct {
+	Role             string   `json:"role"`
+	Permissions      []string `json:"permissions"`
+	RequireTwoFactor bool     `json:"require_two_factor" doc:"该角色的用户必须启用两步验证"`
+}
+
+// CategorySummary 文章所属的分类
+type CategorySummary struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// CategoryResponse 分类树的节点
+type CategoryResponse struct {
+	ID        uint               `json:"id"`
+	Name      string             `json:"name"`
+	ParentID  *uint              `json:"parent_id,omitempty"`
+	PostCount int64              `json:"post_count" doc:"该分类及其子分类下的文章数"`
+	Children  []CategoryResponse `json:"children"`
+}
+
+// TagResponse 标签及使用它的文章数
+type TagResponse struct {
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+}
+
+// PostResponse 文章列表项，默认只返回摘要，include=content 时返回正文
+type PostResponse struct {
+	ID            uint             `json:"id"`
+	Title         string           `json:"title"`
+	Slug          string           `json:"slug" doc:"URL 标识，可通过 /api/posts/by-slug/{slug} 访问"`
+	Excerpt       string           `json:"excerpt" doc:"正文的纯文本摘要"`
+	Content       string           `json:"content,omitempty"`
+	ContentFormat string           `json:"content_format" doc:"markdown 或 plain"`
+	ContentHTML   string           `json:"content_html,omitempty" doc:"渲染并过滤后的正文 HTML，include=content 时返回"`
+	User          UserSummary      `json:"user"`
+	Category      *CategorySummary `json:"category,omitempty" doc:"未分类时不返回"`
+	Tags          []string         `json:"tags"`
+	Status        string           `json:"status" doc:"draft、scheduled、published 或 archived"`
+	PublishedAt   *time.Time       `json:"published_at,omitempty" doc:"发布时间，定时文章为计划发布时间，草稿不返回"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// PostDetailResponse 文章详情，包含评论；content 为作者提交的原文，展示时使用 content_html
+type PostDetailResponse struct {
+	ID             uint              `json:"id"`
+	Title          string            `json:"title"`
+	Slug           string            `json:"slug" doc:"URL 标识，可通过 /api/posts/by-slug/{slug} 访问"`
+	Content        string            `json:"content"`
+	ContentFormat  string            `json:"content_format" doc:"markdown 或 plain"`
+	ContentHTML    string            `json:"content_html" doc:"渲染并过滤后的正文 HTML，标题带有与 toc 对应的 id"`
+	TOC            []TOCEntry        `json:"toc" doc:"目录，按出现顺序排列的标题"`
+	Excerpt        string            `json:"excerpt" doc:"正文的纯文本摘要"`
+	WordCount      int               `json:"word_count" doc:"字数，每个汉字或英文单词计为一个"`
+	ReadingMinutes int               `json:"reading_minutes" doc:"估算的阅读时间（分钟）"`
+	User           UserSummary       `json:"user"`
+	Category       *CategorySummary  `json:"category,omitempty" doc:"未分类时不返回"`
+	Tags           []string          `json:"tags"`
+	Comments       []CommentResponse `json:"comments"`
+	Status         string            `json:"status" doc:"draft、scheduled、published 或 archived"`
+	PublishedAt    *time.Time        `json:"published_at,omitempty" doc:"发布时间，定时文章为计划发布时间，草稿不返回"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// CreatePostResponse 创建文章响应
+type CreatePostResponse struct {
+	ID            uint             `json:"id"`
+	Title         string           `json:"title"`
+	Slug          string           `json:"slug" doc:"URL 标识，可通过 /api/posts/by-slug/{slug} 访问"`
+	Content       string           `json:"content"`
+	ContentFormat string           `json:"content_format"`
+	ContentHTML   string           `json:"content_html"`
+	UserID        uint             `json:"user_id"`
+	Category      *CategorySummary `json:"category,omitempty" doc:"未分类时不返回"`
+	Tags          []string         `json:"tags"`
+	Status        string           `json:"status" doc:"draft、scheduled、published 或 archived"`
+	PublishedAt   *time.Time       `json:"published_at,omitempty" doc:"发布时间，定时文章为计划发布时间，草稿不返回"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// UpdatePostResponse 更新文章响应
+type UpdatePostResponse struct {
+	ID            uint             `json:"id"`
+	Title         string           `json:"title"`
+	Slug          string           `json:"slug" doc:"URL 标识，可通过 /api/posts/by-slug/{slug} 访问"`
+	Content       string           `json:"content"`
+	ContentFormat string           `json:"content_format"`
+	ContentHTML   string           `json:"content_html"`
+	Category      *CategorySummary `json:"category,omitempty" doc:"未分类时不返回"`
+	Tags          []string         `json:"tags"`
+	Status        string           `json:"status" doc:"draft、scheduled、published 或 archived"`
+	PublishedAt   *time.Time       `json:"published_at,omitempty" doc:"发布时间，定时文章为计划发布时间，草稿不返回"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// TOCEntry 目录中的一个标题，id 为正文 HTML 中标题元素的 id
+type TOCEntry struct {
+	Level int    `json:"level" doc:"标题级别 1-6"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// RevisionResponse 文章的一个版本
+type RevisionResponse struct {
+	Number        int         `json:"number"`
+	Title         string      `json:"title"`
+	ContentFormat string      `json:"content_format"`
+	Editor        UserSummary `json:"editor"`
+	Note          string      `json:"note"`
+	RestoredFrom  *int        `json:"restored_from,omitempty" doc:"由恢复操作产生时为恢复的版本号"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// DiffChunk 连续的插入、删除或未改变的文本
+type DiffChunk struct {
+	Op   string `json:"op" doc:"equal、insert 或 delete"`
+	Text string `json:"text"`
+}
+
+// RevisionDiffResponse 两个版本的差异；按顺序拼接 equal 和 delete 得到原版本，拼接 equal 和 insert 得到新版本
+type RevisionDiffResponse struct {
+	From       RevisionResponse `json:"from"`
+	To         RevisionResponse `json:"to"`
+	By         string           `json:"by" doc:"line 或 word"`
+	Title      []DiffChunk      `json:"title" doc:"标题的差异，按词比较"`
+	Content    []DiffChunk      `json:"content" doc:"正文的差异"`
+	Insertions int              `json:"insertions" doc:"正文插入的行数或词数"`
+	Deletions  int              `json:"deletions" doc:"正文删除的行数或词数"`
+}
+
+// SlugRedirectResponse 旧 slug 重定向时返回的文章当前 slug，与 Location 头一致
+type SlugRedirectResponse struct {
+	ID   uint   `json:"id"`
+	Slug string `json:"slug"`
+}
+
+// SearchHitResponse 检索结果；title、snippet 为 HTML，命中词用 <mark> 包裹
+type SearchHitResponse struct {
+	Type      string      `json:"type" doc:"post 或 comment"`
+	ID        uint        `json:"id"`
+	PostID    uint        `json:"post_id"`
+	Title     string      `json:"title,omitempty" doc:"文章标题（仅文章）"`
+	Snippet   string      `json:"snippet" doc:"命中位置附近的正文片段"`
+	Score     float64     `json:"score" doc:"相关度，越大越相关"`
+	User      UserSummary `json:"user"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// CommentResponse 评论
+type CommentResponse struct {
+	ID        uint        `json:"id"`
+	Content   string      `json:"content"`
+	User      UserSummary `json:"user"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// CreateCommentResponse 创建评论响应
+type CreateCommentResponse struct {
+	ID        uint      `json:"id"`
+	Content   string    `json:"content"`
+	UserID    uint      `json:"user_id"`
+	PostID    uint      `json:"post_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MessageResponse 只包含提示信息的响应
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+func newTokenResponse(tokens *services.TokenPair) TokenResponse {
+	return TokenResponse{
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
+	}
+}
+
+func newLoginResponse(tokens *services.TokenPair, user *models.User) LoginResponse {
+	tokenResponse := newTokenResponse(tokens)
+	userResponse := newUserResponse(user)
+	return LoginResponse{TokenResponse: &tokenResponse, User: &userResponse}
+}
+
+func newRolePolicyResponse(policy models.RolePolicy) RolePolicyResponse {
+	permissions := rbac.Role(policy.Role).Permissions()
+	names := make([]string, len(permissions))
+	for i, p := range permissions {
+		names[i] = string(p)
+	}
+	return RolePolicyResponse{Role: policy.Role, Permissions: names, RequireTwoFactor: policy.RequireTwoFactor}
+}
+
+func newPersonalTokenResponse(token *models.PersonalAccessToken) PersonalTokenResponse {
+	return PersonalTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+func newSessionResponse(session *models.Session, currentID string) SessionResponse {
+	return SessionResponse{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.ID == currentID,
+	}
+}
+
+func newLoginEventResponse(event models.LoginEvent) LoginEventResponse {
+	return LoginEventResponse{
+		ID:          event.ID,
+		Type:        event.Type,
+		Username:    event.Username,
+		IP:          event.IP,
+		Failures:    event.Failures,
+		LockedUntil: event.LockedUntil,
+		ActorID:     event.ActorID,
+		CreatedAt:   event.CreatedAt,
+	}
+}
+
+// newUserSummary 作者已注销时关联查询不到用户，只标记为已注销
+func newUserSummary(user models.User) UserSummary {
+	if user.ID == 0 {
+		return UserSummary{Deleted: true}
+	}
+	return UserSummary{ID: user.ID, Username: user.Username, DisplayName: user.DisplayName, AvatarURL: user.AvatarURL}
+}
+
+func newProfileResponse(user *models.User) ProfileResponse {
+	return ProfileResponse{
+		ID:               user.ID,
+		Username:         user.Username,
+		Email:            user.Email,
+		Role:             user.Role,
+		DisplayName:      user.DisplayName,
+		Bio:              user.Bio,
+		AvatarURL:        user.AvatarURL,
+		EmailVerified:    user.EmailVerifiedAt != nil,
+		TwoFactorEnabled: user.TwoFactorEnabled(),
+		CreatedAt:        user.CreatedAt,
+	}
+}
+
+func newUserResponse(user *models.User) UserResponse {
+	return UserResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          user.Role,
+		Disabled:      user.Disabled,
+		EmailVerified: user.EmailVerifiedAt != nil,
+	}
+}
+
+func newCategorySummary(category *models.Category) *CategorySummary {
+	if category == nil {
+		return nil
+	}
+	return &CategorySummary{ID: category.ID, Name: category.Name}
+}
+
+func newCategoryResponse(node *services.CategoryNode) CategoryResponse {
+	return CategoryResponse{
+		ID:        node.ID,
+		Name:      node.Name,
+		ParentID:  node.ParentID,
+		PostCount: node.PostCount,
+		Children:  newCategoryResponses(node.Children),
+	}
+}
+
+func newCategoryResponses(nodes []*services.CategoryNode) []CategoryResponse {
+	response := make([]CategoryResponse, len(nodes))
+	for i, node := range nodes {
+		response[i] = newCategoryResponse(node)
+	}
+	return response
+}
+
+// tagNames 标签名称列表，没有标签时为空数组而不是 null
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+func newPostResponse(post models.Post, withContent bool) PostResponse {
+	response := PostResponse{
+		ID:            post.ID,
+		Title:         post.Title,
+		Slug:          post.Slug,
+		Excerpt:       utils.Excerpt(markup.Analyze(post.ContentHTML).Text, excerptLength),
+		ContentFormat: post.ContentFormat,
+		User:          newUserSummary(post.User),
+		Category:      newCategorySummary(post.Category),
+		Tags:          tagNames(post.Tags),
+		Status:        post.Status,
+		PublishedAt:   post.PublishedAt,
+		CreatedAt:     post.CreatedAt,
+		UpdatedAt:     post.UpdatedAt,
+	}
+	if withContent {
+		response.Content, response.ContentHTML = post.Content, post.ContentHTML
+	}
+	return response
+}
+
+func newPostDetailResponse(post *models.Post) PostDetailResponse {
+	doc := markup.Analyze(post.ContentHTML)
+	toc := make([]TOCEntry, len(doc.Headings))
+	for i, heading := range doc.Headings {
+		toc[i] = TOCEntry{Level: heading.Level, ID: heading.ID, Title: heading.Title}
+	}
+	return PostDetailResponse{
+		ID:             post.ID,
+		Title:          post.Title,
+		Slug:           post.Slug,
+		Content:        post.Content,
+		ContentFormat:  post.ContentFormat,
+		ContentHTML:    post.ContentHTML,
+		TOC:            toc,
+		Excerpt:        utils.Excerpt(doc.Text, excerptLength),
+		WordCount:      doc.Words,
+		ReadingMinutes: markup.ReadingMinutes(doc.Words),
+		User:           newUserSummary(post.User),
+		Category:       newCategorySummary(post.Category),
+		Tags:           tagNames(post.Tags),
+		Comments:       newCommentResponses(post.Comments),
+		Status:         post.Status,
+		PublishedAt:    post.PublishedAt,
+		CreatedAt:      post.CreatedAt,
+		UpdatedAt:      post.UpdatedAt,
+	}
+}
+
+func newUpdatePostResponse(post *models.Post) UpdatePostResponse {
+	return UpdatePostResponse{
+		ID:            post.ID,
+		Title:         post.Title,
+		Slug:          post.Slug,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		ContentHTML:   post.ContentHTML,
+		Category:      newCategorySummary(post.Category),
+		Tags:          tagNames(post.Tags),
+		Status:        post.Status,
+		PublishedAt:   post.PublishedAt,
+		UpdatedAt:     post.UpdatedAt,
+	}
+}
+
+func newRevisionResponse(revision *models.PostRevision) RevisionResponse {
+	return RevisionResponse{
+		Number:        revision.Number,
+		Title:         revision.Title,
+		ContentFormat: revision.ContentFormat,
+		Editor:        newUserSummary(revision.Editor),
+		Note:          revision.Note,
+		RestoredFrom:  revision.RestoredFrom,
+		CreatedAt:     revision.CreatedAt,
+	}
+}
+
+// newDiffChunks 没有任何内容时为空数组而不是 null
+func newDiffChunks(result diff.Result) []DiffChunk {
+	chunks := make([]DiffChunk, len(result.Chunks))
+	for i, chunk := range result.Chunks {
+		chunks[i] = DiffChunk{Op: chunk.Op, Text: chunk.Text}
+	}
+	return chunks
+}
+
+func newCommentResponse(comment models.Comment) CommentResponse {
+	return CommentResponse{
+		ID:        comment.ID,
+		Content:   comment.Content,
+		User:      newUserSummary(comment.User),
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+func newCommentResponses(comments []models.Comment) []CommentResponse {
+	var response []CommentResponse
+	for _, comment := range comments {
+		response = append(response, newCommentResponse(comment))
+	}
+	return response
+}
+
+// searchAuthor 索引中作者名为空表示作者已注销
+func searchAuthor(id uint, username string) UserSummary {
+	if username == "" {
+		return UserSummary{Deleted: true}
+	}
+	return UserSummary{ID: id, Username: username}
+}
+
+func newSearchHitResponse(hit search.Hit) SearchHitResponse {
+	return SearchHitResponse{
+		Type:      string(hit.Kind),
+		ID:        hit.ID,
+		PostID:    hit.PostID,
+		Title:     hit.HighlightedTitle,
+		Snippet:   hit.Snippet,
+		Score:     math.Round(hit.Score*1000) / 1000,
+		User:      searchAuthor(hit.AuthorID, hit.Author),
+		CreatedAt: hit.CreatedAt,
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"blog/search"
+	"blog/services"
+	"blog/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	search *services.SearchService
+}
+
+func NewSearchHandler(search *services.SearchService) *SearchHandler {
+	return &SearchHandler{search: search}
+}
+
+// SearchQuery 全文检索查询参数
+type SearchQuery struct {
+	Query    string `form:"q" binding:"required,max=100" doc:"检索词，中文按相邻两字匹配"`
+	Type     string `form:"type" binding:"omitempty,oneof=post comment" doc:"只检索文章或评论，默认都检索"`
+	Author   string `form:"author" doc:"作者用户名"`
+	Tag      string `form:"tag" doc:"文章标签，指定时只检索文章"`
+	From     string `form:"from" doc:"创建时间下限（含），YYYY-MM-DD 或 RFC3339"`
+	To       string `form:"to" doc:"创建时间上限，YYYY-MM-DD 时包含当天，RFC3339 时不含"`
+	Page     int    `form:"page" binding:"omitempty,min=1" doc:"页码，从 1 开始"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" doc:"每页条数，默认 10"`
+}
+
+// Search 全文检索文章和评论，按相关度排序
+func (h *SearchHandler) Search(c *gin.Context) {
+	var query SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	from, to, err := parseDateRange(query.From, query.To)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = services.DefaultPageSize
+	}
+
+	q := search.Query{
+		Text:   query.Query,
+		Author: query.Author,
+		Tag:    strings.ToLower(strings.TrimSpace(query.Tag)),
+		From:   from,
+		To:     to,
+		Limit:  query.PageSize,
+		Offset: (query.Page - 1) * query.PageSize,
+	}
+	if query.Type != "" {
+		q.Kinds = []search.Kind{search.Kind(query.Type)}
+	}
+
+	result, err := h.search.Search(c.Request.Context(), q)
+	if err != nil {
+		utils.InternalServerError(c, "Failed to search")
+		return
+	}
+
+	response := make([]SearchHitResponse, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		response = append(response, newSearchHitResponse(hit))
+	}
+
+	meta := &utils.Meta{Total: result.Total, Page: query.Page, PageSize: query.PageSize}
+	if int64(query.Page*query.PageSize) < result.Total {
+		meta.Links.Next = pageLink(c, "page", strconv.Itoa(query.Page+1))
+	}
+	if query.Page > 1 {
+		meta.Links.Prev = pageLink(c, "page", strconv.Itoa(query.Page-1))
+	}
+	utils.SuccessWithMeta(c, response, meta)
+}
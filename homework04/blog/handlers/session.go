@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"blog/middleware"
+	"blog/services"
+	"blog/utils"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionHandler 登录会话的列出和吊销
+type SessionHandler struct {
+	tokens *services.TokenService
+}
+
+func NewSessionHandler(tokens *services.TokenService) *SessionHandler {
+	return &SessionHandler{tokens: tokens}
+}
+
+// RevokeSessionsQuery 吊销所有会话的查询参数
+type RevokeSessionsQuery struct {
+	KeepCurrent bool `form:"keep_current" doc:"为 true 时保留当前会话，只退出其他设备"`
+}
+
+// clientInfo 发起请求的客户端 IP 和 User-Agent，记录在登录会话中
+// IP 只在请求来自 server.trusted_proxies 中的代理时才采信 X-Forwarded-For，否则是连接的对端地址
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// List 当前用户仍然有效的登录会话，标记出当前请求所在的会话
+func (h *SessionHandler) List(c *gin.Context) {
+	claims := middleware.GetClaimsFromContext(c)
+	if claims == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	sessions, err := h.tokens.Sessions(c.Request.Context(), claims.UserID)
+	if err != nil {
+		_ = c.Error(err)
+		utils.InternalServerError(c, "Failed to list sessions")
+		return
+	}
+
+	response := make([]SessionResponse, len(sessions))
+	for i := range sessions {
+		response[i] = newSessionResponse(&sessions[i], claims.SessionID)
+	}
+	utils.Success(c, response)
+}
+
+// Revoke 吊销一个会话，该会话的访问令牌和刷新令牌立即失效
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	claims := middleware.GetClaimsFromContext(c)
+	if claims == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.tokens.RevokeSession(c.Request.Context(), claims.UserID, c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			utils.NotFound(c, "Session not found")
+		} else {
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to revoke session")
+		}
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Session revoked"})
+}
+
+// RevokeAll 吊销当前用户的所有会话，keep_current=true 时保留当前会话
+func (h *SessionHandler) RevokeAll(c *gin.Context) {
+	claims := middleware.GetClaimsFromContext(c)
+	if claims == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var query RevokeSessionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	exceptID := ""
+	if query.KeepCurrent {
+		if claims.SessionID == "" {
+			utils.BadRequest(c, "Current token does not belong to a session, please log in again")
+			return
+		}
+		exceptID = claims.SessionID
+	}
+	if err := h.tokens.RevokeSessions(c.Request.Context(), claims.UserID, exceptID); err != nil {
+		_ = c.Error(err)
+		utils.InternalServerError(c, "Failed to revoke sessions")
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Sessions revoked"})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"blog/services"
+	"blog/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CategoryHandler 文章分类，修改分类需要 category:manage 权限
+type CategoryHandler struct {
+	taxonomy *services.TaxonomyService
+}
+
+func NewCategoryHandler(taxonomy *services.TaxonomyService) *CategoryHandler {
+	return &CategoryHandler{taxonomy: taxonomy}
+}
+
+// TagHandler 文章标签
+type TagHandler struct {
+	taxonomy *services.TaxonomyService
+}
+
+func NewTagHandler(taxonomy *services.TaxonomyService) *TagHandler {
+	return &TagHandler{taxonomy: taxonomy}
+}
+
+// CategoryRequest 创建和修改分类请求结构体
+type CategoryRequest struct {
+	Name     string `json:"name" binding:"required,min=1,max=50"`
+	ParentID *uint  `json:"parent_id" doc:"父分类 ID，为空表示顶级分类"`
+}
+
+func (r CategoryRequest) input() services.CategoryInput {
+	return services.CategoryInput{Name: r.Name, ParentID: r.ParentID}
+}
+
+// List 分类树及各分类的文章数
+func (h *CategoryHandler) List(c *gin.Context) {
+	nodes, err := h.taxonomy.Categories(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		utils.InternalServerError(c, "Failed to fetch categories")
+		return
+	}
+	utils.Success(c, newCategoryResponses(nodes))
+}
+
+// Create 创建分类
+func (h *CategoryHandler) Create(c *gin.Context) {
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	category, err := h.taxonomy.CreateCategory(c.Request.Context(), req.input())
+	if err != nil {
+		categoryError(c, err, "Failed to create category")
+		return
+	}
+	utils.Success(c, newCategoryResponse(&services.CategoryNode{Category: *category}))
+}
+
+// Update 修改分类名称或父分类
+func (h *CategoryHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid category ID")
+		return
+	}
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	category, err := h.taxonomy.UpdateCategory(c.Request.Context(), uint(id), req.input())
+	if err != nil {
+		categoryError(c, err, "Failed to update category")
+		return
+	}
+	utils.Success(c, newCategoryResponse(&services.CategoryNode{Category: *category}))
+}
+
+// Delete 删除没有子分类的分类，其下的文章变为未分类
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequest(c, "Invalid category ID")
+		return
+	}
+
+	if err := h.taxonomy.DeleteCategory(c.Request.Context(), uint(id)); err != nil {
+		categoryError(c, err, "Failed to delete category")
+		return
+	}
+	utils.Success(c, MessageResponse{Message: "Category deleted successfully"})
+}
+
+// categoryError 把修改分类时的错误转换为响应，message 为服务端错误时的提示
+func categoryError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		utils.NotFound(c, "Category not found")
+	case errors.Is(err, services.ErrCategoryNotFound):
+		utils.BadRequest(c, "Parent category not found")
+	case errors.Is(err, services.ErrCategoryExists):
+		utils.BadRequest(c, "Category name already exists")
+	case errors.Is(err, services.ErrInvalidParent):
+		utils.BadRequest(c, "Category cannot be moved under itself or its subcategories")
+	case errors.Is(err, services.ErrCategoryHasChildren):
+		utils.BadRequest(c, "Category has subcategories")
+	default:
+		_ = c.Error(err)
+		utils.InternalServerError(c, message)
+	}
+}
+
+// List 有文章使用的标签及其文章数
+func (h *TagHandler) List(c *gin.Context) {
+	tags, err := h.taxonomy.Tags(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		utils.InternalServerError(c, "Failed to fetch tags")
+		return
+	}
+
+	response := make([]TagResponse, len(tags))
+	for i, tag := range tags {
+		response[i] = TagResponse{Name: tag.Name, PostCount: tag.Posts}
+	}
+	utils.Success(c, response)
+}
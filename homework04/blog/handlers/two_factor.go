@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"blog/middleware"
+	"blog/services"
+	"blog/utils"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TwoFactorHandler 两步验证：启用、关闭、恢复码和两步登录
+type TwoFactorHandler struct {
+	twoFactor *services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactor *services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactor: twoFactor}
+}
+
+// TwoFactorLoginRequest 两步登录请求结构体
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required" doc:"登录接口返回的 challenge_token"`
+	Code           string `json:"code" binding:"required" doc:"验证器应用中的 6 位验证码，或一个恢复码"`
+}
+
+// TwoFactorCodeRequest 需要验证码的请求结构体
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required" doc:"验证器应用中的 6 位验证码"`
+}
+
+// DisableTwoFactorRequest 关闭两步验证请求结构体
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required" doc:"验证器应用中的 6 位验证码，或一个恢复码"`
+}
+
+// Login 提交验证码或恢复码完成登录
+func (h *TwoFactorHandler) Login(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tokens, user, err := h.twoFactor.CompleteLogin(c.Request.Context(), req.ChallengeToken, req.Code, clientInfo(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrLoginThrottled):
+			loginThrottled(c, err)
+		case errors.Is(err, services.ErrInvalidChallenge):
+			utils.Unauthorized(c, "Invalid or expired challenge, please log in again")
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			utils.Unauthorized(c, "Invalid two-factor code")
+		case errors.Is(err, services.ErrAccountDisabled):
+			utils.Forbidden(c, "Account is disabled")
+		default:
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to log in")
+		}
+		return
+	}
+
+	utils.Success(c, newLoginResponse(tokens, user))
+}
+
+// Setup 生成新的 TOTP 密钥，确认前不生效
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	setup, err := h.twoFactor.Setup(c.Request.Context(), current.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrTwoFactorEnabled) {
+			utils.BadRequest(c, "Two-factor authentication is already enabled")
+		} else {
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to set up two-factor authentication")
+		}
+		return
+	}
+
+	utils.Success(c, TwoFactorSetupResponse{Secret: setup.Secret, OTPAuthURI: setup.URI})
+}
+
+// Confirm 用验证码确认密钥并启用两步验证，返回恢复码
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	codes, err := h.twoFactor.Enable(c.Request.Context(), current.ID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTwoFactorEnabled):
+			utils.BadRequest(c, "Two-factor authentication is already enabled")
+		case errors.Is(err, services.ErrTwoFactorNotEnabled):
+			utils.BadRequest(c, "Call /api/auth/2fa/setup first")
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			utils.BadRequest(c, "Invalid two-factor code")
+		default:
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to enable two-factor authentication")
+		}
+		return
+	}
+
+	utils.Success(c, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes 生成新的恢复码，旧的全部作废
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	codes, err := h.twoFactor.RegenerateRecoveryCodes(c.Request.Context(), current.ID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTwoFactorNotEnabled):
+			utils.BadRequest(c, "Two-factor authentication is not enabled")
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			utils.BadRequest(c, "Invalid two-factor code")
+		default:
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to generate recovery codes")
+		}
+		return
+	}
+
+	utils.Success(c, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable 关闭两步验证
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	current := middleware.CurrentUserFromContext(c)
+	if current == nil {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if err := h.twoFactor.Disable(c.Request.Context(), current.ID, req.Password, req.Code); err != nil {
+		switch {
+		case errors.Is(err, services.ErrTwoFactorNotEnabled):
+			utils.BadRequest(c, "Two-factor authentication is not enabled")
+		case errors.Is(err, services.ErrInvalidCredentials):
+			utils.BadRequest(c, "Invalid password")
+		case errors.Is(err, services.ErrInvalidTwoFactorCode):
+			utils.BadRequest(c, "Invalid two-factor code")
+		case errors.Is(err, services.ErrTwoFactorRequired):
+			utils.Forbidden(c, "Two-factor authentication is required for your role")
+		default:
+			_ = c.Error(err)
+			utils.InternalServerError(c, "Failed to disable two-factor authentication")
+		}
+		return
+	}
+
+	utils.Success(c, MessageResponse{Message: "Two-factor authentication disabled"})
+}
@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"blog/utils"
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer 把每封邮件保存为 Dir 下的一个 .eml 文件，可以用邮件客户端打开
+// 文件名以 UTC 时间开头，按文件名排序即为发送顺序
+type FileMailer struct {
+	dir  string
+	from *mail.Address
+}
+
+func NewFileMailer(dir string, from *mail.Address) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	suffix, err := utils.RandomToken(6)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), suffix)
+	// 邮件中包含登录凭据，只允许当前用户读取
+	if err := os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg, now), 0o600); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
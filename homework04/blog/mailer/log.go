@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer 只把邮件写入日志，不真正发送
+// 正文中的验证链接和重置链接可以直接登录账号，只能用于本地开发
+type LogMailer struct {
+	logger *zap.Logger
+}
+
+func NewLogMailer(logger *zap.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Info("Mail",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
@@ -0,0 +1,81 @@
+// Package mailer 发送系统邮件（邮箱验证、重置密码等）。
+// Mailer 有三种实现：SMTP 用于生产环境，日志和文件投递用于没有邮件服务的本地开发和测试。
+package mailer
+
+import (
+	"blog/config"
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/mail"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 发送邮件
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New 根据配置创建 Mailer
+func New(cfg config.MailConfig, logger *zap.Logger) (Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail.from: %w", err)
+	}
+
+	switch cfg.Driver {
+	case config.MailDriverLog:
+		return NewLogMailer(logger), nil
+	case config.MailDriverFile:
+		return NewFileMailer(cfg.Dir, from), nil
+	case config.MailDriverSMTP:
+		return NewSMTPMailer(cfg.SMTP, from), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %q", cfg.Driver)
+	}
+}
+
+// format 按 RFC 5322 编码邮件，主题中的非 ASCII 字符使用 RFC 2047 编码
+func format(from *mail.Address, msg Message, now time.Time) []byte {
+	var buf bytes.Buffer
+	to := &mail.Address{Address: msg.To}
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(normalizeNewlines(msg.Body))
+	return buf.Bytes()
+}
+
+// normalizeNewlines 邮件正文统一使用 CRLF 换行
+func normalizeNewlines(s string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\r':
+			buf.WriteString("\r\n")
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case s[i] == '\n':
+			buf.WriteString("\r\n")
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,91 @@
+package mailer
+
+import (
+	"blog/config"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// smtpTimeout 未设置截止时间的 ctx 发送一封邮件的最长时间
+const smtpTimeout = 30 * time.Second
+
+// SMTPMailer 通过 SMTP 服务器发送邮件
+// 服务器支持 STARTTLS 时先升级为 TLS；配置了用户名时使用 PLAIN 认证，net/smtp 拒绝在未加密的连接上发送密码
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     *mail.Address
+}
+
+func NewSMTPMailer(cfg config.SMTPConfig, from *mail.Address) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(cfg.Host, cfg.Port),
+		host:     cfg.Host,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     from,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("failed to authenticate with SMTP server: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if _, err := w.Write(format(m.from, msg, time.Now())); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return client.Quit()
+}
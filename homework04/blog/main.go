@@ -2,45 +2,289 @@ package main
 
 import (
 	"blog/config"
+	"blog/migrations"
+	"blog/rbac"
+	"blog/repository"
 	"blog/routes"
+	"blog/workers"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// defaultConfigFile 未指定 -config 时，若该文件存在则自动加载
+const defaultConfigFile = "config.yaml"
+
 func main() {
+	configFile := flag.String("config", "", "path to config file (.yaml/.yml/.toml)")
+	flag.Parse()
+
 	// 加载环境变量
 	config.LoadEnv()
 
+	// 子命令：blog migrate create <name> [dir]，只生成文件，不需要配置和数据库
+	if args := flag.Args(); len(args) > 1 && args[0] == "migrate" && args[1] == "create" {
+		if err := runMigrateCreate(args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// 加载并校验配置
+	if *configFile == "" {
+		if _, err := os.Stat(defaultConfigFile); err == nil {
+			*configFile = defaultConfigFile
+		}
+	}
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 子命令：blog migrate up|down|status|create
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(cfg, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// 子命令：blog role <username> <role>，用于指定第一个管理员
+	if args := flag.Args(); len(args) > 0 && args[0] == "role" {
+		if err := runRole(cfg, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// 初始化日志
 	logger, err := zap.NewProduction()
 	if err != nil {
 		log.Fatal("Failed to initialize logger:", err)
 	}
-	defer logger.Sync()
 
 	// 初始化数据库
-	db, err := config.InitDB()
+	db, err := config.InitDB(cfg.Database)
 	if err != nil {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.Fatal("Failed to get database connection pool", zap.Error(err))
+	}
+
+	// 执行或检查版本化迁移
+	migrator := migrations.NewMigrator(db)
+	if cfg.Database.MigrateOnStart {
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			logger.Fatal("Failed to run migrations", zap.Error(err))
+		}
+		logger.Info("Migrations applied", zap.Int("count", len(applied)))
+	} else if pending, err := migrator.Pending(); err != nil {
+		logger.Fatal("Failed to check migrations", zap.Error(err))
+	} else if pending > 0 {
+		logger.Warn("Database has pending migrations, run `blog migrate up`", zap.Int("pending", pending))
+	}
+
+	// 内存检索索引不在实例之间同步
+	if cfg.Database.Driver != config.DriverSQLite && cfg.SearchEngine() == config.SearchEngineMemory {
+		logger.Warn("Search uses the in-memory index, which only supports a single instance; use MySQL with search.engine mysql for multiple instances")
+	}
+
+	// 后台任务
+	workerManager := workers.NewManager(logger)
 
 	// 创建Gin路由
+	gin.SetMode(cfg.Server.Mode)
 	r := gin.Default()
 
 	// 注册路由
-	routes.SetupRoutes(r, db)
+	if err := routes.SetupRoutes(r, db, cfg, workerManager); err != nil {
+		logger.Fatal("Failed to set up routes", zap.Error(err))
+	}
+
+	srv := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout.Std(),
+		WriteTimeout: cfg.Server.WriteTimeout.Std(),
+		IdleTimeout:  cfg.Server.IdleTimeout.Std(),
+	}
+
+	// 监听 SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workerManager.Start(ctx)
 
 	// 启动服务器
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Server starting on port " + cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutdown signal received, draining requests",
+			zap.Duration("timeout", cfg.Server.ShutdownTimeout.Std()))
+	case err := <-serverErr:
+		logger.Error("Server failed", zap.Error(err))
+		exitCode = 1
+	}
+
+	shutdown(srv, workerManager, sqlDB, cfg.Server.ShutdownTimeout.Std(), logger)
+	os.Exit(exitCode)
+}
+
+// httpServer 可以优雅关闭的 HTTP 服务器，由 *http.Server 实现
+type httpServer interface {
+	Shutdown(ctx context.Context) error
+}
+
+// workerStopper 可以等待结束的后台任务，由 *workers.Manager 实现
+type workerStopper interface {
+	Stop(ctx context.Context) error
+}
+
+// shutdown 按顺序关闭：停止接收新连接并等待进行中的请求，再等待后台任务结束，都在 timeout 内完成；
+// 然后关闭数据库连接池，最后刷新日志。前一步失败时只记录日志，后面的步骤照常执行
+func shutdown(srv httpServer, workerManager workerStopper, db io.Closer, timeout time.Duration, logger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("Server forced to shutdown", zap.Error(err))
+	}
+
+	if err := workerManager.Stop(ctx); err != nil {
+		logger.Error("Background workers did not stop in time", zap.Error(err))
+	}
+
+	if err := db.Close(); err != nil {
+		logger.Error("Failed to close database", zap.Error(err))
+	}
+
+	logger.Info("Server exited")
+	logger.Sync()
+}
+
+// runMigrate 执行 migrate 子命令
+func runMigrate(cfg *config.Config, args []string) error {
+	usage := "usage: blog migrate up | down [steps] | status | create <name> [dir]"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	db, err := config.OpenDB(cfg.Database)
+	if err != nil {
+		return err
 	}
+	migrator := migrations.NewMigrator(db)
+	ctx := context.Background()
 
-	logger.Info("Server starting on port " + port)
-	if err := r.Run(":" + port); err != nil {
-		logger.Fatal("Failed to start server", zap.Error(err))
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("Applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid steps %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", s.Version, s.Name, state)
+		}
+	default:
+		return errors.New(usage)
+	}
+	return nil
+}
+
+// runMigrateCreate 执行 migrate create 子命令，在 dir（默认 migrations）下生成迁移文件
+func runMigrateCreate(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return errors.New("usage: blog migrate create <name> [dir]")
+	}
+	dir := "migrations"
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	path, err := migrations.Create(dir, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println("Created", path)
+	return nil
+}
+
+// runRole 执行 role 子命令，直接修改数据库中的用户角色，不经过 API 的权限检查
+func runRole(cfg *config.Config, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: blog role <username> admin|editor|author|reader")
+	}
+	role := rbac.Role(args[1])
+	if !role.Valid() {
+		return fmt.Errorf("invalid role %q", args[1])
+	}
+
+	db, err := config.OpenDB(cfg.Database)
+	if err != nil {
+		return err
+	}
+	users := repository.NewGormUserRepository(db)
+	ctx := context.Background()
+
+	user, err := users.FindByUsername(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to find user %q: %w", args[0], err)
+	}
+	user.Role = string(role)
+	if err := users.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
 	}
+	fmt.Printf("User %s is now %s\n", user.Username, role)
+	return nil
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TestMigrateCreateWithoutConfig migrate create 在加载配置之前执行，只生成文件，不需要配置和数据库
+func TestMigrateCreateWithoutConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := runMigrateCreate([]string{"add_post_likes", dir}); err != nil {
+		t.Fatalf("runMigrateCreate: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*_add_post_likes.go"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("generated files: %v, %v", files, err)
+	}
+	if err := runMigrateCreate(nil); err == nil {
+		t.Fatal("runMigrateCreate without name: expected usage error")
+	}
+}
+
+// shutdownStep 记录关闭顺序的测试替身
+type shutdownStep struct {
+	name  string
+	steps *[]string
+	err   error
+}
+
+func (s shutdownStep) record(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New(s.name + " called without deadline")
+	}
+	*s.steps = append(*s.steps, s.name)
+	return s.err
+}
+
+func (s shutdownStep) Shutdown(ctx context.Context) error { return s.record(ctx) }
+
+func (s shutdownStep) Stop(ctx context.Context) error { return s.record(ctx) }
+
+func (s shutdownStep) Close() error {
+	*s.steps = append(*s.steps, s.name)
+	return s.err
+}
+
+// syncCore 记录 Sync 调用的日志 core
+type syncCore struct {
+	zapcore.Core
+	steps *[]string
+}
+
+func (c syncCore) Sync() error {
+	*c.steps = append(*c.steps, "logger")
+	return nil
+}
+
+// TestShutdownOrder 先关闭 HTTP 服务器，再停止后台任务，然后关闭数据库，最后刷新日志；前一步失败不影响后面的步骤
+func TestShutdownOrder(t *testing.T) {
+	for _, failing := range []bool{false, true} {
+		var steps []string
+		var err error
+		if failing {
+			err = errors.New("timed out")
+		}
+		logger := zap.New(syncCore{Core: zapcore.NewNopCore(), steps: &steps})
+
+		shutdown(shutdownStep{name: "server", steps: &steps, err: err}, shutdownStep{name: "workers", steps: &steps, err: err},
+			shutdownStep{name: "database", steps: &steps, err: err}, time.Second, logger)
+
+		if want := []string{"server", "workers", "database", "logger"}; !reflect.DeepEqual(steps, want) {
+			t.Fatalf("failing=%v: shutdown order %v, want %v", failing, steps, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package markup
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// WordsPerMinute 估算阅读时间使用的阅读速度
+const WordsPerMinute = 300
+
+// Heading 目录中的一个标题
+type Heading struct {
+	Level int
+	ID    string
+	Title string
+}
+
+// Document 从渲染后的 HTML 中提取的信息
+type Document struct {
+	Headings []Heading // 按出现顺序排列，没有锚点的标题不计入
+	Text     string    // 纯文本，块级元素之间以空白分隔
+	Words    int
+}
+
+// blockElements 结束时需要和后面的文字分开的元素
+var blockElements = map[string]bool{
+	"p": true, "br": true, "div": true, "li": true, "pre": true, "blockquote": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"tr": true, "td": true, "th": true, "hr": true,
+}
+
+// Analyze 解析 Render 生成的 HTML
+func Analyze(source string) Document {
+	var (
+		doc     Document
+		text    strings.Builder
+		heading *Heading
+		title   strings.Builder
+	)
+	z := html.NewTokenizer(strings.NewReader(source))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			doc.Text = strings.Join(strings.Fields(text.String()), " ")
+			doc.Words = CountWords(doc.Text)
+			return doc
+		case html.TextToken:
+			data := z.Text()
+			text.Write(data)
+			if heading != nil {
+				title.Write(data)
+			}
+		case html.StartTagToken:
+			tok := z.Token()
+			if level := headingLevel(tok.Data); level > 0 {
+				heading = &Heading{Level: level, ID: attr(tok, "id")}
+				title.Reset()
+			}
+			if tok.Data == "br" {
+				text.WriteByte(' ')
+			}
+		case html.SelfClosingTagToken, html.EndTagToken:
+			tok := z.Token()
+			if blockElements[tok.Data] {
+				text.WriteByte(' ')
+			}
+			if heading != nil && headingLevel(tok.Data) == heading.Level {
+				heading.Title = strings.Join(strings.Fields(title.String()), " ")
+				if heading.ID != "" {
+					doc.Headings = append(doc.Headings, *heading)
+				}
+				heading = nil
+			}
+		}
+	}
+}
+
+// CountWords 统计字数：连续的字母和数字算一个词，每个汉字、假名或谚文字符算一个词
+func CountWords(text string) int {
+	words, inWord := 0, false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			words++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'':
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return words
+}
+
+// ReadingMinutes 按 WordsPerMinute 估算的阅读分钟数，向上取整；有内容时至少 1 分钟
+func ReadingMinutes(words int) int {
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
+
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+func attr(tok html.Token, key string) string {
+	for _, a := range tok.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+// Package markup 文章正文渲染：把 Markdown 或纯文本转换为经过白名单过滤的 HTML，
+// 并从 HTML 中提取目录、纯文本和字数。
+package markup
+
+import (
+	"blog/utils"
+	"bytes"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// 正文格式
+const (
+	FormatMarkdown = "markdown"
+	FormatPlain    = "plain"
+)
+
+// ErrUnknownFormat 不支持的正文格式
+var ErrUnknownFormat = errors.New("unknown content format")
+
+// markdown 支持 GFM（表格、删除线、任务列表、自动链接）；允许内嵌 HTML，由 policy 过滤
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+)
+
+// policy 在 bluemonday 用户内容白名单的基础上允许标题锚点和任务列表的复选框
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}()
+
+// Render 把 source 按 format 渲染为安全的 HTML
+func Render(format, source string) (string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatMarkdown:
+		ctx := parser.NewContext(parser.WithIDs(&headingIDs{used: map[string]bool{}}))
+		if err := markdown.Convert([]byte(source), &buf, parser.WithContext(ctx)); err != nil {
+			return "", err
+		}
+	case FormatPlain:
+		renderPlain(&buf, source)
+	default:
+		return "", ErrUnknownFormat
+	}
+	return policy.Sanitize(buf.String()), nil
+}
+
+var blankLines = regexp.MustCompile(`\n\s*\n`)
+
+// renderPlain 空行分隔段落，段落内的换行转换为 <br>
+func renderPlain(buf *bytes.Buffer, source string) {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	for _, para := range blankLines.Split(source, -1) {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		lines := strings.Split(para, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		buf.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+}
+
+// headingIDs 标题锚点由标题文字生成，规则与文章 slug 相同（中文转换为拼音），重复时追加 -2、-3……
+type headingIDs struct {
+	used map[string]bool
+}
+
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := utils.Slugify(string(value))
+	if base == "" {
+		base = "section"
+	}
+	id := base
+	for n := 2; s.used[id]; n++ {
+		id = utils.SlugCandidate(base, n)
+	}
+	s.used[id] = true
+	return []byte(id)
+}
+
+func (s *headingIDs) Put(value []byte) {
+	s.used[string(value)] = true
+}
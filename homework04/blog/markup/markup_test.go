@@ -0,0 +1,73 @@
+package markup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender_Sanitizes(t *testing.T) {
+	source := "# 标题\n\n" +
+		"<script>alert(1)</script>\n\n" +
+		"[click](javascript:alert(1)) <img src=x onerror=alert(1)> <a href=\"https://example.com\" onclick=\"x()\">ok</a>\n\n" +
+		"- [x] done\n"
+	out, err := Render(FormatMarkdown, source)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	for _, bad := range []string{"<script", "javascript:", "onerror", "onclick"} {
+		if strings.Contains(out, bad) {
+			t.Errorf("output contains %q:\n%s", bad, out)
+		}
+	}
+	for _, good := range []string{`<h1 id="biao-ti">标题</h1>`, `href="https://example.com"`, `type="checkbox"`} {
+		if !strings.Contains(out, good) {
+			t.Errorf("output does not contain %q:\n%s", good, out)
+		}
+	}
+}
+
+func TestRender_Plain(t *testing.T) {
+	out, err := Render(FormatPlain, "line <b>1</b>\nline 2\r\n\r\n\n# not a heading")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	want := "<p>line &lt;b&gt;1&lt;/b&gt;<br>\nline 2</p>\n<p># not a heading</p>\n"
+	if out != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+	if _, err := Render("rst", "x"); err != ErrUnknownFormat {
+		t.Fatalf("unknown format: got %v", err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	out, err := Render(FormatMarkdown, "## Intro\n\nHello *world*, it's 2024.\n\n## Intro\n\n### 小结\n\n写得不错")
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	doc := Analyze(out)
+	wantHeadings := []Heading{
+		{Level: 2, ID: "intro", Title: "Intro"},
+		{Level: 2, ID: "intro-2", Title: "Intro"},
+		{Level: 3, ID: "xiao-jie", Title: "小结"},
+	}
+	if !reflect.DeepEqual(doc.Headings, wantHeadings) {
+		t.Fatalf("headings: got %+v, want %+v", doc.Headings, wantHeadings)
+	}
+	if doc.Text != "Intro Hello world, it's 2024. Intro 小结 写得不错" {
+		t.Fatalf("text: got %q", doc.Text)
+	}
+	// Intro Hello world it's 2024 Intro 各算一个词，每个汉字算一个词
+	if doc.Words != 12 {
+		t.Fatalf("words: got %d, want 12", doc.Words)
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	for words, want := range map[int]int{0: 0, 1: 1, WordsPerMinute: 1, WordsPerMinute + 1: 2} {
+		if got := ReadingMinutes(words); got != want {
+			t.Errorf("ReadingMinutes(%d) = %d, want %d", words, got, want)
+		}
+	}
+}
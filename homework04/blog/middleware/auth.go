@@ -2,14 +2,85 @@ package middleware
 
 import (
 	"blog/models"
+	"blog/rbac"
+	"blog/repository"
 	"blog/utils"
+	"context"
+	"errors"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware JWT认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+const (
+	// currentUserKey 当前用户在 gin.Context 中的键
+	currentUserKey = "current_user"
+	// twoFactorSetupKey 标记允许未启用两步验证的用户访问的接口
+	twoFactorSetupKey = "two_factor_setup"
+	// personalTokenScopeKey 接口接受个人访问令牌时要求的 scope
+	personalTokenScopeKey = "personal_token_scope"
+)
+
+// RevocationChecker 查询访问令牌是否已被吊销，以及令牌所属的登录会话是否仍然有效
+type RevocationChecker interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	// TouchSession 会话有效时记录最近活动时间和 IP 并返回 true，会话已吊销、已过期或不存在时返回 false
+	TouchSession(ctx context.Context, sessionID, ip string) (bool, error)
+}
+
+// UserLoader 按 ID 加载用户，用户不存在或已被删除时返回 repository.ErrNotFound
+type UserLoader interface {
+	LoadUser(ctx context.Context, id uint) (*models.User, error)
+}
+
+// TwoFactorPolicy 查询角色是否要求两步验证
+type TwoFactorPolicy interface {
+	Required(ctx context.Context, role rbac.Role) (bool, error)
+}
+
+// PersonalTokenAuthenticator 认证个人访问令牌，令牌不存在、已吊销或已过期时返回 repository.ErrNotFound
+type PersonalTokenAuthenticator interface {
+	AuthenticatePersonalToken(ctx context.Context, secret string) (*models.PersonalAccessToken, error)
+}
+
+// CurrentUser 当前请求的已认证用户，由 AuthMiddleware 设置
+type CurrentUser struct {
+	ID       uint
+	Username string
+	// Role 数据库中的当前角色，而不是令牌签发时的角色
+	Role rbac.Role
+	User *models.User
+	// Claims 使用 JWT 认证时的令牌声明，使用个人访问令牌时为 nil
+	Claims *utils.Claims
+	// PersonalToken 使用个人访问令牌认证时的令牌
+	PersonalToken *models.PersonalAccessToken
+}
+
+// Actor 用于 rbac 权限和策略判断
+func (u *CurrentUser) Actor() rbac.Actor {
+	return rbac.Actor{UserID: u.ID, Role: u.Role}
+}
+
+// AllowTwoFactorSetup 放在 AuthMiddleware 之前，允许角色要求两步验证但尚未启用的用户访问该接口，
+// 用于查看个人信息、登出和启用两步验证
+func AllowTwoFactorSetup(c *gin.Context) {
+	c.Set(twoFactorSetupKey, true)
+	c.Next()
+}
+
+// AcceptPersonalToken 放在 AuthMiddleware 之前，允许拥有 scope 的个人访问令牌访问该接口；
+// 没有标记的接口只接受登录得到的 JWT
+func AcceptPersonalToken(scope rbac.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(personalTokenScopeKey, scope)
+		c.Next()
+	}
+}
+
+// AuthMiddleware 认证中间件，接受 JWT 和个人访问令牌，拒绝已吊销（已登出）的令牌、已结束会话的令牌，以及已删除或被禁用的用户
+// 个人访问令牌只能访问 AcceptPersonalToken 标记、且令牌拥有所需 scope 的接口
+// 角色要求两步验证而用户尚未启用时，只允许访问 AllowTwoFactorSetup 标记的接口
+func AuthMiddleware(jwtManager *utils.JWTManager, revocations RevocationChecker, users UserLoader, twoFactor TwoFactorPolicy, personalTokens PersonalTokenAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -27,34 +98,173 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		claims, err := utils.ParseToken(tokenString)
+		var (
+			userID        uint
+			claims        *utils.Claims
+			personalToken *models.PersonalAccessToken
+		)
+		if strings.HasPrefix(tokenString, models.PersonalTokenPrefix) {
+			var ok bool
+			if personalToken, ok = authenticatePersonalToken(c, personalTokens, tokenString); !ok {
+				c.Abort()
+				return
+			}
+			userID = personalToken.UserID
+		} else {
+			var err error
+			claims, err = jwtManager.ParseToken(tokenString)
+			if err != nil {
+				utils.Unauthorized(c, "Invalid token")
+				c.Abort()
+				return
+			}
+
+			revoked, err := revocations.IsAccessTokenRevoked(c.Request.Context(), claims.ID)
+			if err != nil {
+				utils.InternalServerError(c, "Failed to verify token")
+				c.Abort()
+				return
+			}
+			if revoked {
+				utils.Unauthorized(c, "Token has been revoked")
+				c.Abort()
+				return
+			}
+			// 升级前签发的令牌没有 sid，只按 jti 检查
+			if claims.SessionID != "" {
+				active, err := revocations.TouchSession(c.Request.Context(), claims.SessionID, c.ClientIP())
+				if err != nil {
+					utils.InternalServerError(c, "Failed to verify session")
+					c.Abort()
+					return
+				}
+				if !active {
+					utils.Unauthorized(c, "Session has been revoked")
+					c.Abort()
+					return
+				}
+			}
+			userID = claims.UserID
+		}
+
+		// 从数据库（经过缓存）获取用户信息
+		user, err := users.LoadUser(c.Request.Context(), userID)
 		if err != nil {
-			utils.Unauthorized(c, "Invalid token")
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.Unauthorized(c, "User not found")
+			} else {
+				utils.InternalServerError(c, "Failed to load user")
+			}
 			c.Abort()
 			return
 		}
-
-		// 从数据库获取用户信息
-		// db := c.MustGet("db").(*gorm.DB)
-		// var user models.User
-		// if err := db.First(&user, claims.UserID).Error; err != nil {
-		// 	utils.Unauthorized(c, "User not found")
-		// 	c.Abort()
-		// 	return
-		// }
+		if user.Disabled {
+			utils.Forbidden(c, "Account is disabled")
+			c.Abort()
+			return
+		}
+		if !user.TwoFactorEnabled() && !c.GetBool(twoFactorSetupKey) {
+			required, err := twoFactor.Required(c.Request.Context(), rbac.Role(user.Role))
+			if err != nil {
+				utils.InternalServerError(c, "Failed to load security policy")
+				c.Abort()
+				return
+			}
+			if required {
+				utils.Forbidden(c, "Two-factor authentication is required for your role")
+				c.Abort()
+				return
+			}
+		}
 
 		// 将用户信息存储到上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		SetCurrentUser(c, &CurrentUser{
+			ID:            user.ID,
+			Username:      user.Username,
+			Role:          rbac.Role(user.Role),
+			User:          user,
+			Claims:        claims,
+			PersonalToken: personalToken,
+		})
 		c.Next()
 	}
 }
 
-// GetUserFromContext 从上下文中获取用户信息
-func GetUserFromContext(c *gin.Context) *models.User {
-	user, exists := c.Get("user")
+// OptionalAuth 用于公开接口：请求带有 Authorization 头时按 auth 认证（令牌无效时同样拒绝），否则以匿名身份继续，
+// 处理器通过 CurrentUserFromContext 区分，用于向作者展示其未发布的文章
+func OptionalAuth(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// authenticatePersonalToken 认证个人访问令牌并检查接口要求的 scope，失败时写入响应并返回 false
+func authenticatePersonalToken(c *gin.Context, personalTokens PersonalTokenAuthenticator, secret string) (*models.PersonalAccessToken, bool) {
+	token, err := personalTokens.AuthenticatePersonalToken(c.Request.Context(), secret)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.Unauthorized(c, "Invalid token")
+		} else {
+			utils.InternalServerError(c, "Failed to verify token")
+		}
+		return nil, false
+	}
+
+	value, ok := c.Get(personalTokenScopeKey)
+	if !ok {
+		utils.Forbidden(c, "Personal access tokens cannot be used for this endpoint")
+		return nil, false
+	}
+	required := value.(rbac.Scope)
+	granted := make([]rbac.Scope, 0, len(token.ScopeList()))
+	for _, scope := range token.ScopeList() {
+		granted = append(granted, rbac.Scope(scope))
+	}
+	if !rbac.Allows(granted, required) {
+		utils.Forbidden(c, "Token scope does not allow this request: requires "+string(required))
+		return nil, false
+	}
+	return token, true
+}
+
+// SetCurrentUser 设置当前用户，供 AuthMiddleware 和测试使用
+func SetCurrentUser(c *gin.Context, user *CurrentUser) {
+	c.Set(currentUserKey, user)
+}
+
+// CurrentUserFromContext 获取当前用户，未认证时返回 nil
+func CurrentUserFromContext(c *gin.Context) *CurrentUser {
+	user, exists := c.Get(currentUserKey)
 	if !exists {
 		return nil
 	}
-	return user.(*models.User)
+	return user.(*CurrentUser)
+}
+
+// CurrentUserID 当前用户的 ID，未认证时返回 0
+func CurrentUserID(c *gin.Context) uint {
+	if user := CurrentUserFromContext(c); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+// GetClaimsFromContext 获取当前请求的令牌声明
+func GetClaimsFromContext(c *gin.Context) *utils.Claims {
+	if user := CurrentUserFromContext(c); user != nil {
+		return user.Claims
+	}
+	return nil
+}
+
+// GetUserFromContext 从上下文中获取用户信息
+func GetUserFromContext(c *gin.Context) *models.User {
+	if user := CurrentUserFromContext(c); user != nil {
+		return user.User
+	}
+	return nil
 }
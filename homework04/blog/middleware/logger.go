@@ -19,7 +19,7 @@ func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 		end := time.Now()
 		latency := end.Sub(start)
 
-		logger.Info("Request",
+		fields := []zap.Field{
 			zap.Int("status", c.Writer.Status()),
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
@@ -27,6 +27,11 @@ func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 			zap.String("ip", c.ClientIP()),
 			zap.String("user-agent", c.Request.UserAgent()),
 			zap.Duration("latency", latency),
-		)
+		}
+		// 处理器通过 c.Error 记录的内部错误，不会返回给客户端
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+		logger.Info("Request", fields...)
 	}
 }
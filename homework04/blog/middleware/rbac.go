@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"blog/rbac"
+	"blog/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 要求当前用户的角色拥有权限 perm，需放在 AuthMiddleware 之后
+// 只能判断角色本身的权限；涉及资源归属（own/any）的判断由服务层的 rbac 策略完成
+func RequirePermission(perm rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !GetActorFromContext(c).Can(perm) {
+			utils.Forbidden(c, "Permission denied: "+string(perm))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetActorFromContext 获取当前用户及其角色，未认证时返回零值（没有任何权限）
+func GetActorFromContext(c *gin.Context) rbac.Actor {
+	if user := CurrentUserFromContext(c); user != nil {
+		return user.Actor()
+	}
+	return rbac.Actor{}
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 以下结构体是 0001 版本时的表结构快照，后续模型变更不应修改这里
+
+type user0001 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Username  string `gorm:"uniqueIndex;not null;size:50"`
+	Password  string `gorm:"not null"`
+	Email     string `gorm:"uniqueIndex;not null;size:100"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (user0001) TableName() string { return "users" }
+
+type post0001 struct {
+	ID        uint     `gorm:"primaryKey"`
+	Title     string   `gorm:"not null;size:200"`
+	Content   string   `gorm:"type:text;not null"`
+	UserID    uint     `gorm:"not null;index"`
+	User      user0001 `gorm:"foreignKey:UserID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (post0001) TableName() string { return "posts" }
+
+type comment0001 struct {
+	ID        uint     `gorm:"primaryKey"`
+	Content   string   `gorm:"type:text;not null"`
+	UserID    uint     `gorm:"not null;index"`
+	User      user0001 `gorm:"foreignKey:UserID"`
+	PostID    uint     `gorm:"not null;index"`
+	Post      post0001 `gorm:"foreignKey:PostID"`
+	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (comment0001) TableName() string { return "comments" }
+
+func init() {
+	register(&Migration{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			// 兼容此前由 AutoMigrate 建好的库：已存在的表直接跳过
+			return createTables(tx, &user0001{}, &post0001{}, &comment0001{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&comment0001{}, &post0001{}, &user0001{})
+		},
+	})
+}
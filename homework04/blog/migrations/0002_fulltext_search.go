@@ -0,0 +1,45 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// 全文索引只在 MySQL 上创建，其他数据库使用内存索引，这里不做任何操作
+// ngram 解析器按相邻字符切分，中文无需分词插件（MySQL 5.7.6+）
+
+func init() {
+	register(&Migration{
+		Version: 2,
+		Name:    "fulltext_search",
+		Up: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			if !tx.Migrator().HasIndex("posts", "ft_posts_title_content") {
+				if err := tx.Exec("ALTER TABLE posts ADD FULLTEXT INDEX ft_posts_title_content (title, content) WITH PARSER ngram").Error; err != nil {
+					return err
+				}
+			}
+			if !tx.Migrator().HasIndex("comments", "ft_comments_content") {
+				if err := tx.Exec("ALTER TABLE comments ADD FULLTEXT INDEX ft_comments_content (content) WITH PARSER ngram").Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			if tx.Dialector.Name() != "mysql" {
+				return nil
+			}
+			for table, index := range map[string]string{
+				"posts":    "ft_posts_title_content",
+				"comments": "ft_comments_content",
+			} {
+				if tx.Migrator().HasIndex(table, index) {
+					if err := tx.Migrator().DropIndex(table, index); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type refreshToken0003 struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	FamilyID  string    `gorm:"not null;size:64;index"`
+	TokenHash string    `gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+func (refreshToken0003) TableName() string { return "refresh_tokens" }
+
+type revokedToken0003 struct {
+	JTI       string    `gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+func (revokedToken0003) TableName() string { return "revoked_tokens" }
+
+func init() {
+	register(&Migration{
+		Version: 3,
+		Name:    "refresh_tokens",
+		Up: func(tx *gorm.DB) error {
+			return createTables(tx, &refreshToken0003{}, &revokedToken0003{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&revokedToken0003{}, &refreshToken0003{})
+		},
+	})
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import "gorm.io/gorm"
+
+type userRole0004 struct {
+	Role string `gorm:"not null;size:20;default:author"`
+}
+
+func (userRole0004) TableName() string { return "users" }
+
+func init() {
+	register(&Migration{
+		Version: 4,
+		Name:    "user_roles",
+		Up: func(tx *gorm.DB) error {
+			// 已有用户都按默认角色 author 处理
+			return addColumns(tx, &userRole0004{}, "Role")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&userRole0004{}, "Role")
+		},
+	})
+}
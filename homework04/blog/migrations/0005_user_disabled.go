@@ -0,0 +1,22 @@
+package migrations
+
+import "gorm.io/gorm"
+
+type userDisabled0005 struct {
+	Disabled bool `gorm:"not null;default:false"`
+}
+
+func (userDisabled0005) TableName() string { return "users" }
+
+func init() {
+	register(&Migration{
+		Version: 5,
+		Name:    "user_disabled",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &userDisabled0005{}, "Disabled")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&userDisabled0005{}, "Disabled")
+		},
+	})
+}
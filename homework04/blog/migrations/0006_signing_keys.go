@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type signingKey0006 struct {
+	KID        string    `gorm:"column:kid;primaryKey;size:64"`
+	Algorithm  string    `gorm:"not null;size:16"`
+	PrivateKey string    `gorm:"type:text;not null"`
+	PublicKey  string    `gorm:"type:text;not null"`
+	CreatedAt  time.Time `gorm:"index"`
+	RetiredAt  *time.Time
+	ExpiresAt  *time.Time `gorm:"index"`
+}
+
+func (signingKey0006) TableName() string { return "signing_keys" }
+
+func init() {
+	register(&Migration{
+		Version: 6,
+		Name:    "signing_keys",
+		Up: func(tx *gorm.DB) error {
+			return createTables(tx, &signingKey0006{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&signingKey0006{})
+		},
+	})
+}
@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type userEmailVerified0007 struct {
+	EmailVerifiedAt *time.Time
+}
+
+func (userEmailVerified0007) TableName() string { return "users" }
+
+func init() {
+	register(&Migration{
+		Version: 7,
+		Name:    "user_email_verified",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &userEmailVerified0007{}, "EmailVerifiedAt")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&userEmailVerified0007{}, "EmailVerifiedAt")
+		},
+	})
+}
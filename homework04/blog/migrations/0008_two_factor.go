@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type userTwoFactor0008 struct {
+	TOTPSecret    string     `gorm:"column:totp_secret;size:255"`
+	TOTPEnabledAt *time.Time `gorm:"column:totp_enabled_at"`
+	TOTPLastStep  int64      `gorm:"column:totp_last_step;not null;default:0"`
+}
+
+func (userTwoFactor0008) TableName() string { return "users" }
+
+type recoveryCode0008 struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"not null;size:64"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+func (recoveryCode0008) TableName() string { return "recovery_codes" }
+
+type rolePolicy0008 struct {
+	Role             string `gorm:"primaryKey;size:20"`
+	RequireTwoFactor bool   `gorm:"not null;default:false"`
+	UpdatedAt        time.Time
+}
+
+func (rolePolicy0008) TableName() string { return "role_policies" }
+
+var userTwoFactorColumns0008 = []string{"TOTPSecret", "TOTPEnabledAt", "TOTPLastStep"}
+
+func init() {
+	register(&Migration{
+		Version: 8,
+		Name:    "two_factor",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &userTwoFactor0008{}, userTwoFactorColumns0008...); err != nil {
+				return err
+			}
+			return createTables(tx, &recoveryCode0008{}, &rolePolicy0008{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&rolePolicy0008{}, &recoveryCode0008{}); err != nil {
+				return err
+			}
+			for _, column := range userTwoFactorColumns0008 {
+				if err := tx.Migrator().DropColumn(&userTwoFactor0008{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
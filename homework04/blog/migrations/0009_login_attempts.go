@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type loginCounter0009 struct {
+	Subject       string    `gorm:"primaryKey;size:100"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null;index"`
+	LockedUntil   *time.Time
+}
+
+func (loginCounter0009) TableName() string { return "login_counters" }
+
+type loginEvent0009 struct {
+	ID          uint   `gorm:"primaryKey"`
+	Type        string `gorm:"not null;size:20"`
+	Subject     string `gorm:"not null;size:100;index"`
+	Username    string `gorm:"size:50;index"`
+	IP          string `gorm:"column:ip;size:45"`
+	Failures    int    `gorm:"not null;default:0"`
+	LockedUntil *time.Time
+	ActorID     *uint
+	CreatedAt   time.Time `gorm:"index"`
+}
+
+func (loginEvent0009) TableName() string { return "login_events" }
+
+func init() {
+	register(&Migration{
+		Version: 9,
+		Name:    "login_attempts",
+		Up: func(tx *gorm.DB) error {
+			return createTables(tx, &loginCounter0009{}, &loginEvent0009{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&loginEvent0009{}, &loginCounter0009{})
+		},
+	})
+}
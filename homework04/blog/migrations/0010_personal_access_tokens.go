@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type personalAccessToken0010 struct {
+	ID         uint       `gorm:"primaryKey"`
+	UserID     uint       `gorm:"not null;index"`
+	Name       string     `gorm:"not null;size:100"`
+	Prefix     string     `gorm:"not null;size:20"`
+	TokenHash  string     `gorm:"not null;size:64;uniqueIndex"`
+	Scopes     string     `gorm:"not null;size:255"`
+	ExpiresAt  *time.Time `gorm:"index"`
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+func (personalAccessToken0010) TableName() string { return "personal_access_tokens" }
+
+func init() {
+	register(&Migration{
+		Version: 10,
+		Name:    "personal_access_tokens",
+		Up: func(tx *gorm.DB) error {
+			return createTables(tx, &personalAccessToken0010{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&personalAccessToken0010{})
+		},
+	})
+}
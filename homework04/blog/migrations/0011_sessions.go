@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type session0011 struct {
+	ID         string `gorm:"primaryKey;size:64"`
+	UserID     uint   `gorm:"not null;index"`
+	UserAgent  string `gorm:"size:255"`
+	IP         string `gorm:"column:ip;size:45"`
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time `gorm:"not null;index"`
+	RevokedAt  *time.Time
+}
+
+func (session0011) TableName() string { return "sessions" }
+
+func init() {
+	register(&Migration{
+		Version: 11,
+		Name:    "sessions",
+		Up: func(tx *gorm.DB) error {
+			return createTables(tx, &session0011{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&session0011{})
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import "gorm.io/gorm"
+
+type userProfile0012 struct {
+	DisplayName string `gorm:"size:50"`
+	Bio         string `gorm:"size:500"`
+	AvatarURL   string `gorm:"column:avatar_url;size:255"`
+}
+
+func (userProfile0012) TableName() string { return "users" }
+
+var userProfileColumns0012 = []string{"DisplayName", "Bio", "AvatarURL"}
+
+func init() {
+	register(&Migration{
+		Version: 12,
+		Name:    "user_profile",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &userProfile0012{}, userProfileColumns0012...)
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range userProfileColumns0012 {
+				if err := tx.Migrator().DropColumn(&userProfile0012{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type category0013 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex;not null;size:50"`
+	ParentID  *uint  `gorm:"index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (category0013) TableName() string { return "categories" }
+
+type tag0013 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"uniqueIndex;not null;size:30"`
+	CreatedAt time.Time
+}
+
+func (tag0013) TableName() string { return "tags" }
+
+// postTag0013 文章和标签的关联表，按标签查文章时使用 tag_id 索引
+type postTag0013 struct {
+	PostID uint `gorm:"primaryKey;autoIncrement:false"`
+	TagID  uint `gorm:"primaryKey;autoIncrement:false;index"`
+}
+
+func (postTag0013) TableName() string { return "post_tags" }
+
+type postCategory0013 struct {
+	CategoryID *uint `gorm:"index"`
+}
+
+func (postCategory0013) TableName() string { return "posts" }
+
+func init() {
+	register(&Migration{
+		Version: 13,
+		Name:    "taxonomy",
+		Up: func(tx *gorm.DB) error {
+			if err := createTables(tx, &category0013{}, &tag0013{}, &postTag0013{}); err != nil {
+				return err
+			}
+			if err := addColumns(tx, &postCategory0013{}, "CategoryID"); err != nil {
+				return err
+			}
+			return createIndex(tx, &postCategory0013{}, "CategoryID")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&postCategory0013{}, "CategoryID"); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropColumn(&postCategory0013{}, "CategoryID"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropTable(&postTag0013{}, &tag0013{}, &category0013{})
+		},
+	})
+}
@@ -0,0 +1,45 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// postStatus0014 定时发布按 (status, published_at) 查找到期的文章
+type postStatus0014 struct {
+	Status      string     `gorm:"not null;size:20;default:published;index:idx_posts_status_published_at,priority:1"`
+	PublishedAt *time.Time `gorm:"index:idx_posts_status_published_at,priority:2"`
+}
+
+func (postStatus0014) TableName() string { return "posts" }
+
+var postStatusColumns0014 = []string{"Status", "PublishedAt"}
+
+func init() {
+	register(&Migration{
+		Version: 14,
+		Name:    "post_status",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &postStatus0014{}, postStatusColumns0014...); err != nil {
+				return err
+			}
+			if err := createIndex(tx, &postStatus0014{}, "idx_posts_status_published_at"); err != nil {
+				return err
+			}
+			// 已有文章都按默认状态 published 处理，发布时间取创建时间
+			return tx.Exec("UPDATE posts SET published_at = created_at WHERE published_at IS NULL").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropIndex(&postStatus0014{}, "idx_posts_status_published_at"); err != nil {
+				return err
+			}
+			for _, column := range postStatusColumns0014 {
+				if err := tx.Migrator().DropColumn(&postStatus0014{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
@@ -0,0 +1,155 @@
+package migrations
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+type postSlug0015 struct {
+	Slug string `gorm:"size:100;uniqueIndex"`
+}
+
+func (postSlug0015) TableName() string { return "posts" }
+
+// postSlugHistory0015 文章用过的旧 slug
+type postSlugHistory0015 struct {
+	ID        uint   `gorm:"primaryKey"`
+	PostID    uint   `gorm:"not null;index"`
+	Slug      string `gorm:"not null;size:100;uniqueIndex"`
+	CreatedAt time.Time
+}
+
+func (postSlugHistory0015) TableName() string { return "post_slugs" }
+
+func init() {
+	register(&Migration{
+		Version: 15,
+		Name:    "post_slugs",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &postSlug0015{}, "Slug"); err != nil {
+				return err
+			}
+			if err := backfillSlugs0015(tx); err != nil {
+				return err
+			}
+			if err := createIndex(tx, &postSlug0015{}, "Slug"); err != nil {
+				return err
+			}
+			return createTables(tx, &postSlugHistory0015{})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(&postSlugHistory0015{}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropIndex(&postSlug0015{}, "Slug"); err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&postSlug0015{}, "Slug")
+		},
+	})
+}
+
+// backfillSlugs0015 由标题为还没有 slug 的文章（包括已删除的）生成 slug，重复时按 ID 顺序追加 -2、-3……；
+// AutoMigrate 建好的库中已有的 slug 保持不变
+func backfillSlugs0015(tx *gorm.DB) error {
+	var posts []struct {
+		ID    uint
+		Title string
+		Slug  *string
+	}
+	if err := tx.Table("posts").Select("id, title, slug").Order("id").Find(&posts).Error; err != nil {
+		return err
+	}
+	used := make(map[string]bool, len(posts))
+	for _, post := range posts {
+		if post.Slug != nil && *post.Slug != "" {
+			used[*post.Slug] = true
+		}
+	}
+	for _, post := range posts {
+		if post.Slug != nil && *post.Slug != "" {
+			continue
+		}
+		base := slugify0015(post.Title)
+		if base == "" {
+			base = "post"
+		}
+		slug := base
+		for n := 2; used[slug]; n++ {
+			slug = slugCandidate0015(base, n)
+		}
+		used[slug] = true
+		if err := tx.Table("posts").Where("id = ?", post.ID).Update("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 以下是 0015 版本时 utils.Slugify 和 utils.SlugCandidate 的快照，后续修改 slug 规则不应修改这里
+
+const maxSlugLength0015 = 80
+
+func slugify0015(title string) string {
+	var (
+		words []string
+		word  strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	args := pinyin.NewArgs()
+	for _, r := range norm.NFD.String(title) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if syllables := pinyin.SinglePinyin(r, args); len(syllables) > 0 {
+				words = append(words, strings.ReplaceAll(syllables[0], "ü", "v"))
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	var slug strings.Builder
+	for _, w := range words {
+		if slug.Len() == 0 {
+			if len(w) > maxSlugLength0015 {
+				w = w[:maxSlugLength0015]
+			}
+			slug.WriteString(w)
+			continue
+		}
+		if slug.Len()+1+len(w) > maxSlugLength0015 {
+			break
+		}
+		slug.WriteByte('-')
+		slug.WriteString(w)
+	}
+	return slug.String()
+}
+
+func slugCandidate0015(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	suffix := "-" + strconv.Itoa(n)
+	if len(base)+len(suffix) > maxSlugLength0015 {
+		base = strings.TrimRight(base[:maxSlugLength0015-len(suffix)], "-")
+	}
+	return base + suffix
+}
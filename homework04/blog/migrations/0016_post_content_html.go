@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type postContent0016 struct {
+	ContentFormat string `gorm:"not null;size:20;default:markdown"`
+	ContentHTML   string `gorm:"type:text"`
+}
+
+func (postContent0016) TableName() string { return "posts" }
+
+var postContentColumns0016 = []string{"ContentFormat", "ContentHTML"}
+
+func init() {
+	register(&Migration{
+		Version: 16,
+		Name:    "post_content_html",
+		Up: func(tx *gorm.DB) error {
+			if err := addColumns(tx, &postContent0016{}, postContentColumns0016...); err != nil {
+				return err
+			}
+			return backfillContentHTML0016(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			for _, column := range postContentColumns0016 {
+				if err := tx.Migrator().DropColumn(&postContent0016{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// backfillContentHTML0016 已有文章的正文原样保存，按纯文本渲染，避免其中的 #、* 等字符被当作 Markdown 语法；
+// AutoMigrate 建好的库中已经渲染过的文章保持不变
+func backfillContentHTML0016(tx *gorm.DB) error {
+	var posts []struct {
+		ID      uint
+		Content string
+	}
+	if err := tx.Table("posts").Select("id, content").Where("content_html IS NULL OR content_html = ''").
+		Order("id").Find(&posts).Error; err != nil {
+		return err
+	}
+	for _, post := range posts {
+		if err := tx.Table("posts").Where("id = ?", post.ID).Updates(map[string]interface{}{
+			"content_format": "plain",
+			"content_html":   renderPlain0016(post.Content),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var blankLines0016 = regexp.MustCompile(`\n\s*\n`)
+
+// renderPlain0016 0016 版本时 markup.Render 的纯文本渲染快照，后续修改渲染规则不应修改这里：
+// 空行分隔段落，段落内的换行转换为 <br>；文本全部转义，输出无需再经过 HTML 过滤
+func renderPlain0016(source string) string {
+	var buf strings.Builder
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	for _, para := range blankLines0016.Split(source, -1) {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		lines := strings.Split(para, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		buf.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+	return buf.String()
+}
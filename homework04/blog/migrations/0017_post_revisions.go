@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type postRevision0017 struct {
+	ID            uint   `gorm:"primaryKey"`
+	PostID        uint   `gorm:"not null;uniqueIndex:idx_post_revisions_post_number,priority:1"`
+	Number        int    `gorm:"not null;uniqueIndex:idx_post_revisions_post_number,priority:2"`
+	Title         string `gorm:"not null;size:200"`
+	Content       string `gorm:"type:text;not null"`
+	ContentFormat string `gorm:"not null;size:20"`
+	EditorID      uint   `gorm:"not null;index"`
+	Note          string `gorm:"size:200"`
+	RestoredFrom  *int
+	CreatedAt     time.Time
+}
+
+func (postRevision0017) TableName() string { return "post_revisions" }
+
+func init() {
+	register(&Migration{
+		Version: 17,
+		Name:    "post_revisions",
+		Up: func(tx *gorm.DB) error {
+			if err := createTables(tx, &postRevision0017{}); err != nil {
+				return err
+			}
+			// 还没有版本历史的文章（包括已删除的）以当前内容作为第 1 个版本，编辑者为作者
+			return tx.Exec(`INSERT INTO post_revisions (post_id, number, title, content, content_format, editor_id, note, created_at)
+				SELECT id, 1, title, content, content_format, user_id, '', updated_at FROM posts
+				WHERE NOT EXISTS (SELECT 1 FROM post_revisions WHERE post_revisions.post_id = posts.id)`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&postRevision0017{})
+		},
+	})
+}
@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var (
+	migrationNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	migrationFilePattern = regexp.MustCompile(`^(\d{4})_.*\.go$`)
+)
+
+var migrationTemplate = template.Must(template.New("migration").Parse(`package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(&Migration{
+		Version: {{.Version}},
+		Name:    "{{.Name}}",
+		Up: func(tx *gorm.DB) error {
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			return nil
+		},
+	})
+}
+`))
+
+// Create 在 dir 目录下生成下一个版本号的迁移文件，返回文件路径
+func Create(dir, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !migrationNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid migration name %q: use lower_snake_case", name)
+	}
+
+	version, err := nextVersion(dir)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%04d_%s.go", version, name))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create migration file: %w", err)
+	}
+	defer file.Close()
+
+	data := struct {
+		Version uint
+		Name    string
+	}{version, name}
+	if err := migrationTemplate.Execute(file, data); err != nil {
+		return "", fmt.Errorf("failed to write migration file: %w", err)
+	}
+	return path, nil
+}
+
+// nextVersion 取已注册迁移和目录中迁移文件的最大版本号加一
+func nextVersion(dir string) (uint, error) {
+	var max uint
+	for _, m := range All() {
+		if m.Version > max {
+			max = m.Version
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.ParseUint(match[1], 10, 32)
+		if uint(version) > max {
+			max = uint(version)
+		}
+	}
+	return max + 1, nil
+}
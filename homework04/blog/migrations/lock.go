@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// staleLockAfter 超过该时长未刷新的锁视为持有者已崩溃，可以被抢占
+const staleLockAfter = 10 * time.Minute
+
+// lockRefreshInterval 持有锁期间刷新 locked_at 的间隔，必须明显小于 staleLockAfter，
+// 耗时超过 staleLockAfter 的迁移也不会被其他实例抢占
+const lockRefreshInterval = time.Minute
+
+// ErrLockTimeout 等待迁移锁超时
+var ErrLockTimeout = errors.New("timed out waiting for migration lock")
+
+// migrationLock 迁移锁表，最多只有一行 (id = 1)，主键冲突保证同一时刻只有一个实例能迁移
+type migrationLock struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string    `gorm:"size:255;not null"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定表名
+func (migrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// lockOwner 当前进程的锁持有者标识
+func lockOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano())
+}
+
+// withLock 获取迁移锁后执行 fn，执行期间定期刷新锁，执行完毕释放锁
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	owner := lockOwner()
+	if err := m.acquireLock(ctx, owner); err != nil {
+		return err
+	}
+	stop := m.refreshLock(owner)
+	defer func() {
+		stop()
+		m.db.Where("id = ? AND owner = ?", 1, owner).Delete(&migrationLock{})
+	}()
+	return fn()
+}
+
+// refreshLock 每隔 lockRefresh 更新一次锁的 locked_at，返回停止刷新的函数；
+// 刷新失败（如 SQLite 上迁移事务正在写库）时等下一次再试
+func (m *Migrator) refreshLock(owner string) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(m.lockRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				m.db.Model(&migrationLock{}).Where("id = ? AND owner = ?", 1, owner).Update("locked_at", time.Now())
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// acquireLock 循环尝试插入锁记录，直到成功、超时或 ctx 取消
+func (m *Migrator) acquireLock(ctx context.Context, owner string) error {
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		lock := migrationLock{ID: 1, Owner: owner, LockedAt: time.Now()}
+		if err := m.db.Create(&lock).Error; err == nil {
+			return nil
+		}
+
+		// 清理过期的锁
+		m.db.Where("id = ? AND locked_at < ?", 1, time.Now().Add(-staleLockAfter)).Delete(&migrationLock{})
+
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
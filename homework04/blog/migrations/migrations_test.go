@@ -0,0 +1,222 @@
+package migrations
+
+import (
+	"blog/config"
+	"blog/models"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var dbCounter atomic.Int64
+
+// openDB 打开一个独立的 SQLite 内存库；autoMigrate 为 true 时按当前模型执行 AutoMigrate，模拟引入版本化迁移之前建好的库
+func openDB(t *testing.T, autoMigrate bool) *gorm.DB {
+	t.Helper()
+	db, err := config.InitDB(config.DatabaseConfig{
+		Driver:      config.DriverSQLite,
+		Path:        fmt.Sprintf("file:migrationtest%d?mode=memory&cache=shared", dbCounter.Add(1)),
+		AutoMigrate: autoMigrate,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestUpAdoptsAutoMigratedSchema(t *testing.T) {
+	db := openDB(t, true)
+	user := models.User{Username: "alice", Password: "x", Email: "alice@example.com"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	post := models.Post{Title: "Hello", Slug: "kept", Content: "# Hi", ContentFormat: "markdown", ContentHTML: "<h1>Hi</h1>", UserID: user.ID}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatalf("create post: %v", err)
+	}
+
+	if _, err := NewMigrator(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate auto-migrated database: %v", err)
+	}
+
+	// 已有的数据保持不变，只补齐缺少的版本历史
+	var got models.Post
+	if err := db.First(&got, post.ID).Error; err != nil {
+		t.Fatalf("load post: %v", err)
+	}
+	if got.Slug != "kept" || got.ContentFormat != "markdown" || got.ContentHTML != "<h1>Hi</h1>" {
+		t.Fatalf("post changed by migrations: %+v", got)
+	}
+	var revisions int64
+	if err := db.Model(&models.PostRevision{}).Where("post_id = ?", post.ID).Count(&revisions).Error; err != nil || revisions != 1 {
+		t.Fatalf("revisions: got %d, %v", revisions, err)
+	}
+}
+
+func TestLockRefreshedWhileHeld(t *testing.T) {
+	migrator := NewMigrator(openDB(t, false))
+	migrator.lockRefresh = 10 * time.Millisecond
+	if err := migrator.ensureTables(); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := time.Now().Add(-2 * staleLockAfter)
+	err := migrator.withLock(context.Background(), func() error {
+		// 模拟已经持有锁很久的迁移
+		if err := migrator.db.Model(&migrationLock{}).Where("id = ?", 1).Update("locked_at", stale).Error; err != nil {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+		var lock migrationLock
+		if err := migrator.db.First(&lock, 1).Error; err != nil {
+			return err
+		}
+		if !lock.LockedAt.After(stale) {
+			return fmt.Errorf("lock not refreshed: locked_at %v", lock.LockedAt)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	migrator.db.Model(&migrationLock{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("lock not released: %d rows", count)
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t, false)
+	migrator := NewMigrator(db)
+	all := All()
+	latest := all[len(all)-1].Version
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || len(applied) != len(all) {
+		t.Fatalf("Up: applied %d of %d, err %v", len(applied), len(all), err)
+	}
+	if version, err := CurrentVersion(db); err != nil || version != latest {
+		t.Fatalf("CurrentVersion after Up: got %d, %v, want %d", version, err, latest)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up: applied %d, err %v", len(applied), err)
+	}
+
+	reverted, err := migrator.Down(ctx, 2)
+	if err != nil || len(reverted) != 2 || reverted[0].Version != latest {
+		t.Fatalf("Down: reverted %v, err %v", reverted, err)
+	}
+	if pending, err := migrator.Pending(); err != nil || pending != 2 {
+		t.Fatalf("Pending after Down: got %d, %v, want 2", pending, err)
+	}
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, status := range statuses {
+		if want := status.Version < reverted[1].Version; status.Applied != want {
+			t.Fatalf("status of %04d_%s: applied %v, want %v", status.Version, status.Name, status.Applied, want)
+		}
+	}
+
+	// 回滚的迁移可以重新执行
+	if applied, err := migrator.Up(ctx); err != nil || len(applied) != 2 {
+		t.Fatalf("Up after Down: applied %d, err %v", len(applied), err)
+	}
+	if version, err := CurrentVersion(db); err != nil || version != latest {
+		t.Fatalf("CurrentVersion after reapply: got %d, %v, want %d", version, err, latest)
+	}
+}
+
+func TestLockContention(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t, false)
+	holder := NewMigrator(db)
+	if err := holder.ensureTables(); err != nil {
+		t.Fatal(err)
+	}
+	waiter := NewMigrator(db)
+	waiter.lockTimeout = 0
+
+	err := holder.withLock(ctx, func() error {
+		// 锁被其他实例持有时不执行迁移
+		if _, err := waiter.Up(ctx); !errors.Is(err, ErrLockTimeout) {
+			return fmt.Errorf("Up while locked: got %v, want ErrLockTimeout", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := CurrentVersion(db); err != nil || version != 0 {
+		t.Fatalf("CurrentVersion: got %d, %v, want 0", version, err)
+	}
+
+	// 持有者崩溃后留下的过期锁可以被抢占
+	stale := migrationLock{ID: 1, Owner: "crashed", LockedAt: time.Now().Add(-2 * staleLockAfter)}
+	if err := db.Create(&stale).Error; err != nil {
+		t.Fatalf("create stale lock: %v", err)
+	}
+	waiter.lockTimeout = time.Second
+	if _, err := waiter.Up(ctx); err != nil {
+		t.Fatalf("Up with stale lock: %v", err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	all := All()
+	next := all[len(all)-1].Version + 1
+
+	path, err := Create(dir, "Add_Post_Likes")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if want := filepath.Join(dir, fmt.Sprintf("%04d_add_post_likes.go", next)); path != want {
+		t.Fatalf("path: got %s, want %s", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read migration: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), path, data, 0); err != nil {
+		t.Fatalf("generated file does not parse: %v", err)
+	}
+	if !strings.Contains(string(data), fmt.Sprintf("Version: %d,", next)) || !strings.Contains(string(data), `Name:    "add_post_likes"`) {
+		t.Fatalf("generated file:\n%s", data)
+	}
+
+	// 目录中已有的文件也参与编号
+	if err := os.WriteFile(filepath.Join(dir, "0100_future.go"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := Create(dir, "after_future"); err != nil || filepath.Base(path) != "0101_after_future.go" {
+		t.Fatalf("Create after 0100: got %s, %v", path, err)
+	}
+
+	for _, name := range []string{"", "add-likes", "1st", "../escape"} {
+		if _, err := Create(dir, name); err == nil {
+			t.Fatalf("Create(%q): expected error", name)
+		}
+	}
+}
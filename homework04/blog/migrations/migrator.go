@@ -0,0 +1,234 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 一个带版本号的数据库迁移
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration 记录已执行迁移的表 schema_migrations
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 指定表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus 迁移状态
+type MigrationStatus struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var registry = map[uint]*Migration{}
+
+// register 注册迁移，由各迁移文件的 init 调用
+func register(m *Migration) {
+	if _, exists := registry[m.Version]; exists {
+		panic(fmt.Sprintf("migrations: duplicate migration version %04d", m.Version))
+	}
+	registry[m.Version] = m
+}
+
+// All 按版本号升序返回所有已注册的迁移
+func All() []*Migration {
+	list := make([]*Migration, 0, len(registry))
+	for _, m := range registry {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+// Migrator 执行版本化迁移
+type Migrator struct {
+	db          *gorm.DB
+	lockTimeout time.Duration
+	lockRefresh time.Duration
+}
+
+// NewMigrator 创建迁移执行器
+func NewMigrator(db *gorm.DB) *Migrator {
+	return &Migrator{db: db, lockTimeout: time.Minute, lockRefresh: lockRefreshInterval}
+}
+
+// ensureTables 确保迁移记录表和锁表存在
+func (m *Migrator) ensureTables() error {
+	if err := m.db.AutoMigrate(&SchemaMigration{}, &migrationLock{}); err != nil {
+		return fmt.Errorf("failed to create migration tables: %w", err)
+	}
+	return nil
+}
+
+// applied 返回已执行的迁移记录，按版本号索引
+func (m *Migrator) applied() (map[uint]SchemaMigration, error) {
+	var rows []SchemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	result := make(map[uint]SchemaMigration, len(rows))
+	for _, row := range rows {
+		result[row.Version] = row
+	}
+	return result, nil
+}
+
+// Up 执行所有未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	if err := m.ensureTables(); err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		for _, migration := range All() {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("steps must be positive")
+	}
+	if err := m.ensureTables(); err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		all := All()
+		for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := all[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.rollback(migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status 返回所有迁移的执行状态
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.ensureTables(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range All() {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending 返回未执行的迁移数量
+func (m *Migrator) Pending() (int, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// CurrentVersion 返回数据库当前的迁移版本，未执行任何迁移时返回 0
+func CurrentVersion(db *gorm.DB) (uint, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return 0, nil
+	}
+	var version uint
+	if err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// apply 在事务中执行单个迁移并记录版本
+func (m *Migrator) apply(migration *Migration) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		return tx.Create(&SchemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s up failed: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// rollback 在事务中回滚单个迁移并删除版本记录
+func (m *Migrator) rollback(migration *Migration) error {
+	if migration.Down == nil {
+		return fmt.Errorf("migration %04d_%s is irreversible", migration.Version, migration.Name)
+	}
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s down failed: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// 以下函数兼容此前由 AutoMigrate 建好的库：AutoMigrate 可能已经按当时的模型建好了后续迁移要加的表、列和索引，
+// 这些迁移第一次执行时跳过已存在的部分，只补齐缺少的
+
+// createTables 创建表，已存在的表直接跳过
+func createTables(tx *gorm.DB, tables ...interface{}) error {
+	for _, table := range tables {
+		if tx.Migrator().HasTable(table) {
+			continue
+		}
+		if err := tx.Migrator().CreateTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumns 按 model 的字段定义添加列，已存在的列直接跳过
+func addColumns(tx *gorm.DB, model interface{}, fields ...string) error {
+	for _, field := range fields {
+		if tx.Migrator().HasColumn(model, field) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIndex 按 model 的定义创建索引，已存在时跳过
+func createIndex(tx *gorm.DB, model interface{}, name string) error {
+	if tx.Migrator().HasIndex(model, name) {
+		return nil
+	}
+	return tx.Migrator().CreateIndex(model, name)
+}
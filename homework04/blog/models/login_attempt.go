@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// 登录锁定事件类型
+const (
+	LoginEventLockout = "lockout"
+	LoginEventUnlock  = "unlock"
+)
+
+// LoginCounter 一个用户名或 IP 的连续登录失败计数
+// Subject 为 user:<用户名> 或 ip:<地址>
+type LoginCounter struct {
+	Subject       string     `json:"subject" gorm:"primaryKey;size:100"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at" gorm:"not null;index"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// LoginEvent 登录锁定和解锁记录
+type LoginEvent struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Type     string `json:"type" gorm:"not null;size:20"`
+	Subject  string `json:"subject" gorm:"not null;size:100;index"`
+	Username string `json:"username,omitempty" gorm:"size:50;index"`
+	IP       string `json:"ip,omitempty" gorm:"column:ip;size:45"`
+	// Failures 锁定时的连续失败次数
+	Failures    int        `json:"failures,omitempty" gorm:"not null;default:0"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	// ActorID 解锁的管理员，锁定事件为空
+	ActorID   *uint     `json:"actor_id,omitempty"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalTokenPrefix 个人访问令牌的前缀，认证中间件据此区分个人访问令牌和 JWT
+const PersonalTokenPrefix = "blog_pat_"
+
+// PersonalAccessToken 个人访问令牌，供脚本等非交互场景调用 API；数据库只保存令牌的 SHA-256 哈希
+// 令牌只能访问其 Scopes 允许的接口，同时仍受用户角色权限的约束
+type PersonalAccessToken struct {
+	ID     uint   `json:"id" gorm:"primaryKey"`
+	UserID uint   `json:"user_id" gorm:"not null;index"`
+	Name   string `json:"name" gorm:"not null;size:100"`
+	// Prefix 令牌开头的几个字符，用于在列表中辨认令牌
+	Prefix    string `json:"prefix" gorm:"not null;size:20"`
+	TokenHash string `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	// Scopes 以空格分隔的 scope 列表
+	Scopes     string     `json:"scopes" gorm:"not null;size:255"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ScopeList 令牌的 scope 列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// Expired 令牌在 now 时是否已过期，没有设置过期时间的令牌永不过期
+func (t *PersonalAccessToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
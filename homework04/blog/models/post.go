@@ -6,14 +6,62 @@ import (
 	"gorm.io/gorm"
 )
 
+// 文章状态，只有已发布的文章对作者以外的用户可见
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+	PostStatusArchived  = "archived"
+)
+
+// Post 文章。Slug 在所有文章（包括已删除的）中唯一；
+// ContentHTML 是按 ContentFormat 渲染并过滤后的正文，保存文章时生成；
+// PublishedAt 对已发布和已归档的文章是首次发布时间，对定时文章是计划发布时间，草稿为空
 type Post struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Title     string         `json:"title" gorm:"not null;size:200"`
-	Content   string         `json:"content" gorm:"type:text;not null"`
-	UserID    uint           `json:"user_id" gorm:"not null;index"`
-	User      User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
-	Comments  []Comment      `json:"comments,omitempty" gorm:"foreignKey:PostID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	Title         string         `json:"title" gorm:"not null;size:200"`
+	Slug          string         `json:"slug" gorm:"size:100;uniqueIndex"`
+	Content       string         `json:"content" gorm:"type:text;not null"`
+	ContentFormat string         `json:"content_format" gorm:"not null;size:20;default:markdown"`
+	ContentHTML   string         `json:"content_html" gorm:"type:text"`
+	UserID        uint           `json:"user_id" gorm:"not null;index"`
+	User          User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CategoryID    *uint          `json:"category_id,omitempty" gorm:"index"`
+	Category      *Category      `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	Tags          []Tag          `json:"tags,omitempty" gorm:"many2many:post_tags"`
+	Comments      []Comment      `json:"comments,omitempty" gorm:"foreignKey:PostID"`
+	Status        string         `json:"status" gorm:"not null;size:20;default:published;index:idx_posts_status_published_at,priority:1"`
+	PublishedAt   *time.Time     `json:"published_at,omitempty" gorm:"index:idx_posts_status_published_at,priority:2"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+// VisibleTo 文章是否对用户可见，userID 为 0 表示匿名用户
+func (p *Post) VisibleTo(userID uint) bool {
+	return p.Status == PostStatusPublished || (userID != 0 && p.UserID == userID)
+}
+
+// PostSlug 文章改名前使用过的 slug，旧链接据此重定向到文章当前的 slug
+type PostSlug struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"not null;index"`
+	Slug      string    `json:"slug" gorm:"not null;size:100;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostRevision 文章的一个版本，创建、更新和恢复文章时各保存一个；Number 在同一篇文章内从 1 递增
+// RestoredFrom 为恢复操作所恢复的版本号
+type PostRevision struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	PostID        uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_post_revisions_post_number,priority:1"`
+	Number        int       `json:"number" gorm:"not null;uniqueIndex:idx_post_revisions_post_number,priority:2"`
+	Title         string    `json:"title" gorm:"not null;size:200"`
+	Content       string    `json:"content" gorm:"type:text;not null"`
+	ContentFormat string    `json:"content_format" gorm:"not null;size:20"`
+	EditorID      uint      `json:"editor_id" gorm:"not null;index"`
+	Editor        User      `json:"editor,omitempty" gorm:"foreignKey:EditorID"`
+	Note          string    `json:"note" gorm:"size:200"`
+	RestoredFrom  *int      `json:"restored_from,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
 }
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Category 文章分类，ParentID 为空表示顶级分类；每篇文章最多属于一个分类
+type Category struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null;size:50"`
+	ParentID  *uint     `json:"parent_id,omitempty" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Tag 文章标签，名称统一为小写；没有文章使用的标签会被清理
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null;size:30"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// RefreshToken 刷新令牌，数据库只保存令牌的 SHA-256 哈希
+// 同一次登录轮换出的令牌属于同一个 FamilyID，发现旧令牌被重复使用时整个 family 一起吊销
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID  string     `json:"family_id" gorm:"not null;size:64;index"`
+	TokenHash string     `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null;index"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Revoked 令牌是否已被吊销（包括轮换后失效）
+func (t *RefreshToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
+
+// RevokedToken 已吊销的访问令牌（jti 黑名单），过期后即可清理
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey;size:64"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SigningKey JWT 非对称签名密钥，私钥以 jwt.secret 加密后保存
+// 最新的未退役密钥用于签名；轮换后旧密钥设置 RetiredAt，只用于验证，到 ExpiresAt 后清理
+type SigningKey struct {
+	KID        string     `json:"kid" gorm:"column:kid;primaryKey;size:64"`
+	Algorithm  string     `json:"algorithm" gorm:"not null;size:16"`
+	PrivateKey string     `json:"-" gorm:"type:text;not null"`
+	PublicKey  string     `json:"public_key" gorm:"type:text;not null"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" gorm:"index"`
+}
+
+// Session 一次登录产生的会话（设备），ID 与该次登录的刷新令牌 FamilyID 相同，访问令牌的 sid 声明指向它
+// 会话被吊销后其刷新令牌和访问令牌立即失效；每次刷新令牌都会延长 ExpiresAt
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:64"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	UserAgent  string     `json:"user_agent" gorm:"size:255"`
+	IP         string     `json:"ip" gorm:"column:ip;size:45"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null;index"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active 会话在 now 时是否仍然有效
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
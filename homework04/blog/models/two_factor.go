@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RecoveryCode 两步验证的恢复码，只保存 SHA-256 摘要，每个只能使用一次
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"not null;size:64"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RolePolicy 角色的安全策略，由管理员设置；没有记录的角色使用默认值
+type RolePolicy struct {
+	Role string `json:"role" gorm:"primaryKey;size:20"`
+	// RequireTwoFactor 该角色的用户必须启用两步验证，未启用时只能访问启用两步验证的接口
+	RequireTwoFactor bool      `json:"require_two_factor" gorm:"not null;default:false"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
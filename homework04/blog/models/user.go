@@ -8,20 +8,43 @@ import (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"uniqueIndex;not null;size:50"`
-	Password  string         `json:"-" gorm:"not null"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null;size:100"`
-	Posts     []Post         `json:"posts,omitempty" gorm:"foreignKey:UserID"`
-	Comments  []Comment      `json:"comments,omitempty" gorm:"foreignKey:UserID"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Username string `json:"username" gorm:"uniqueIndex;not null;size:50"`
+	Password string `json:"-" gorm:"not null"`
+	Email    string `json:"email" gorm:"uniqueIndex;not null;size:100"`
+	Role     string `json:"role" gorm:"not null;size:20;default:author"`
+	Disabled bool   `json:"disabled" gorm:"not null;default:false"`
+	// DisplayName、Bio、AvatarURL 用户自己填写的公开资料
+	DisplayName string `json:"display_name" gorm:"size:50"`
+	Bio         string `json:"bio" gorm:"size:500"`
+	AvatarURL   string `json:"avatar_url" gorm:"column:avatar_url;size:255"`
+	// EmailVerifiedAt 通过邮件链接验证邮箱的时间，nil 表示未验证
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	// TOTPSecret 加密后的 TOTP 密钥；TOTPEnabledAt 为 nil 时表示已生成密钥但尚未确认
+	TOTPSecret    string     `json:"-" gorm:"column:totp_secret;size:255"`
+	TOTPEnabledAt *time.Time `json:"-" gorm:"column:totp_enabled_at"`
+	// TOTPLastStep 最近一次通过验证的时间步，同一个验证码不能使用两次
+	TOTPLastStep int64          `json:"-" gorm:"column:totp_last_step;not null;default:0"`
+	Posts        []Post         `json:"posts,omitempty" gorm:"foreignKey:UserID"`
+	Comments     []Comment      `json:"comments,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 }
 
-// HashPassword 加密密码
+// TwoFactorEnabled 是否已启用两步验证
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// HashPassword 使用默认 cost 加密密码
 func (u *User) HashPassword(password string) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return u.HashPasswordWithCost(password, bcrypt.DefaultCost)
+}
+
+// HashPasswordWithCost 使用指定的 bcrypt cost 加密密码
+func (u *User) HashPasswordWithCost(password string, cost int) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	if err != nil {
 		return err
 	}
@@ -29,6 +52,12 @@ func (u *User) HashPassword(password string) error {
 	return nil
 }
 
+// NeedsRehash 密码哈希的 cost 与 cost 不同时返回 true，应在验证密码成功后重新哈希
+func (u *User) NeedsRehash(cost int) bool {
+	current, err := bcrypt.Cost([]byte(u.Password))
+	return err == nil && current != cost
+}
+
 // CheckPassword 验证密码
 func (u *User) CheckPassword(password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
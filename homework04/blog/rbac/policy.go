@@ -0,0 +1,37 @@
+package rbac
+
+import "blog/models"
+
+// Actor 发起操作的用户
+type Actor struct {
+	UserID uint
+	Role   Role
+}
+
+// Can 是否拥有权限
+func (a Actor) Can(p Permission) bool {
+	return a.Role.Has(p)
+}
+
+// canManage 操作自己的资源需要 own 权限，操作他人的资源需要 any 权限
+func (a Actor) canManage(ownerID uint, own, any Permission) bool {
+	if a.UserID != 0 && a.UserID == ownerID && a.Can(own) {
+		return true
+	}
+	return a.Can(any)
+}
+
+// CanUpdatePost 作者可以修改自己的文章，编辑和管理员可以修改任意文章
+func CanUpdatePost(a Actor, post *models.Post) bool {
+	return a.canManage(post.UserID, PostUpdateOwn, PostUpdateAny)
+}
+
+// CanDeletePost 作者可以删除自己的文章，编辑和管理员可以删除任意文章
+func CanDeletePost(a Actor, post *models.Post) bool {
+	return a.canManage(post.UserID, PostDeleteOwn, PostDeleteAny)
+}
+
+// CanDeleteComment 评论者可以删除自己的评论，编辑和管理员可以删除任意评论
+func CanDeleteComment(a Actor, comment *models.Comment) bool {
+	return a.canManage(comment.UserID, CommentDeleteOwn, CommentDeleteAny)
+}
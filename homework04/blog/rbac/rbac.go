@@ -0,0 +1,72 @@
+// Package rbac 基于角色的访问控制：角色、权限，以及文章、评论的访问策略。
+package rbac
+
+// Role 用户角色
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleAuthor Role = "author"
+	RoleReader Role = "reader"
+)
+
+// DefaultRole 新注册用户的角色
+const DefaultRole = RoleAuthor
+
+// Permission 权限，格式为 资源:操作[:范围]，范围 own 表示只能操作自己的资源，any 表示任意资源
+type Permission string
+
+const (
+	PostCreate       Permission = "post:create"
+	PostUpdateOwn    Permission = "post:update:own"
+	PostUpdateAny    Permission = "post:update:any"
+	PostDeleteOwn    Permission = "post:delete:own"
+	PostDeleteAny    Permission = "post:delete:any"
+	CommentCreate    Permission = "comment:create"
+	CommentDeleteOwn Permission = "comment:delete:own"
+	CommentDeleteAny Permission = "comment:delete:any"
+	CategoryManage   Permission = "category:manage"
+	UserManage       Permission = "user:manage"
+)
+
+// rolePermissions 各角色的权限，后一个角色包含前一个角色的全部权限
+var rolePermissions = map[Role][]Permission{}
+
+// Roles 按权限从低到高排列的所有角色
+var Roles = []Role{RoleReader, RoleAuthor, RoleEditor, RoleAdmin}
+
+func init() {
+	grants := map[Role][]Permission{
+		RoleReader: {CommentCreate, CommentDeleteOwn},
+		RoleAuthor: {PostCreate, PostUpdateOwn, PostDeleteOwn},
+		RoleEditor: {PostUpdateAny, PostDeleteAny, CommentDeleteAny, CategoryManage},
+		RoleAdmin:  {UserManage},
+	}
+	var inherited []Permission
+	for _, role := range Roles {
+		inherited = append(inherited, grants[role]...)
+		rolePermissions[role] = append([]Permission(nil), inherited...)
+	}
+}
+
+// Valid 是否为已定义的角色
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Has 角色是否拥有权限，未知角色没有任何权限
+func (r Role) Has(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions 角色拥有的全部权限
+func (r Role) Permissions() []Permission {
+	return append([]Permission(nil), rolePermissions[r]...)
+}
@@ -0,0 +1,62 @@
+package rbac
+
+import (
+	"blog/models"
+	"testing"
+)
+
+func TestRolePermissions(t *testing.T) {
+	cases := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleReader, CommentCreate, true},
+		{RoleReader, PostCreate, false},
+		{RoleAuthor, PostCreate, true},
+		{RoleAuthor, PostDeleteAny, false},
+		{RoleEditor, PostDeleteAny, true},
+		{RoleEditor, CommentDeleteAny, true},
+		{RoleAuthor, CategoryManage, false},
+		{RoleEditor, CategoryManage, true},
+		{RoleEditor, UserManage, false},
+		{RoleAdmin, UserManage, true},
+		{RoleAdmin, PostDeleteAny, true},
+		{Role("root"), CommentCreate, false},
+	}
+	for _, tc := range cases {
+		if got := tc.role.Has(tc.perm); got != tc.want {
+			t.Errorf("%s.Has(%s) = %v, want %v", tc.role, tc.perm, got, tc.want)
+		}
+	}
+}
+
+func TestPolicies(t *testing.T) {
+	post := &models.Post{UserID: 1}
+	comment := &models.Comment{UserID: 2}
+
+	cases := []struct {
+		name  string
+		actor Actor
+		post  bool
+		cmt   bool
+	}{
+		{"post author", Actor{UserID: 1, Role: RoleAuthor}, true, false},
+		{"commenter", Actor{UserID: 2, Role: RoleReader}, false, true},
+		{"other author", Actor{UserID: 3, Role: RoleAuthor}, false, false},
+		{"editor", Actor{UserID: 3, Role: RoleEditor}, true, true},
+		{"admin", Actor{UserID: 3, Role: RoleAdmin}, true, true},
+		{"anonymous", Actor{}, false, false},
+	}
+	for _, tc := range cases {
+		if got := CanUpdatePost(tc.actor, post); got != tc.post {
+			t.Errorf("%s: CanUpdatePost = %v, want %v", tc.name, got, tc.post)
+		}
+		if got := CanDeletePost(tc.actor, post); got != tc.post {
+			t.Errorf("%s: CanDeletePost = %v, want %v", tc.name, got, tc.post)
+		}
+		if got := CanDeleteComment(tc.actor, comment); got != tc.cmt {
+			t.Errorf("%s: CanDeleteComment = %v, want %v", tc.name, got, tc.cmt)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package rbac
+
+// Scope 个人访问令牌的权限范围；令牌只能访问声明了所需 scope 的接口，并且仍受用户角色权限的约束
+type Scope string
+
+const (
+	// ScopeRead 只读，访问需要认证的查询接口
+	ScopeRead Scope = "read"
+	// ScopePostsWrite 创建、修改、删除文章，包含只读
+	ScopePostsWrite Scope = "posts:write"
+	// ScopeCommentsWrite 发表、删除评论，包含只读
+	ScopeCommentsWrite Scope = "comments:write"
+)
+
+// Scopes 所有可以授予个人访问令牌的 scope
+var Scopes = []Scope{ScopeRead, ScopePostsWrite, ScopeCommentsWrite}
+
+// Valid 是否为已定义的 scope
+func (s Scope) Valid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows 拥有 granted 中的 scope 时是否可以访问要求 required 的接口；任意 scope 都包含只读
+func Allows(granted []Scope, required Scope) bool {
+	for _, scope := range granted {
+		if scope == required || required == ScopeRead && scope.Valid() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,858 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// translateError 将 GORM 的错误转换为仓储层错误
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUserRepository 基于 GORM 的用户存储
+func NewGormUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+func (r *gormUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *gormUserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Omit("Posts", "Comments").Save(user).Error
+}
+
+func (r *gormUserRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormUserRepository) DeleteAccount(ctx context.Context, user *models.User, opts AccountDeletion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 指定 Select 后 Save 只更新，不会在用户已被删除时重新插入
+		result := tx.Select("*").Omit("Posts", "Comments").Save(user)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		if err := tx.Delete(&models.User{}, user.ID).Error; err != nil {
+			return err
+		}
+		if opts.DeletePosts {
+			var ids []uint
+			if err := tx.Model(&models.Post{}).Where("user_id = ?", user.ID).Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := deletePost(tx, id); err != nil {
+					return err
+				}
+			}
+		}
+		if opts.DeleteComments {
+			if err := tx.Where("user_id = ?", user.ID).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Update("revoked_at", opts.RevokedAt).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Update("revoked_at", opts.RevokedAt).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.PersonalAccessToken{}).Error
+	})
+}
+
+type gormPostRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPostRepository 基于 GORM 的文章存储
+func NewGormPostRepository(db *gorm.DB) PostRepository {
+	return &gormPostRepository{db: db}
+}
+
+// Create 保存文章及其标签关联；标签按 ID 插入并忽略冲突，即使标签刚好被并发清理也会重新写入
+func (r *gormPostRepository) Create(ctx context.Context, post *models.Post, revision *models.PostRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Category").Create(post).Error; err != nil {
+			return err
+		}
+		revision.PostID, revision.Number = post.ID, 1
+		return tx.Omit("Editor").Create(revision).Error
+	})
+}
+
+func (r *gormPostRepository) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := r.preload(r.db.WithContext(ctx)).First(&post, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) FindBySlug(ctx context.Context, slug string) (*models.Post, error) {
+	var post models.Post
+	if err := r.preload(r.db.WithContext(ctx)).Where("slug = ?", slug).First(&post).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &post, nil
+}
+
+func (r *gormPostRepository) FindByOldSlug(ctx context.Context, slug string) (*models.Post, error) {
+	var old models.PostSlug
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&old).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return r.FindByID(ctx, old.PostID)
+}
+
+func (r *gormPostRepository) SlugTaken(ctx context.Context, slug string, exceptID uint) (bool, error) {
+	db := r.db.WithContext(ctx)
+	var count int64
+	if err := db.Unscoped().Model(&models.Post{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	err := db.Model(&models.PostSlug{}).Where("slug = ? AND post_id <> ?", slug, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+// preload 加载作者、分类和标签
+func (r *gormPostRepository) preload(query *gorm.DB) *gorm.DB {
+	return query.Preload("User").Preload("Category").Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("tags.name")
+	})
+}
+
+func (r *gormPostRepository) List(ctx context.Context, opts PostListOptions) ([]models.Post, int64, error) {
+	query := r.filter(r.db.WithContext(ctx).Model(&models.Post{}), opts.PostFilter)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	field := opts.SortField
+	if field == "" {
+		field = SortCreatedAt
+	}
+	desc := opts.scanDesc()
+
+	if opts.Cursor != nil {
+		value, err := cursorValue(*opts.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		query = query.Where(
+			fmt.Sprintf("(posts.%[1]s %[2]s ? OR (posts.%[1]s = ? AND posts.id %[2]s ?))", field, op),
+			value, value, opts.Cursor.ID,
+		)
+	} else if opts.Offset > 0 {
+		query = query.Offset(opts.Offset)
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	query = query.Order(fmt.Sprintf("posts.%s %s, posts.id %s", field, direction, direction))
+	if opts.Limit > 0 {
+		query = query.Limit(opts.Limit)
+	}
+
+	var posts []models.Post
+	if err := r.preload(query).Find(&posts).Error; err != nil {
+		return nil, 0, err
+	}
+	if desc != opts.Desc {
+		reversePosts(posts)
+	}
+	return posts, total, nil
+}
+
+// filter 应用过滤条件
+func (r *gormPostRepository) filter(query *gorm.DB, f PostFilter) *gorm.DB {
+	if f.AuthorID != 0 {
+		query = query.Where("posts.user_id = ?", f.AuthorID)
+	}
+	if f.AuthorUsername != "" {
+		query = query.Where("posts.user_id IN (?)", r.db.Model(&models.User{}).Select("id").Where("username = ?", f.AuthorUsername))
+	}
+	if !f.CreatedFrom.IsZero() {
+		query = query.Where("posts.created_at >= ?", f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		query = query.Where("posts.created_at < ?", f.CreatedTo)
+	}
+	if f.Keyword != "" {
+		pattern := "%" + escapeLike(strings.ToLower(f.Keyword)) + "%"
+		query = query.Where("(LOWER(posts.title) LIKE ? ESCAPE '!' OR LOWER(posts.content) LIKE ? ESCAPE '!')", pattern, pattern)
+	}
+	if f.Tag != "" {
+		query = query.Where("posts.id IN (?)", r.db.Table("post_tags").Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").Where("tags.name = ?", f.Tag))
+	}
+	if len(f.CategoryIDs) > 0 {
+		query = query.Where("posts.category_id IN ?", f.CategoryIDs)
+	}
+	if f.Status != "" {
+		query = query.Where("posts.status = ?", f.Status)
+	}
+	if f.OnlyVisible {
+		query = query.Where("(posts.status = ? OR posts.user_id = ?)", models.PostStatusPublished, f.ViewerID)
+	}
+	return query
+}
+
+// Update 版本号由 (post_id, number) 唯一索引保证不重复，并发更新同一篇文章时后提交的一方失败
+func (r *gormPostRepository) Update(ctx context.Context, post *models.Post, revision *models.PostRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		previous, err := postTagIDs(tx, post.ID)
+		if err != nil {
+			return err
+		}
+		var slugs []string
+		if err := tx.Model(&models.Post{}).Where("id = ?", post.ID).Pluck("slug", &slugs).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("User", "Category", "Tags", "Comments").Save(post).Error; err != nil {
+			return err
+		}
+		if len(slugs) == 1 && slugs[0] != post.Slug {
+			slug := slugs[0]
+			if err := tx.Where("post_id = ? AND slug = ?", post.ID, post.Slug).Delete(&models.PostSlug{}).Error; err != nil {
+				return err
+			}
+			if slug != "" {
+				if err := tx.Create(&models.PostSlug{PostID: post.ID, Slug: slug}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		var last int
+		if err := tx.Model(&models.PostRevision{}).Where("post_id = ?", post.ID).Select("COALESCE(MAX(number), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+		revision.PostID, revision.Number = post.ID, last+1
+		if err := tx.Omit("Editor").Create(revision).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(post).Association("Tags").Replace(post.Tags); err != nil {
+			return err
+		}
+		return deleteUnusedTags(tx, previous)
+	})
+}
+
+func (r *gormPostRepository) ListRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	if err := r.db.WithContext(ctx).Preload("Editor").Omit("content").Where("post_id = ?", postID).Order("number DESC").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *gormPostRepository) FindRevision(ctx context.Context, postID uint, number int) (*models.PostRevision, error) {
+	var revision models.PostRevision
+	if err := r.db.WithContext(ctx).Preload("Editor").Where("post_id = ? AND number = ?", postID, number).First(&revision).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &revision, nil
+}
+
+// Delete 软删除文章并删除其标签关联，文章恢复后不再带有标签
+func (r *gormPostRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deletePost(tx, id)
+	})
+}
+
+// deletePost 在事务 tx 中软删除文章、删除其标签关联和不再使用的标签
+func deletePost(tx *gorm.DB, id uint) error {
+	previous, err := postTagIDs(tx, id)
+	if err != nil {
+		return err
+	}
+	if err := tx.Delete(&models.Post{}, id).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM post_tags WHERE post_id = ?", id).Error; err != nil {
+		return err
+	}
+	return deleteUnusedTags(tx, previous)
+}
+
+// PublishDue 先查出到期的定时文章，再逐篇按状态条件更新，只返回本实例更新成功的文章
+// published_at 保存为 UTC（见 services.applyStatus），now 也转换为 UTC 再比较
+func (r *gormPostRepository) PublishDue(ctx context.Context, now time.Time) ([]models.Post, error) {
+	db := r.db.WithContext(ctx)
+	var ids []uint
+	err := db.Model(&models.Post{}).
+		Where("status = ? AND published_at <= ?", models.PostStatusScheduled, now.UTC()).
+		Order("published_at").Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var published []uint
+	for _, id := range ids {
+		// 不修改 updated_at，发布不算编辑
+		result := db.Model(&models.Post{}).Where("id = ? AND status = ?", id, models.PostStatusScheduled).
+			UpdateColumn("status", models.PostStatusPublished)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			published = append(published, id)
+		}
+	}
+	if len(published) == 0 {
+		return nil, nil
+	}
+
+	var posts []models.Post
+	if err := r.preload(db).Where("id IN ?", published).Order("published_at, id").Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// postTagIDs 文章当前关联的标签
+func postTagIDs(tx *gorm.DB, postID uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Table("post_tags").Where("post_id = ?", postID).Pluck("tag_id", &ids).Error
+	return ids, err
+}
+
+// deleteUnusedTags 删除 ids 中已没有文章关联的标签
+func deleteUnusedTags(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Where("id IN ? AND NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.tag_id = tags.id)", ids).Delete(&models.Tag{}).Error
+}
+
+type gormTaxonomyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormTaxonomyRepository 基于 GORM 的分类和标签存储
+func NewGormTaxonomyRepository(db *gorm.DB) TaxonomyRepository {
+	return &gormTaxonomyRepository{db: db}
+}
+
+func (r *gormTaxonomyRepository) CreateCategory(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *gormTaxonomyRepository) FindCategory(ctx context.Context, id uint) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &category, nil
+}
+
+func (r *gormTaxonomyRepository) FindCategoryByName(ctx context.Context, name string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&category).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &category, nil
+}
+
+func (r *gormTaxonomyRepository) ListCategories(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.WithContext(ctx).Order("name").Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormTaxonomyRepository) CountPostsByCategory(ctx context.Context) (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Posts      int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.Post{}).Select("category_id, COUNT(*) AS posts").
+		Where("category_id IS NOT NULL AND status = ?", models.PostStatusPublished).Group("category_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Posts
+	}
+	return counts, nil
+}
+
+func (r *gormTaxonomyRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Save(category).Error
+}
+
+func (r *gormTaxonomyRepository) DeleteCategory(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 包括已软删除的文章，避免恢复后指向不存在的分类
+		if err := tx.Unscoped().Model(&models.Post{}).Where("category_id = ?", id).Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Category{}, id).Error
+	})
+}
+
+func (r *gormTaxonomyRepository) EnsureTags(ctx context.Context, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	db := r.db.WithContext(ctx)
+	tags := make([]models.Tag, len(names))
+	for i, name := range names {
+		tags[i] = models.Tag{Name: name}
+	}
+	// 同名标签已存在时忽略，随后统一按名称查询 ID
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+	var found []models.Tag
+	if err := db.Where("name IN ?", names).Order("name").Find(&found).Error; err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func (r *gormTaxonomyRepository) ListTags(ctx context.Context) ([]TagCount, error) {
+	var tags []TagCount
+	err := r.db.WithContext(ctx).Table("tags").
+		Select("tags.id, tags.name, tags.created_at, COUNT(*) AS posts").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL AND posts.status = ?", models.PostStatusPublished).
+		Group("tags.id, tags.name, tags.created_at").
+		Order("posts DESC, tags.name").Scan(&tags).Error
+	return tags, err
+}
+
+type gormCommentRepository struct {
+	db *gorm.DB
+}
+
+// NewGormCommentRepository 基于 GORM 的评论存储
+func NewGormCommentRepository(db *gorm.DB) CommentRepository {
+	return &gormCommentRepository{db: db}
+}
+
+func (r *gormCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+func (r *gormCommentRepository) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).Preload("User").First(&comment, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &comment, nil
+}
+
+func (r *gormCommentRepository) ListByPost(ctx context.Context, postID uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	if err := r.db.WithContext(ctx).Preload("User").Where("post_id = ?", postID).Order("created_at DESC, id DESC").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *gormCommentRepository) ListByUser(ctx context.Context, userID uint) ([]models.Comment, error) {
+	var comments []models.Comment
+	if err := r.db.WithContext(ctx).Preload("User").Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *gormCommentRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Comment{}, id).Error
+}
+
+type gormTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormTokenRepository 基于 GORM 的令牌存储
+func NewGormTokenRepository(db *gorm.DB) TokenRepository {
+	return &gormTokenRepository{db: db}
+}
+
+func (r *gormTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *gormTokenRepository) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &token, nil
+}
+
+func (r *gormTokenRepository) RevokeRefreshToken(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *gormTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+func (r *gormTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID uint, exceptFamilyID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, exceptFamilyID).
+		Update("revoked_at", at).Error
+}
+
+func (r *gormTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (r *gormTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *gormTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		result = tx.Where("expires_at < ?", before).Delete(&models.RevokedToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		result = tx.Where("expires_at < ?", before).Delete(&models.Session{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
+
+func (r *gormTokenRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *gormTokenRepository) FindSession(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &session, nil
+}
+
+func (r *gormTokenRepository) ListSessions(ctx context.Context, userID uint, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC, created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *gormTokenRepository) UpdateSession(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Model(&models.Session{ID: session.ID}).
+		Select("last_seen_at", "ip", "user_agent", "expires_at").
+		Updates(session).Error
+}
+
+func (r *gormTokenRepository) RevokeSession(ctx context.Context, id string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormTokenRepository) RevokeUserSessions(ctx context.Context, userID uint, exceptID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+		Update("revoked_at", at).Error
+}
+
+type gormKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormKeyRepository 基于 GORM 的签名密钥存储
+func NewGormKeyRepository(db *gorm.DB) KeyRepository {
+	return &gormKeyRepository{db: db}
+}
+
+func (r *gormKeyRepository) CreateSigningKey(ctx context.Context, key *models.SigningKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *gormKeyRepository) ListSigningKeys(ctx context.Context, now time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.WithContext(ctx).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("created_at DESC, kid DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *gormKeyRepository) RetireSigningKeys(ctx context.Context, keepKID string, at, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.SigningKey{}).
+		Where("kid <> ? AND retired_at IS NULL", keepKID).
+		Updates(map[string]interface{}{"retired_at": at, "expires_at": expiresAt}).Error
+}
+
+func (r *gormKeyRepository) DeleteExpiredSigningKeys(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.SigningKey{})
+	return result.RowsAffected, result.Error
+}
+
+type gormTwoFactorRepository struct {
+	db *gorm.DB
+}
+
+// NewGormTwoFactorRepository 基于 GORM 的两步验证存储
+func NewGormTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &gormTwoFactorRepository{db: db}
+}
+
+func (r *gormTwoFactorRepository) AdvanceTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		UpdateColumn("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		codes := make([]models.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *gormTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormTwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+type gormPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPolicyRepository 基于 GORM 的角色策略存储
+func NewGormPolicyRepository(db *gorm.DB) PolicyRepository {
+	return &gormPolicyRepository{db: db}
+}
+
+func (r *gormPolicyRepository) ListRolePolicies(ctx context.Context) ([]models.RolePolicy, error) {
+	var policies []models.RolePolicy
+	err := r.db.WithContext(ctx).Order("role").Find(&policies).Error
+	return policies, err
+}
+
+func (r *gormPolicyRepository) SaveRolePolicy(ctx context.Context, policy *models.RolePolicy) error {
+	return r.db.WithContext(ctx).Save(policy).Error
+}
+
+type gormLoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewGormLoginAttemptRepository 基于 GORM 的登录失败计数，多个实例共享
+func NewGormLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &gormLoginAttemptRepository{db: db}
+}
+
+func (r *gormLoginAttemptRepository) SwapLoginCounter(ctx context.Context, subject string, old, new *models.LoginCounter) (bool, error) {
+	db := r.db.WithContext(ctx)
+	var result *gorm.DB
+	switch {
+	case old == nil:
+		// 主键冲突说明其他请求已经插入了记录
+		counter := *new
+		counter.Subject = subject
+		result = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter)
+	case new == nil:
+		result = db.Where("subject = ? AND failures = ?", subject, old.Failures).Delete(&models.LoginCounter{})
+	default:
+		result = db.Model(&models.LoginCounter{}).
+			Where("subject = ? AND failures = ?", subject, old.Failures).
+			Updates(map[string]interface{}{
+				"failures":        new.Failures,
+				"last_failure_at": new.LastFailureAt,
+				"locked_until":    new.LockedUntil,
+			})
+	}
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *gormLoginAttemptRepository) FindLoginCounter(ctx context.Context, subject string) (*models.LoginCounter, error) {
+	var counter models.LoginCounter
+	if err := r.db.WithContext(ctx).Where("subject = ?", subject).Take(&counter).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &counter, nil
+}
+
+func (r *gormLoginAttemptRepository) LockLogin(ctx context.Context, subject string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.LoginCounter{}).
+		Where("subject = ?", subject).
+		Update("locked_until", until).Error
+}
+
+func (r *gormLoginAttemptRepository) ResetLoginCounter(ctx context.Context, subject string) error {
+	return r.db.WithContext(ctx).Where("subject = ?", subject).Delete(&models.LoginCounter{}).Error
+}
+
+func (r *gormLoginAttemptRepository) CreateLoginEvent(ctx context.Context, event *models.LoginEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *gormLoginAttemptRepository) ListLoginEvents(ctx context.Context, filter LoginEventFilter) ([]models.LoginEvent, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Limit(filter.Limit)
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	var events []models.LoginEvent
+	err := query.Find(&events).Error
+	return events, err
+}
+
+func (r *gormLoginAttemptRepository) DeleteStaleLoginCounters(ctx context.Context, before, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, now).
+		Delete(&models.LoginCounter{})
+	return result.RowsAffected, result.Error
+}
+
+type gormPersonalTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPersonalTokenRepository 基于 GORM 的个人访问令牌存储
+func NewGormPersonalTokenRepository(db *gorm.DB) PersonalTokenRepository {
+	return &gormPersonalTokenRepository{db: db}
+}
+
+func (r *gormPersonalTokenRepository) CreatePersonalToken(ctx context.Context, token *models.PersonalAccessToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *gormPersonalTokenRepository) FindPersonalTokenByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &token, nil
+}
+
+func (r *gormPersonalTokenRepository) ListPersonalTokens(ctx context.Context, userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *gormPersonalTokenRepository) DeletePersonalToken(ctx context.Context, userID, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.PersonalAccessToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *gormPersonalTokenRepository) DeleteUserPersonalTokens(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.PersonalAccessToken{}).Error
+}
+
+func (r *gormPersonalTokenRepository) TouchPersonalToken(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.PersonalAccessToken{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,1226 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore 内存存储，供测试和无数据库的场景使用
+// 三个仓储共享同一份数据，查询时像 GORM Preload 一样填充关联的 User
+type MemoryStore struct {
+	mu       sync.RWMutex
+	users    map[uint]models.User
+	posts    map[uint]models.Post
+	comments map[uint]models.Comment
+	// categories、tags 为分类和标签，postTags 为文章关联的标签 ID，oldSlugs 为文章用过的旧 slug 对应的文章 ID，
+	// revisions 为各文章按版本号排列的版本
+	categories map[uint]models.Category
+	tags       map[uint]models.Tag
+	postTags   map[uint][]uint
+	oldSlugs   map[string]uint
+	revisions  map[uint][]models.PostRevision
+	refresh    map[uint]models.RefreshToken
+	revoked    map[string]models.RevokedToken
+	sessions   map[string]models.Session
+	keys       map[string]models.SigningKey
+	recovery   map[uint]models.RecoveryCode
+	policies   map[string]models.RolePolicy
+	counters   map[string]models.LoginCounter
+	events     []models.LoginEvent
+	personal   map[uint]models.PersonalAccessToken
+	nextID     map[string]uint
+}
+
+// NewMemoryStore 创建空的内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:      map[uint]models.User{},
+		posts:      map[uint]models.Post{},
+		comments:   map[uint]models.Comment{},
+		categories: map[uint]models.Category{},
+		tags:       map[uint]models.Tag{},
+		postTags:   map[uint][]uint{},
+		oldSlugs:   map[string]uint{},
+		revisions:  map[uint][]models.PostRevision{},
+		refresh:    map[uint]models.RefreshToken{},
+		revoked:    map[string]models.RevokedToken{},
+		sessions:   map[string]models.Session{},
+		keys:       map[string]models.SigningKey{},
+		recovery:   map[uint]models.RecoveryCode{},
+		policies:   map[string]models.RolePolicy{},
+		counters:   map[string]models.LoginCounter{},
+		personal:   map[uint]models.PersonalAccessToken{},
+		nextID:     map[string]uint{},
+	}
+}
+
+// Users 返回基于该存储的用户仓储
+func (s *MemoryStore) Users() UserRepository { return &memoryUserRepository{s} }
+
+// Posts 返回基于该存储的文章仓储
+func (s *MemoryStore) Posts() PostRepository { return &memoryPostRepository{s} }
+
+// Comments 返回基于该存储的评论仓储
+func (s *MemoryStore) Comments() CommentRepository { return &memoryCommentRepository{s} }
+
+// Taxonomy 返回基于该存储的分类和标签仓储
+func (s *MemoryStore) Taxonomy() TaxonomyRepository { return &memoryTaxonomyRepository{s} }
+
+// Tokens 返回基于该存储的令牌仓储
+func (s *MemoryStore) Tokens() TokenRepository { return &memoryTokenRepository{s} }
+
+// Keys 返回基于该存储的签名密钥仓储
+func (s *MemoryStore) Keys() KeyRepository { return &memoryKeyRepository{s} }
+
+// TwoFactor 返回基于该存储的两步验证仓储
+func (s *MemoryStore) TwoFactor() TwoFactorRepository { return &memoryTwoFactorRepository{s} }
+
+// Policies 返回基于该存储的角色策略仓储
+func (s *MemoryStore) Policies() PolicyRepository { return &memoryPolicyRepository{s} }
+
+// LoginAttempts 返回基于该存储的登录失败计数，单实例部署时也可以代替数据库使用
+func (s *MemoryStore) LoginAttempts() LoginAttemptRepository { return &memoryLoginAttemptRepository{s} }
+
+// PersonalTokens 返回基于该存储的个人访问令牌仓储
+func (s *MemoryStore) PersonalTokens() PersonalTokenRepository {
+	return &memoryPersonalTokenRepository{s}
+}
+
+// allocID 分配自增 ID，调用方需持有写锁
+func (s *MemoryStore) allocID(table string) uint {
+	s.nextID[table]++
+	return s.nextID[table]
+}
+
+// author 返回未删除的用户，调用方需持有读锁
+func (s *MemoryStore) author(id uint) models.User {
+	user, ok := s.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return models.User{}
+	}
+	return stripUser(user)
+}
+
+// withAssociations 填充文章的作者、分类和标签，调用方需持有读锁
+func (s *MemoryStore) withAssociations(post models.Post) models.Post {
+	post.User = s.author(post.UserID)
+	post.Category = nil
+	if post.CategoryID != nil {
+		if category, ok := s.categories[*post.CategoryID]; ok {
+			post.Category = &category
+		}
+	}
+	post.Tags = nil
+	for _, id := range s.postTags[post.ID] {
+		if tag, ok := s.tags[id]; ok {
+			post.Tags = append(post.Tags, tag)
+		}
+	}
+	sort.Slice(post.Tags, func(i, j int) bool { return post.Tags[i].Name < post.Tags[j].Name })
+	return post
+}
+
+// addRevision 追加文章的版本并填写 ID、PostID 和 Number，调用方需持有写锁
+func (s *MemoryStore) addRevision(postID uint, revision *models.PostRevision) {
+	revision.ID = s.allocID("post_revisions")
+	revision.PostID, revision.Number = postID, len(s.revisions[postID])+1
+	revision.CreatedAt = time.Now()
+	stored := *revision
+	stored.Editor = models.User{}
+	s.revisions[postID] = append(s.revisions[postID], stored)
+}
+
+// setPostTags 保存文章的标签关联，并像 GORM 一样重新写入已被清理的标签；
+// 返回原先关联的标签 ID，调用方需持有写锁
+func (s *MemoryStore) setPostTags(postID uint, tags []models.Tag) []uint {
+	previous := s.postTags[postID]
+	ids := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		if _, ok := s.tags[tag.ID]; !ok {
+			s.tags[tag.ID] = tag
+		}
+		ids = append(ids, tag.ID)
+	}
+	if len(ids) == 0 {
+		delete(s.postTags, postID)
+	} else {
+		s.postTags[postID] = ids
+	}
+	return previous
+}
+
+// deleteUnusedTags 删除 ids 中已没有文章关联的标签，调用方需持有写锁
+func (s *MemoryStore) deleteUnusedTags(ids []uint) {
+	used := map[uint]bool{}
+	for _, tagIDs := range s.postTags {
+		for _, id := range tagIDs {
+			used[id] = true
+		}
+	}
+	for _, id := range ids {
+		if !used[id] {
+			delete(s.tags, id)
+		}
+	}
+}
+
+// stripUser 去掉用户上的关联，避免返回值共享内部数据
+func stripUser(user models.User) models.User {
+	user.Posts = nil
+	user.Comments = nil
+	return user
+}
+
+type memoryUserRepository struct{ s *MemoryStore }
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now()
+	user.ID = r.s.allocID("users")
+	user.CreatedAt, user.UpdatedAt = now, now
+	r.s.users[user.ID] = stripUser(*user)
+	return nil
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.ID == id })
+}
+
+func (r *memoryUserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Username == username })
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Email == email })
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.users[user.ID]
+	if !ok || existing.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	user.UpdatedAt = time.Now()
+	r.s.users[user.ID] = stripUser(*user)
+	return nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	user, ok := r.s.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	user.DeletedAt.Time, user.DeletedAt.Valid = time.Now(), true
+	r.s.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) DeleteAccount(ctx context.Context, user *models.User, opts AccountDeletion) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.users[user.ID]
+	if !ok || existing.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	now := time.Now()
+	deleted := stripUser(*user)
+	deleted.UpdatedAt = now
+	deleted.DeletedAt.Time, deleted.DeletedAt.Valid = now, true
+	r.s.users[user.ID] = deleted
+
+	if opts.DeletePosts {
+		for id, post := range r.s.posts {
+			if post.UserID == user.ID && !post.DeletedAt.Valid {
+				post.DeletedAt.Time, post.DeletedAt.Valid = now, true
+				r.s.posts[id] = post
+				r.s.deleteUnusedTags(r.s.setPostTags(id, nil))
+			}
+		}
+	}
+	if opts.DeleteComments {
+		for id, comment := range r.s.comments {
+			if comment.UserID == user.ID && !comment.DeletedAt.Valid {
+				comment.DeletedAt.Time, comment.DeletedAt.Valid = now, true
+				r.s.comments[id] = comment
+			}
+		}
+	}
+	at := opts.RevokedAt
+	for id, session := range r.s.sessions {
+		if session.UserID == user.ID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			r.s.sessions[id] = session
+		}
+	}
+	for id, token := range r.s.refresh {
+		if token.UserID == user.ID && !token.Revoked() {
+			token.RevokedAt = &at
+			r.s.refresh[id] = token
+		}
+	}
+	for id, token := range r.s.personal {
+		if token.UserID == user.ID {
+			delete(r.s.personal, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) find(match func(models.User) bool) (*models.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, user := range r.s.users {
+		if !user.DeletedAt.Valid && match(user) {
+			found := stripUser(user)
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+type memoryPostRepository struct{ s *MemoryStore }
+
+func (r *memoryPostRepository) Create(ctx context.Context, post *models.Post, revision *models.PostRevision) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now()
+	post.ID = r.s.allocID("posts")
+	post.CreatedAt, post.UpdatedAt = now, now
+	if post.Status == "" {
+		post.Status = models.PostStatusPublished
+	}
+	r.s.posts[post.ID] = r.stored(*post)
+	r.s.setPostTags(post.ID, post.Tags)
+	r.s.addRevision(post.ID, revision)
+	return nil
+}
+
+func (r *memoryPostRepository) FindByID(ctx context.Context, id uint) (*models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	post, ok := r.s.posts[id]
+	if !ok || post.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	post = r.s.withAssociations(post)
+	return &post, nil
+}
+
+func (r *memoryPostRepository) FindBySlug(ctx context.Context, slug string) (*models.Post, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, post := range r.s.posts {
+		if post.Slug == slug && !post.DeletedAt.Valid {
+			post = r.s.withAssociations(post)
+			return &post, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryPostRepository) FindByOldSlug(ctx context.Context, slug string) (*models.Post, error) {
+	r.s.mu.RLock()
+	id, ok := r.s.oldSlugs[slug]
+	r.s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r.FindByID(ctx, id)
+}
+
+func (r *memoryPostRepository) SlugTaken(ctx context.Context, slug string, exceptID uint) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	if id, ok := r.s.oldSlugs[slug]; ok && id != exceptID {
+		return true, nil
+	}
+	for _, post := range r.s.posts {
+		if post.Slug == slug && post.ID != exceptID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryPostRepository) List(ctx context.Context, opts PostListOptions) ([]models.Post, int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	field := opts.SortField
+	if field == "" {
+		field = SortCreatedAt
+	}
+	desc := opts.scanDesc()
+
+	posts := make([]models.Post, 0, len(r.s.posts))
+	for _, post := range r.s.posts {
+		if post.DeletedAt.Valid || !r.matches(post, opts.PostFilter) {
+			continue
+		}
+		posts = append(posts, r.s.withAssociations(post))
+	}
+	total := int64(len(posts))
+
+	// less 按 (排序字段, ID) 升序比较
+	less := func(a, b models.Post) bool {
+		if c := compareSortValue(a, b, field); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if desc {
+			return less(posts[j], posts[i])
+		}
+		return less(posts[i], posts[j])
+	})
+
+	start := 0
+	if opts.Cursor != nil {
+		anchor := models.Post{ID: opts.Cursor.ID}
+		value, err := cursorValue(*opts.Cursor)
+		if err != nil {
+			return nil, 0, err
+		}
+		switch v := value.(type) {
+		case string:
+			anchor.Title = v
+		case time.Time:
+			anchor.CreatedAt, anchor.UpdatedAt = v, v
+		}
+		start = sort.Search(len(posts), func(i int) bool {
+			if desc {
+				return less(posts[i], anchor)
+			}
+			return less(anchor, posts[i])
+		})
+	} else if opts.Offset > 0 {
+		start = opts.Offset
+	}
+	if start > len(posts) {
+		start = len(posts)
+	}
+	posts = posts[start:]
+	if opts.Limit > 0 && len(posts) > opts.Limit {
+		posts = posts[:opts.Limit]
+	}
+	if desc != opts.Desc {
+		reversePosts(posts)
+	}
+	return posts, total, nil
+}
+
+// matches 判断文章是否满足过滤条件，调用方需持有读锁
+func (r *memoryPostRepository) matches(post models.Post, f PostFilter) bool {
+	if f.AuthorID != 0 && post.UserID != f.AuthorID {
+		return false
+	}
+	if f.AuthorUsername != "" && r.s.author(post.UserID).Username != f.AuthorUsername {
+		return false
+	}
+	if !f.CreatedFrom.IsZero() && post.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && !post.CreatedAt.Before(f.CreatedTo) {
+		return false
+	}
+	if f.Keyword != "" {
+		keyword := strings.ToLower(f.Keyword)
+		if !strings.Contains(strings.ToLower(post.Title), keyword) && !strings.Contains(strings.ToLower(post.Content), keyword) {
+			return false
+		}
+	}
+	if f.Tag != "" && !r.hasTag(post.ID, f.Tag) {
+		return false
+	}
+	if len(f.CategoryIDs) > 0 && (post.CategoryID == nil || !containsID(f.CategoryIDs, *post.CategoryID)) {
+		return false
+	}
+	if f.Status != "" && post.Status != f.Status {
+		return false
+	}
+	if f.OnlyVisible && !post.VisibleTo(f.ViewerID) {
+		return false
+	}
+	return true
+}
+
+// hasTag 文章是否带有名为 name 的标签，调用方需持有读锁
+func (r *memoryPostRepository) hasTag(postID uint, name string) bool {
+	for _, id := range r.s.postTags[postID] {
+		if r.s.tags[id].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// compareSortValue 比较两篇文章在排序字段上的值
+func compareSortValue(a, b models.Post, field string) int {
+	switch field {
+	case SortTitle:
+		return strings.Compare(a.Title, b.Title)
+	case SortUpdatedAt:
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	}
+}
+
+func (r *memoryPostRepository) Update(ctx context.Context, post *models.Post, revision *models.PostRevision) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.posts[post.ID]
+	if !ok || existing.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	post.UpdatedAt = time.Now()
+	r.s.posts[post.ID] = r.stored(*post)
+	r.s.deleteUnusedTags(r.s.setPostTags(post.ID, post.Tags))
+	if existing.Slug != post.Slug {
+		delete(r.s.oldSlugs, post.Slug)
+		if existing.Slug != "" {
+			r.s.oldSlugs[existing.Slug] = post.ID
+		}
+	}
+	r.s.addRevision(post.ID, revision)
+	return nil
+}
+
+func (r *memoryPostRepository) ListRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	revisions := r.s.revisions[postID]
+	list := make([]models.PostRevision, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		revision.Content = ""
+		revision.Editor = r.s.author(revision.EditorID)
+		list = append(list, revision)
+	}
+	return list, nil
+}
+
+func (r *memoryPostRepository) FindRevision(ctx context.Context, postID uint, number int) (*models.PostRevision, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	revisions := r.s.revisions[postID]
+	if number < 1 || number > len(revisions) {
+		return nil, ErrNotFound
+	}
+	revision := revisions[number-1]
+	revision.Editor = r.s.author(revision.EditorID)
+	return &revision, nil
+}
+
+func (r *memoryPostRepository) Delete(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	post, ok := r.s.posts[id]
+	if !ok || post.DeletedAt.Valid {
+		return nil
+	}
+	post.DeletedAt.Time, post.DeletedAt.Valid = time.Now(), true
+	r.s.posts[id] = post
+	r.s.deleteUnusedTags(r.s.setPostTags(id, nil))
+	return nil
+}
+
+func (r *memoryPostRepository) PublishDue(ctx context.Context, now time.Time) ([]models.Post, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var posts []models.Post
+	for id, post := range r.s.posts {
+		if post.DeletedAt.Valid || post.Status != models.PostStatusScheduled || post.PublishedAt == nil || post.PublishedAt.After(now) {
+			continue
+		}
+		post.Status = models.PostStatusPublished
+		r.s.posts[id] = post
+		posts = append(posts, r.s.withAssociations(post))
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].PublishedAt.Equal(*posts[j].PublishedAt) {
+			return posts[i].PublishedAt.Before(*posts[j].PublishedAt)
+		}
+		return posts[i].ID < posts[j].ID
+	})
+	return posts, nil
+}
+
+// stored 去掉关联后再保存
+func (r *memoryPostRepository) stored(post models.Post) models.Post {
+	post.User = models.User{}
+	post.Category = nil
+	post.Tags = nil
+	post.Comments = nil
+	return post
+}
+
+type memoryTaxonomyRepository struct{ s *MemoryStore }
+
+func (r *memoryTaxonomyRepository) CreateCategory(ctx context.Context, category *models.Category) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := time.Now()
+	category.ID = r.s.allocID("categories")
+	category.CreatedAt, category.UpdatedAt = now, now
+	r.s.categories[category.ID] = *category
+	return nil
+}
+
+func (r *memoryTaxonomyRepository) FindCategory(ctx context.Context, id uint) (*models.Category, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	category, ok := r.s.categories[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &category, nil
+}
+
+func (r *memoryTaxonomyRepository) FindCategoryByName(ctx context.Context, name string) (*models.Category, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, category := range r.s.categories {
+		if category.Name == name {
+			return &category, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryTaxonomyRepository) ListCategories(ctx context.Context) ([]models.Category, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	categories := make([]models.Category, 0, len(r.s.categories))
+	for _, category := range r.s.categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+func (r *memoryTaxonomyRepository) CountPostsByCategory(ctx context.Context) (map[uint]int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	counts := map[uint]int64{}
+	for _, post := range r.s.posts {
+		if !post.DeletedAt.Valid && post.Status == models.PostStatusPublished && post.CategoryID != nil {
+			counts[*post.CategoryID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *memoryTaxonomyRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.categories[category.ID]; !ok {
+		return ErrNotFound
+	}
+	category.UpdatedAt = time.Now()
+	r.s.categories[category.ID] = *category
+	return nil
+}
+
+func (r *memoryTaxonomyRepository) DeleteCategory(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for postID, post := range r.s.posts {
+		if post.CategoryID != nil && *post.CategoryID == id {
+			post.CategoryID = nil
+			r.s.posts[postID] = post
+		}
+	}
+	delete(r.s.categories, id)
+	return nil
+}
+
+func (r *memoryTaxonomyRepository) EnsureTags(ctx context.Context, names []string) ([]models.Tag, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing := map[string]models.Tag{}
+	for _, tag := range r.s.tags {
+		existing[tag.Name] = tag
+	}
+	var tags []models.Tag
+	for _, name := range names {
+		tag, ok := existing[name]
+		if !ok {
+			tag = models.Tag{ID: r.s.allocID("tags"), Name: name, CreatedAt: time.Now()}
+			r.s.tags[tag.ID] = tag
+			existing[name] = tag
+		}
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (r *memoryTaxonomyRepository) ListTags(ctx context.Context) ([]TagCount, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	counts := map[uint]int64{}
+	for postID, ids := range r.s.postTags {
+		if post, ok := r.s.posts[postID]; !ok || post.DeletedAt.Valid || post.Status != models.PostStatusPublished {
+			continue
+		}
+		for _, id := range ids {
+			counts[id]++
+		}
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for id, count := range counts {
+		if tag, ok := r.s.tags[id]; ok {
+			tags = append(tags, TagCount{Tag: tag, Posts: count})
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Posts != tags[j].Posts {
+			return tags[i].Posts > tags[j].Posts
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+type memoryCommentRepository struct{ s *MemoryStore }
+
+func (r *memoryCommentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	comment.ID = r.s.allocID("comments")
+	comment.CreatedAt = time.Now()
+	r.s.comments[comment.ID] = r.stored(*comment)
+	return nil
+}
+
+func (r *memoryCommentRepository) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	comment, ok := r.s.comments[id]
+	if !ok || comment.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	comment.User = r.s.author(comment.UserID)
+	return &comment, nil
+}
+
+func (r *memoryCommentRepository) ListByPost(ctx context.Context, postID uint) ([]models.Comment, error) {
+	return r.list(func(c models.Comment) bool { return c.PostID == postID })
+}
+
+func (r *memoryCommentRepository) ListByUser(ctx context.Context, userID uint) ([]models.Comment, error) {
+	return r.list(func(c models.Comment) bool { return c.UserID == userID })
+}
+
+// list 未删除且满足 match 的评论，按创建时间倒序
+func (r *memoryCommentRepository) list(match func(models.Comment) bool) ([]models.Comment, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var comments []models.Comment
+	for _, comment := range r.s.comments {
+		if comment.DeletedAt.Valid || !match(comment) {
+			continue
+		}
+		comment.User = r.s.author(comment.UserID)
+		comments = append(comments, comment)
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.After(comments[j].CreatedAt)
+		}
+		return comments[i].ID > comments[j].ID
+	})
+	return comments, nil
+}
+
+func (r *memoryCommentRepository) Delete(ctx context.Context, id uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	comment, ok := r.s.comments[id]
+	if !ok || comment.DeletedAt.Valid {
+		return nil
+	}
+	comment.DeletedAt.Time, comment.DeletedAt.Valid = time.Now(), true
+	r.s.comments[id] = comment
+	return nil
+}
+
+// stored 去掉关联后再保存
+func (r *memoryCommentRepository) stored(comment models.Comment) models.Comment {
+	comment.User = models.User{}
+	comment.Post = models.Post{}
+	return comment
+}
+
+type memoryTokenRepository struct{ s *MemoryStore }
+
+func (r *memoryTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	token.ID = r.s.allocID("refresh_tokens")
+	token.CreatedAt = time.Now()
+	r.s.refresh[token.ID] = *token
+	return nil
+}
+
+func (r *memoryTokenRepository) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, token := range r.s.refresh {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryTokenRepository) RevokeRefreshToken(ctx context.Context, id uint, at time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	token, ok := r.s.refresh[id]
+	if !ok || token.Revoked() {
+		return false, nil
+	}
+	token.RevokedAt = &at
+	r.s.refresh[id] = token
+	return true, nil
+}
+
+func (r *memoryTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, token := range r.s.refresh {
+		if token.FamilyID == familyID && !token.Revoked() {
+			token.RevokedAt = &at
+			r.s.refresh[id] = token
+		}
+	}
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID uint, exceptFamilyID string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, token := range r.s.refresh {
+		if token.UserID == userID && token.FamilyID != exceptFamilyID && !token.Revoked() {
+			token.RevokedAt = &at
+			r.s.refresh[id] = token
+		}
+	}
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.revoked[jti]; !ok {
+		r.s.revoked[jti] = models.RevokedToken{JTI: jti, ExpiresAt: expiresAt, CreatedAt: time.Now()}
+	}
+	return nil
+}
+
+func (r *memoryTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	_, ok := r.s.revoked[jti]
+	return ok, nil
+}
+
+func (r *memoryTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var deleted int64
+	for id, token := range r.s.refresh {
+		if token.ExpiresAt.Before(before) {
+			delete(r.s.refresh, id)
+			deleted++
+		}
+	}
+	for jti, token := range r.s.revoked {
+		if token.ExpiresAt.Before(before) {
+			delete(r.s.revoked, jti)
+			deleted++
+		}
+	}
+	for id, session := range r.s.sessions {
+		if session.ExpiresAt.Before(before) {
+			delete(r.s.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memoryTokenRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *memoryTokenRepository) FindSession(ctx context.Context, id string) (*models.Session, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	session, ok := r.s.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &session, nil
+}
+
+func (r *memoryTokenRepository) ListSessions(ctx context.Context, userID uint, now time.Time) ([]models.Session, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var sessions []models.Session
+	for _, session := range r.s.sessions {
+		if session.UserID == userID && session.Active(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (r *memoryTokenRepository) UpdateSession(ctx context.Context, session *models.Session) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	stored, ok := r.s.sessions[session.ID]
+	if !ok {
+		return nil
+	}
+	stored.LastSeenAt = session.LastSeenAt
+	stored.IP = session.IP
+	stored.UserAgent = session.UserAgent
+	stored.ExpiresAt = session.ExpiresAt
+	r.s.sessions[session.ID] = stored
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeSession(ctx context.Context, id string, at time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	session, ok := r.s.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return false, nil
+	}
+	session.RevokedAt = &at
+	r.s.sessions[id] = session
+	return true, nil
+}
+
+func (r *memoryTokenRepository) RevokeUserSessions(ctx context.Context, userID uint, exceptID string, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, session := range r.s.sessions {
+		if session.UserID == userID && id != exceptID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			r.s.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+type memoryKeyRepository struct{ s *MemoryStore }
+
+func (r *memoryKeyRepository) CreateSigningKey(ctx context.Context, key *models.SigningKey) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	r.s.keys[key.KID] = *key
+	return nil
+}
+
+func (r *memoryKeyRepository) ListSigningKeys(ctx context.Context, now time.Time) ([]models.SigningKey, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var keys []models.SigningKey
+	for _, key := range r.s.keys {
+		if key.ExpiresAt == nil || key.ExpiresAt.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].KID > keys[j].KID
+	})
+	return keys, nil
+}
+
+func (r *memoryKeyRepository) RetireSigningKeys(ctx context.Context, keepKID string, at, expiresAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for kid, key := range r.s.keys {
+		if kid != keepKID && key.RetiredAt == nil {
+			retiredAt, expires := at, expiresAt
+			key.RetiredAt, key.ExpiresAt = &retiredAt, &expires
+			r.s.keys[kid] = key
+		}
+	}
+	return nil
+}
+
+func (r *memoryKeyRepository) DeleteExpiredSigningKeys(ctx context.Context, before time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var deleted int64
+	for kid, key := range r.s.keys {
+		if key.ExpiresAt != nil && key.ExpiresAt.Before(before) {
+			delete(r.s.keys, kid)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type memoryTwoFactorRepository struct{ s *MemoryStore }
+
+func (r *memoryTwoFactorRepository) AdvanceTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	user, ok := r.s.users[userID]
+	if !ok || user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	r.s.users[userID] = user
+	return true, nil
+}
+
+func (r *memoryTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, code := range r.s.recovery {
+		if code.UserID == userID {
+			delete(r.s.recovery, id)
+		}
+	}
+	now := time.Now()
+	for _, hash := range hashes {
+		id := r.s.allocID("recovery_codes")
+		r.s.recovery[id] = models.RecoveryCode{ID: id, UserID: userID, CodeHash: hash, CreatedAt: now}
+	}
+	return nil
+}
+
+func (r *memoryTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, code := range r.s.recovery {
+		if code.UserID == userID && code.CodeHash == hash && code.UsedAt == nil {
+			code.UsedAt = &at
+			r.s.recovery[id] = code
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryTwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var count int64
+	for _, code := range r.s.recovery {
+		if code.UserID == userID && code.UsedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type memoryPolicyRepository struct{ s *MemoryStore }
+
+func (r *memoryPolicyRepository) ListRolePolicies(ctx context.Context) ([]models.RolePolicy, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	policies := make([]models.RolePolicy, 0, len(r.s.policies))
+	for _, policy := range r.s.policies {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Role < policies[j].Role })
+	return policies, nil
+}
+
+func (r *memoryPolicyRepository) SaveRolePolicy(ctx context.Context, policy *models.RolePolicy) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	policy.UpdatedAt = time.Now()
+	r.s.policies[policy.Role] = *policy
+	return nil
+}
+
+type memoryLoginAttemptRepository struct{ s *MemoryStore }
+
+func (r *memoryLoginAttemptRepository) SwapLoginCounter(ctx context.Context, subject string, old, new *models.LoginCounter) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	current, ok := r.s.counters[subject]
+	if ok != (old != nil) || ok && current.Failures != old.Failures {
+		return false, nil
+	}
+	if new == nil {
+		delete(r.s.counters, subject)
+		return true, nil
+	}
+	counter := *new
+	counter.Subject = subject
+	r.s.counters[subject] = counter
+	return true, nil
+}
+
+func (r *memoryLoginAttemptRepository) FindLoginCounter(ctx context.Context, subject string) (*models.LoginCounter, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	counter, ok := r.s.counters[subject]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &counter, nil
+}
+
+func (r *memoryLoginAttemptRepository) LockLogin(ctx context.Context, subject string, until time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if counter, ok := r.s.counters[subject]; ok {
+		counter.LockedUntil = &until
+		r.s.counters[subject] = counter
+	}
+	return nil
+}
+
+func (r *memoryLoginAttemptRepository) ResetLoginCounter(ctx context.Context, subject string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.counters, subject)
+	return nil
+}
+
+func (r *memoryLoginAttemptRepository) CreateLoginEvent(ctx context.Context, event *models.LoginEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	event.ID = r.s.allocID("login_events")
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	r.s.events = append(r.s.events, *event)
+	return nil
+}
+
+func (r *memoryLoginAttemptRepository) ListLoginEvents(ctx context.Context, filter LoginEventFilter) ([]models.LoginEvent, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	events := []models.LoginEvent{}
+	for i := len(r.s.events) - 1; i >= 0 && len(events) < filter.Limit; i-- {
+		if filter.Username == "" || r.s.events[i].Username == filter.Username {
+			events = append(events, r.s.events[i])
+		}
+	}
+	return events, nil
+}
+
+func (r *memoryLoginAttemptRepository) DeleteStaleLoginCounters(ctx context.Context, before, now time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var deleted int64
+	for subject, counter := range r.s.counters {
+		if counter.LastFailureAt.Before(before) && (counter.LockedUntil == nil || counter.LockedUntil.Before(now)) {
+			delete(r.s.counters, subject)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type memoryPersonalTokenRepository struct{ s *MemoryStore }
+
+func (r *memoryPersonalTokenRepository) CreatePersonalToken(ctx context.Context, token *models.PersonalAccessToken) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	token.ID = r.s.allocID("personal_access_tokens")
+	token.CreatedAt = time.Now()
+	r.s.personal[token.ID] = *token
+	return nil
+}
+
+func (r *memoryPersonalTokenRepository) FindPersonalTokenByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, token := range r.s.personal {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryPersonalTokenRepository) ListPersonalTokens(ctx context.Context, userID uint) ([]models.PersonalAccessToken, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var tokens []models.PersonalAccessToken
+	for _, token := range r.s.personal {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID > tokens[j].ID })
+	return tokens, nil
+}
+
+func (r *memoryPersonalTokenRepository) DeletePersonalToken(ctx context.Context, userID, id uint) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	token, ok := r.s.personal[id]
+	if !ok || token.UserID != userID {
+		return false, nil
+	}
+	delete(r.s.personal, id)
+	return true, nil
+}
+
+func (r *memoryPersonalTokenRepository) DeleteUserPersonalTokens(ctx context.Context, userID uint) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, token := range r.s.personal {
+		if token.UserID == userID {
+			delete(r.s.personal, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryPersonalTokenRepository) TouchPersonalToken(ctx context.Context, id uint, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if token, ok := r.s.personal[id]; ok {
+		token.LastUsedAt = &at
+		r.s.personal[id] = token
+	}
+	return nil
+}
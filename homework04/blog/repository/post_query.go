@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"blog/models"
+	"strings"
+	"time"
+)
+
+// 文章列表可用的排序字段
+const (
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortTitle     = "title"
+)
+
+// PostFilter 文章列表过滤条件，零值表示不过滤
+type PostFilter struct {
+	AuthorID       uint
+	AuthorUsername string
+	CreatedFrom    time.Time // 包含
+	CreatedTo      time.Time // 不包含
+	Keyword        string    // 匹配标题或正文
+	Tag            string
+	CategoryIDs    []uint // 属于其中任一分类，为空时不过滤
+	Status         string // 为空时不按状态过滤
+	// OnlyVisible 只返回已发布的文章，以及 ViewerID（非 0 时）本人的文章，见 models.Post.VisibleTo
+	OnlyVisible bool
+	ViewerID    uint
+}
+
+// PostListOptions 文章列表查询参数
+// Cursor 不为空时使用游标分页并忽略 Offset
+type PostListOptions struct {
+	PostFilter
+	SortField string
+	Desc      bool
+	Limit     int
+	Offset    int
+	Cursor    *PostCursor
+}
+
+// PostCursor 游标：排序字段的值加 ID 唯一确定一行的位置
+// Backward 为 true 时取该位置之前的数据（上一页）
+type PostCursor struct {
+	Field    string `json:"f"`
+	Value    string `json:"v"`
+	ID       uint   `json:"id"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+// CursorFor 生成指向 post 的游标
+func CursorFor(post models.Post, field string, backward bool) PostCursor {
+	return PostCursor{Field: field, Value: sortValue(post, field), ID: post.ID, Backward: backward}
+}
+
+// sortValue 文章在排序字段上的值，时间使用 RFC3339Nano 表示
+// 时间保留数据库返回的时区，不转换为 UTC：SQLite 把时间连同时区偏移按字符串保存和比较，
+// 游标中的值必须与列中保存的值使用同一个时区
+func sortValue(post models.Post, field string) string {
+	switch field {
+	case SortUpdatedAt:
+		return post.UpdatedAt.Format(time.RFC3339Nano)
+	case SortTitle:
+		return post.Title
+	default:
+		return post.CreatedAt.Format(time.RFC3339Nano)
+	}
+}
+
+// cursorValue 把游标中的值转换为查询参数，时间保留游标中的时区偏移
+func cursorValue(cursor PostCursor) (interface{}, error) {
+	if cursor.Field == SortTitle {
+		return cursor.Value, nil
+	}
+	return time.Parse(time.RFC3339Nano, cursor.Value)
+}
+
+// scanDesc 实际扫描方向：向前翻页时与排序方向相反
+func (o PostListOptions) scanDesc() bool {
+	if o.Cursor != nil && o.Cursor.Backward {
+		return !o.Desc
+	}
+	return o.Desc
+}
+
+// escapeLike 转义 LIKE 模式中的通配符，转义符为 '!'（各数据库对反斜杠的处理不一致）
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// reversePosts 原地反转
+func reversePosts(posts []models.Post) {
+	for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+		posts[i], posts[j] = posts[j], posts[i]
+	}
+}
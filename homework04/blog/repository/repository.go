@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"blog/models"
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound 记录不存在（或已被软删除）
+var ErrNotFound = errors.New("record not found")
+
+// UserRepository 用户存储
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	// Delete 软删除用户，用户不存在或已删除时返回 ErrNotFound
+	Delete(ctx context.Context, id uint) error
+	// DeleteAccount 在一个事务中注销账号：保存已清除个人信息的 user 并软删除，按 opts 删除其文章（连同标签关联）和评论，
+	// 吊销其所有登录会话和刷新令牌，删除其个人访问令牌；用户不存在或已删除时返回 ErrNotFound
+	DeleteAccount(ctx context.Context, user *models.User, opts AccountDeletion) error
+}
+
+// AccountDeletion 注销账号时对文章和评论的处理
+type AccountDeletion struct {
+	DeletePosts    bool
+	DeleteComments bool
+	// RevokedAt 会话和刷新令牌的吊销时间
+	RevokedAt time.Time
+}
+
+// PostRepository 文章存储，查询结果会带上作者 User、分类 Category 和按名称排序的 Tags
+// 保存文章时同时保存其与 Tags 的关联；更新或删除文章后不再被任何文章使用的标签会被删除
+// 更新文章时 slug 变化则把原来的 slug 记为旧 slug，文章改回自己用过的旧 slug 时从旧 slug 中移除
+type PostRepository interface {
+	// Create 保存文章和它的第一个版本；revision 的 PostID 和 Number 由仓储填写
+	Create(ctx context.Context, post *models.Post, revision *models.PostRevision) error
+	FindByID(ctx context.Context, id uint) (*models.Post, error)
+	// FindBySlug 按文章当前的 slug 查找
+	FindBySlug(ctx context.Context, slug string) (*models.Post, error)
+	// FindByOldSlug 按文章用过的旧 slug 查找
+	FindByOldSlug(ctx context.Context, slug string) (*models.Post, error)
+	// SlugTaken slug 是否已被 exceptID 以外的文章占用：其他文章（包括已删除的）当前或用过的 slug
+	SlugTaken(ctx context.Context, slug string, exceptID uint) (bool, error)
+	// List 按条件分页查询，返回当前页和满足过滤条件的总数
+	// 使用游标且 Cursor.Backward 为 true 时，返回的数据仍按 opts 指定的顺序排列
+	List(ctx context.Context, opts PostListOptions) ([]models.Post, int64, error)
+	// Update 保存文章并追加一个版本，Number 为该文章已有的最大版本号加 1
+	Update(ctx context.Context, post *models.Post, revision *models.PostRevision) error
+	Delete(ctx context.Context, id uint) error
+	// PublishDue 把计划发布时间不晚于 now 的定时文章改为已发布，返回本次发布的文章
+	// 每篇文章按状态条件更新，多个实例同时调用时只有一个实例会发布并返回它
+	PublishDue(ctx context.Context, now time.Time) ([]models.Post, error)
+	// ListRevisions 文章的所有版本（不含正文），按版本号倒序，填充 Editor
+	ListRevisions(ctx context.Context, postID uint) ([]models.PostRevision, error)
+	// FindRevision 按版本号查找文章的一个版本，填充 Editor
+	FindRevision(ctx context.Context, postID uint, number int) (*models.PostRevision, error)
+}
+
+// TagCount 标签及使用它的文章数
+type TagCount struct {
+	models.Tag
+	Posts int64
+}
+
+// TaxonomyRepository 文章分类和标签；文章与标签的关联随文章一起保存，见 PostRepository
+type TaxonomyRepository interface {
+	CreateCategory(ctx context.Context, category *models.Category) error
+	FindCategory(ctx context.Context, id uint) (*models.Category, error)
+	FindCategoryByName(ctx context.Context, name string) (*models.Category, error)
+	// ListCategories 所有分类，按名称排序
+	ListCategories(ctx context.Context) ([]models.Category, error)
+	// CountPostsByCategory 各分类直接包含的已发布文章数（不含子分类），没有文章的分类不出现在结果中
+	CountPostsByCategory(ctx context.Context) (map[uint]int64, error)
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	// DeleteCategory 删除分类，其下的文章变为未分类
+	DeleteCategory(ctx context.Context, id uint) error
+	// EnsureTags 按名称返回标签，不存在的自动创建；并发创建同名标签时得到同一个标签
+	EnsureTags(ctx context.Context, names []string) ([]models.Tag, error)
+	// ListTags 有已发布文章使用的标签，按文章数倒序、名称升序
+	ListTags(ctx context.Context) ([]TagCount, error)
+}
+
+// CommentRepository 评论存储，查询结果会带上评论者 User
+type CommentRepository interface {
+	Create(ctx context.Context, comment *models.Comment) error
+	FindByID(ctx context.Context, id uint) (*models.Comment, error)
+	ListByPost(ctx context.Context, postID uint) ([]models.Comment, error)
+	// ListByUser 用户发表的评论，按创建时间倒序
+	ListByUser(ctx context.Context, userID uint) ([]models.Comment, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// TokenRepository 刷新令牌、访问令牌黑名单和登录会话
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RevokeRefreshToken 吊销尚未吊销的令牌，返回 false 表示令牌已被吊销；并发轮换同一个令牌时只有一方成功
+	RevokeRefreshToken(ctx context.Context, id uint, at time.Time) (bool, error)
+	// RevokeFamily 吊销同一 family 中所有未吊销的令牌
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+	// RevokeUserRefreshTokens 吊销用户除 exceptFamilyID 以外所有未吊销的刷新令牌，exceptFamilyID 为空时全部吊销
+	RevokeUserRefreshTokens(ctx context.Context, userID uint, exceptFamilyID string, at time.Time) error
+	// RevokeAccessToken 把访问令牌的 jti 加入黑名单，重复加入不报错
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	CreateSession(ctx context.Context, session *models.Session) error
+	FindSession(ctx context.Context, id string) (*models.Session, error)
+	// ListSessions 用户在 now 时仍然有效的会话，按最近活动时间倒序
+	ListSessions(ctx context.Context, userID uint, now time.Time) ([]models.Session, error)
+	// UpdateSession 保存会话的最近活动时间、IP、User-Agent 和过期时间
+	UpdateSession(ctx context.Context, session *models.Session) error
+	// RevokeSession 吊销尚未吊销的会话，返回 false 表示会话已被吊销
+	RevokeSession(ctx context.Context, id string, at time.Time) (bool, error)
+	// RevokeUserSessions 吊销用户除 exceptID 以外所有未吊销的会话，exceptID 为空时全部吊销
+	RevokeUserSessions(ctx context.Context, userID uint, exceptID string, at time.Time) error
+	// DeleteExpired 清理 before 之前过期的刷新令牌、黑名单记录和会话
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// KeyRepository JWT 签名密钥
+type KeyRepository interface {
+	CreateSigningKey(ctx context.Context, key *models.SigningKey) error
+	// ListSigningKeys 返回 now 时仍可用于验证的密钥，按创建时间倒序
+	ListSigningKeys(ctx context.Context, now time.Time) ([]models.SigningKey, error)
+	// RetireSigningKeys 退役除 keepKID 以外所有未退役的密钥，退役的密钥到 expiresAt 后过期
+	RetireSigningKeys(ctx context.Context, keepKID string, at, expiresAt time.Time) error
+	// DeleteExpiredSigningKeys 清理 before 之前过期的密钥
+	DeleteExpiredSigningKeys(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TwoFactorRepository 两步验证的验证码防重放和恢复码
+type TwoFactorRepository interface {
+	// AdvanceTOTPStep 把用户最近使用的时间步推进到 step，step 不大于已记录的值时返回 false；
+	// 同一个验证码被并发提交时只有一方成功
+	AdvanceTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	// ReplaceRecoveryCodes 删除用户原有的恢复码并保存新的摘要
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error
+	// UseRecoveryCode 把未使用的恢复码标记为已使用，不存在或已使用时返回 false
+	UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error)
+	// CountRecoveryCodes 用户未使用的恢复码数量
+	CountRecoveryCodes(ctx context.Context, userID uint) (int64, error)
+}
+
+// PolicyRepository 角色安全策略
+type PolicyRepository interface {
+	ListRolePolicies(ctx context.Context) ([]models.RolePolicy, error)
+	// SaveRolePolicy 创建或更新角色的策略
+	SaveRolePolicy(ctx context.Context, policy *models.RolePolicy) error
+}
+
+// LoginEventFilter 登录锁定事件的查询条件
+type LoginEventFilter struct {
+	// Username 非空时只返回该用户名的事件
+	Username string
+	Limit    int
+}
+
+// LoginAttemptRepository 登录失败计数和锁定记录
+type LoginAttemptRepository interface {
+	// SwapLoginCounter 比较并交换：记录的失败次数仍为 old.Failures（old 为 nil 表示没有记录）时把记录替换为 new
+	// （new 为 nil 表示删除），返回是否替换成功；old 和 new 不能都为 nil
+	SwapLoginCounter(ctx context.Context, subject string, old, new *models.LoginCounter) (bool, error)
+	// FindLoginCounter 没有失败记录时返回 ErrNotFound
+	FindLoginCounter(ctx context.Context, subject string) (*models.LoginCounter, error)
+	LockLogin(ctx context.Context, subject string, until time.Time) error
+	// ResetLoginCounter 删除失败计数和锁定，记录不存在时不报错
+	ResetLoginCounter(ctx context.Context, subject string) error
+	CreateLoginEvent(ctx context.Context, event *models.LoginEvent) error
+	// ListLoginEvents 按时间倒序返回事件
+	ListLoginEvents(ctx context.Context, filter LoginEventFilter) ([]models.LoginEvent, error)
+	// DeleteStaleLoginCounters 清理 before 之前最后一次失败且未处于锁定的计数
+	DeleteStaleLoginCounters(ctx context.Context, before, now time.Time) (int64, error)
+}
+
+// PersonalTokenRepository 个人访问令牌
+type PersonalTokenRepository interface {
+	CreatePersonalToken(ctx context.Context, token *models.PersonalAccessToken) error
+	FindPersonalTokenByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error)
+	// ListPersonalTokens 用户的所有令牌（包括已过期的），按创建时间倒序
+	ListPersonalTokens(ctx context.Context, userID uint) ([]models.PersonalAccessToken, error)
+	// DeletePersonalToken 删除属于 userID 的令牌，令牌不存在或属于其他用户时返回 false
+	DeletePersonalToken(ctx context.Context, userID, id uint) (bool, error)
+	// DeleteUserPersonalTokens 删除用户的所有令牌
+	DeleteUserPersonalTokens(ctx context.Context, userID uint) error
+	// TouchPersonalToken 记录令牌的最近使用时间
+	TouchPersonalToken(ctx context.Context, id uint, at time.Time) error
+}
@@ -0,0 +1,121 @@
+package routes_test
+
+import (
+	"blog/testutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestEmailVerification 注册 -> 验证邮件 -> 验证邮箱 -> 令牌不能再次使用
+func TestEmailVerification(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+
+	mails := server.Mails(alice.Email)
+	if len(mails) != 1 || !strings.Contains(mails[0].Body, server.Config.Mail.BaseURL+"/verify-email?token=") {
+		t.Fatalf("verification mail: got %+v", mails)
+	}
+	token := server.LastMailToken(alice.Email)
+
+	verify := func(token string, wantCode int) *testutil.Response {
+		t.Helper()
+		resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/verify", Body: map[string]string{"token": token}})
+		if resp.Code != wantCode {
+			t.Fatalf("verify: got status %d, want %d (body %s)", resp.Code, wantCode, resp.Body)
+		}
+		return resp
+	}
+
+	verify(token[:len(token)-2]+"xx", http.StatusBadRequest)
+	testutil.AssertGolden(t, "auth_verify", verify(token, http.StatusOK).Body)
+	testutil.AssertGolden(t, "auth_verify_invalid", verify(token, http.StatusBadRequest).Body)
+
+	var profile struct {
+		EmailVerified bool `json:"email_verified"`
+	}
+	server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/auth/profile", Token: alice.Token}).Decode(t, &profile)
+	if !profile.EmailVerified {
+		t.Fatal("profile: email not marked as verified")
+	}
+
+	resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/verify/resend", Token: alice.Token})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("resend after verification: got status %d (body %s)", resp.Code, resp.Body)
+	}
+
+	// 未验证的用户可以重新发送，旧链接在验证前仍然有效
+	bob := server.RegisterAndLogin("bob")
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/verify/resend", Token: bob.Token})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("resend: got status %d (body %s)", resp.Code, resp.Body)
+	}
+	testutil.AssertGolden(t, "auth_verify_resend", resp.Body)
+	if got := len(server.Mails(bob.Email)); got != 2 {
+		t.Fatalf("mails to bob: got %d, want 2", got)
+	}
+	verify(server.LastMailToken(bob.Email), http.StatusOK)
+}
+
+// TestPasswordReset 找回密码 -> 重置 -> 旧密码和旧会话失效 -> 令牌不能再次使用
+func TestPasswordReset(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+	verifyToken := server.LastMailToken(alice.Email)
+
+	forgot := func(email string) *testutil.Response {
+		t.Helper()
+		resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/forgot-password", Body: map[string]string{"email": email}})
+		if resp.Code != http.StatusOK {
+			t.Fatalf("forgot-password: got status %d (body %s)", resp.Code, resp.Body)
+		}
+		return resp
+	}
+	reset := func(token, password string, wantCode int) *testutil.Response {
+		t.Helper()
+		resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/reset-password", Body: map[string]string{"token": token, "password": password}})
+		if resp.Code != wantCode {
+			t.Fatalf("reset-password: got status %d, want %d (body %s)", resp.Code, wantCode, resp.Body)
+		}
+		return resp
+	}
+
+	// 未注册的邮箱得到同样的响应，但不会发送邮件
+	unknown := forgot("nobody@example.com")
+	if len(server.Mails("nobody@example.com")) != 0 {
+		t.Fatal("mail sent to an unregistered address")
+	}
+	resp := forgot(alice.Email)
+	if string(resp.Body) != string(unknown.Body) {
+		t.Fatalf("forgot-password responses differ: %s vs %s", resp.Body, unknown.Body)
+	}
+	testutil.AssertGolden(t, "auth_forgot_password", resp.Body)
+
+	// 验证邮件中的令牌不能用于重置密码
+	reset(verifyToken, "newpassword", http.StatusBadRequest)
+
+	token := server.LastMailToken(alice.Email)
+	reset(token, "123", http.StatusBadRequest)
+	testutil.AssertGolden(t, "auth_reset_password", reset(token, "newpassword", http.StatusOK).Body)
+	testutil.AssertGolden(t, "auth_reset_password_invalid", reset(token, "anotherpassword", http.StatusBadRequest).Body)
+
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/refresh", Body: map[string]string{"refresh_token": alice.RefreshToken}})
+	if resp.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after reset: got status %d (body %s)", resp.Code, resp.Body)
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{"username": "alice", "password": alice.Password}})
+	if resp.Code != http.StatusUnauthorized {
+		t.Fatalf("login with old password: got status %d (body %s)", resp.Code, resp.Body)
+	}
+	alice.Password = "newpassword"
+	server.Login(alice)
+
+	// 收到重置邮件也证明了邮箱归属
+	var profile struct {
+		EmailVerified bool `json:"email_verified"`
+	}
+	server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/auth/profile", Token: alice.Token}).Decode(t, &profile)
+	if !profile.EmailVerified {
+		t.Fatal("profile: email not marked as verified after password reset")
+	}
+}
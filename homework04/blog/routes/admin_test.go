@@ -0,0 +1,153 @@
+package routes_test
+
+import (
+	"blog/testutil"
+	"net/http"
+	"testing"
+)
+
+// newRBACFixture 在 newFixture 的基础上增加管理员 carol、编辑 dave 和只读用户 erin
+func newRBACFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := newFixture(t)
+	for name, role := range map[string]string{"carol": "admin", "dave": "editor", "erin": "reader"} {
+		user := f.server.RegisterAndLogin(name)
+		f.server.SetRole(user, role)
+		f.users[name] = user
+	}
+	return f
+}
+
+func TestRBACRoutes(t *testing.T) {
+	cases := []routeCase{
+		{
+			name: "reader cannot create post", method: http.MethodPost, path: "/api/posts", as: "erin",
+			body:     map[string]string{"title": "x", "content": "y"},
+			wantCode: http.StatusForbidden, golden: "rbac_post_create_forbidden",
+		},
+		{
+			name: "reader can comment", method: http.MethodPost, path: "/api/posts/{post}/comments", as: "erin",
+			body: map[string]string{"content": "Read it"}, wantCode: http.StatusOK,
+		},
+		{
+			name: "editor updates any post", method: http.MethodPut, path: "/api/posts/{post}", as: "dave",
+			body:     map[string]string{"title": "Edited", "content": "Edited by editor"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "editor deletes any comment", method: http.MethodDelete, path: "/api/posts/{post}/comments/{comment}", as: "dave",
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "moderate post as editor", method: http.MethodDelete, path: "/api/admin/posts/{post}", as: "dave",
+			wantCode: http.StatusOK, golden: "posts_delete",
+		},
+		{
+			name: "moderate post as author", method: http.MethodDelete, path: "/api/admin/posts/{post}", as: "alice",
+			wantCode: http.StatusForbidden, golden: "rbac_moderate_post_forbidden",
+		},
+		{
+			name: "moderate comment as admin", method: http.MethodDelete, path: "/api/admin/comments/{comment}", as: "carol",
+			wantCode: http.StatusOK, golden: "comments_delete",
+		},
+		{
+			name: "moderate comment as commenter", method: http.MethodDelete, path: "/api/admin/comments/{comment}", as: "bob",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "set role as admin", method: http.MethodPut, path: "/api/admin/users/{bob}/role", as: "carol",
+			body: map[string]string{"role": "editor"}, wantCode: http.StatusOK, golden: "admin_set_role",
+		},
+		{
+			name: "set role as editor", method: http.MethodPut, path: "/api/admin/users/{bob}/role", as: "dave",
+			body: map[string]string{"role": "admin"}, wantCode: http.StatusForbidden, golden: "rbac_user_manage_forbidden",
+		},
+		{
+			name: "set own role", method: http.MethodPut, path: "/api/admin/users/{carol}/role", as: "carol",
+			body: map[string]string{"role": "reader"}, wantCode: http.StatusBadRequest, golden: "admin_set_own_role",
+		},
+		{
+			name: "set unknown role", method: http.MethodPut, path: "/api/admin/users/{bob}/role", as: "carol",
+			body: map[string]string{"role": "root"}, wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "set role of missing user", method: http.MethodPut, path: "/api/admin/users/999/role", as: "carol",
+			body: map[string]string{"role": "editor"}, wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			newRBACFixture(t).run(t, tc)
+		})
+	}
+}
+
+// TestRoleChangeTakesEffectImmediately 认证中间件按数据库中的当前角色鉴权，已签发的令牌无需刷新
+func TestRoleChangeTakesEffectImmediately(t *testing.T) {
+	f := newRBACFixture(t)
+	post := routeCase{
+		method: http.MethodPost, path: "/api/posts", as: "erin",
+		body: map[string]string{"title": "Promoted", "content": "Now I can write"},
+	}
+
+	post.wantCode = http.StatusForbidden
+	f.run(t, post)
+	f.run(t, routeCase{
+		method: http.MethodPut, path: "/api/admin/users/{erin}/role", as: "carol",
+		body: map[string]string{"role": "author"}, wantCode: http.StatusOK,
+	})
+	post.wantCode = http.StatusOK
+	f.run(t, post)
+}
+
+// TestDisabledAndDeletedUsers 被禁用或删除的用户，已签发的令牌也不能再使用
+func TestDisabledAndDeletedUsers(t *testing.T) {
+	f := newRBACFixture(t)
+	bob := f.users["bob"]
+	profile := func(as string, wantCode int, golden string) {
+		t.Helper()
+		f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", as: as, wantCode: wantCode, golden: golden})
+	}
+	setStatus := func(disabled bool, wantCode int, golden string) {
+		t.Helper()
+		f.run(t, routeCase{
+			method: http.MethodPut, path: "/api/admin/users/{bob}/status", as: "carol",
+			body: map[string]bool{"disabled": disabled}, wantCode: wantCode, golden: golden,
+		})
+	}
+
+	setStatus(true, http.StatusOK, "admin_disable_user")
+	profile("bob", http.StatusForbidden, "auth_account_disabled")
+	login := f.server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{
+		"username": bob.Username, "password": bob.Password,
+	}})
+	if login.Code != http.StatusForbidden {
+		t.Fatalf("login of disabled user: got status %d (body %s)", login.Code, login.Body)
+	}
+	refresh := f.server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/refresh", Body: map[string]string{
+		"refresh_token": bob.RefreshToken,
+	}})
+	if refresh.Code != http.StatusForbidden {
+		t.Fatalf("refresh of disabled user: got status %d (body %s)", refresh.Code, refresh.Body)
+	}
+
+	setStatus(false, http.StatusOK, "")
+	profile("bob", http.StatusOK, "")
+
+	f.run(t, routeCase{
+		method: http.MethodPut, path: "/api/admin/users/{carol}/status", as: "carol",
+		body: map[string]bool{"disabled": true}, wantCode: http.StatusBadRequest,
+	})
+	f.run(t, routeCase{
+		method: http.MethodPut, path: "/api/admin/users/{bob}/status", as: "dave",
+		body: map[string]bool{"disabled": true}, wantCode: http.StatusForbidden,
+	})
+
+	// 直接在数据库中软删除：缓存过期前本实例仍可能命中缓存，这里删除的是从未请求过的用户
+	if err := f.server.DB.Exec("UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?", f.users["erin"].ID).Error; err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+	profile("erin", http.StatusUnauthorized, "auth_user_not_found")
+}
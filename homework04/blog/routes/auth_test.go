@@ -0,0 +1,107 @@
+package routes_test
+
+import (
+	"blog/config"
+	"blog/testutil"
+	"blog/utils"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TestTokenLifecycle 登录 -> 刷新 -> 重用旧刷新令牌 -> 登出
+func TestTokenLifecycle(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+
+	refresh := func(refreshToken string, wantCode int) *testutil.Response {
+		t.Helper()
+		resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/refresh", Body: map[string]string{"refresh_token": refreshToken}})
+		if resp.Code != wantCode {
+			t.Fatalf("refresh: got status %d, want %d (body %s)", resp.Code, wantCode, resp.Body)
+		}
+		return resp
+	}
+	profile := func(token string, wantCode int) {
+		t.Helper()
+		resp := server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/auth/profile", Token: token})
+		if resp.Code != wantCode {
+			t.Fatalf("profile: got status %d, want %d (body %s)", resp.Code, wantCode, resp.Body)
+		}
+	}
+
+	resp := refresh(alice.RefreshToken, http.StatusOK)
+	testutil.AssertGolden(t, "auth_refresh", resp.Body)
+	var rotated struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	resp.Decode(t, &rotated)
+	profile(rotated.Token, http.StatusOK)
+
+	// 重用已轮换的刷新令牌：整个登录会话失效
+	resp = refresh(alice.RefreshToken, http.StatusUnauthorized)
+	testutil.AssertGolden(t, "auth_refresh_reused", resp.Body)
+	refresh(rotated.RefreshToken, http.StatusUnauthorized)
+
+	// 登出后访问令牌和刷新令牌都不能再使用
+	server.Login(alice)
+	logout := server.Do(testutil.Request{
+		Method: http.MethodPost, Path: "/api/auth/logout", Token: alice.Token,
+		Body: map[string]string{"refresh_token": alice.RefreshToken},
+	})
+	if logout.Code != http.StatusOK {
+		t.Fatalf("logout: got status %d (body %s)", logout.Code, logout.Body)
+	}
+	profile(alice.Token, http.StatusUnauthorized)
+	refresh(alice.RefreshToken, http.StatusUnauthorized)
+}
+
+// TestJWKS 访问令牌可以用 JWKS 中 kid 对应的公钥验证；HS256 时不公开任何密钥
+func TestJWKS(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+
+	resp := server.Do(testutil.Request{Method: http.MethodGet, Path: "/.well-known/jwks.json"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("jwks: got status %d (body %s)", resp.Code, resp.Body)
+	}
+	if got := resp.Header.Get("Cache-Control"); !strings.Contains(got, "max-age=") {
+		t.Fatalf("jwks: Cache-Control %q", got)
+	}
+	var set utils.JWKSet
+	if err := json.Unmarshal(resp.Body, &set); err != nil {
+		t.Fatalf("decode jwks: %v", err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].Kty != "OKP" || set.Keys[0].Alg != config.JWTAlgorithmEdDSA {
+		t.Fatalf("jwks: got %+v, want one Ed25519 key", set.Keys)
+	}
+
+	// 只用 JWKS 中的公钥验证 alice 的访问令牌，模拟外部服务
+	public, err := base64.RawURLEncoding.DecodeString(set.Keys[0].X)
+	if err != nil {
+		t.Fatalf("decode public key: %v", err)
+	}
+	token, err := jwt.Parse(alice.Token, func(token *jwt.Token) (interface{}, error) {
+		if token.Header["kid"] != set.Keys[0].Kid {
+			return nil, fmt.Errorf("unexpected kid %v", token.Header["kid"])
+		}
+		return ed25519.PublicKey(public), nil
+	}, jwt.WithValidMethods([]string{config.JWTAlgorithmEdDSA}), jwt.WithAudience(server.Config.JWT.Audience))
+	if err != nil || !token.Valid {
+		t.Fatalf("verify access token with JWKS: %v", err)
+	}
+
+	hs256 := testutil.NewServer(t, func(cfg *config.Config) { cfg.JWT.Algorithm = config.JWTAlgorithmHS256 })
+	hs256.RegisterAndLogin("alice")
+	resp = hs256.Do(testutil.Request{Method: http.MethodGet, Path: "/.well-known/jwks.json"})
+	if resp.Code != http.StatusOK || strings.TrimSpace(string(resp.Body)) != `{"keys":[]}` {
+		t.Fatalf("hs256 jwks: got status %d body %s", resp.Code, resp.Body)
+	}
+}
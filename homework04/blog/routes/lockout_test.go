@@ -0,0 +1,107 @@
+package routes_test
+
+import (
+	"blog/config"
+	"blog/testutil"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func login(server *testutil.Server, username, password string) *testutil.Response {
+	return server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{
+		"username": username, "password": password,
+	}})
+}
+
+// TestLoginLockout 连续失败达到上限后即使密码正确也不能登录，管理员解锁后恢复
+func TestLoginLockout(t *testing.T) {
+	server := testutil.NewServer(t, func(cfg *config.Config) {
+		cfg.Auth.Lockout.MaxAttempts = 3
+	})
+	alice := server.RegisterAndLogin("alice")
+	carol := server.RegisterAndLogin("carol")
+	server.SetRole(carol, "admin")
+	f := &fixture{server: server, users: map[string]*testutil.User{"alice": alice, "carol": carol}}
+
+	for i := 0; i < 3; i++ {
+		if resp := login(server, "alice", "wrong-password"); resp.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: got status %d (body %s)", i+1, resp.Code, resp.Body)
+		}
+	}
+	resp := login(server, "alice", alice.Password)
+	if resp.Code != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "900" {
+		t.Fatalf("locked login: got status %d, Retry-After %q", resp.Code, resp.Header.Get("Retry-After"))
+	}
+	testutil.AssertGolden(t, "auth_login_locked", resp.Body)
+	// 已签发的令牌不受影响
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", as: "alice", wantCode: http.StatusOK})
+
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/admin/users/{alice}/unlock", as: "alice", wantCode: http.StatusForbidden})
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/admin/users/999/unlock", as: "carol", wantCode: http.StatusNotFound})
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/admin/users/{alice}/unlock", as: "carol", wantCode: http.StatusOK, golden: "admin_unlock_user"})
+	if resp := login(server, "alice", alice.Password); resp.Code != http.StatusOK {
+		t.Fatalf("login after unlock: got status %d (body %s)", resp.Code, resp.Body)
+	}
+
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/admin/login-events?username=alice", as: "carol", wantCode: http.StatusOK, golden: "admin_login_events"})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/admin/login-events?limit=500", as: "carol", wantCode: http.StatusBadRequest})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/admin/login-events", as: "alice", wantCode: http.StatusForbidden})
+}
+
+// TestLoginProgressiveDelay 超过免延迟次数后，下一次尝试需要等待；不存在的用户名同样计数
+func TestLoginProgressiveDelay(t *testing.T) {
+	for _, username := range []string{"alice", "nobody"} {
+		server := testutil.NewServer(t, func(cfg *config.Config) {
+			cfg.Auth.Lockout.FreeAttempts = 1
+			cfg.Auth.Lockout.BaseDelay = config.Duration(time.Minute)
+			cfg.Auth.Lockout.MaxDelay = config.Duration(time.Hour)
+		})
+		alice := server.RegisterAndLogin("alice")
+
+		for i := 0; i < 2; i++ {
+			if resp := login(server, username, "wrong-password"); resp.Code != http.StatusUnauthorized {
+				t.Fatalf("%s failure %d: got status %d", username, i+1, resp.Code)
+			}
+		}
+		resp := login(server, username, alice.Password)
+		if resp.Code != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "60" {
+			t.Fatalf("%s delayed login: got status %d, Retry-After %q", username, resp.Code, resp.Header.Get("Retry-After"))
+		}
+		testutil.AssertGolden(t, "auth_login_delayed", resp.Body)
+	}
+}
+
+// TestLoginLockoutIgnoresForgedForwardedFor 不可信的来源伪造 X-Forwarded-For 不能绕过按 IP 的限制；
+// 来自可信代理的请求按代理转发的客户端 IP 计数
+func TestLoginLockoutIgnoresForgedForwardedFor(t *testing.T) {
+	forged := func(server *testutil.Server, i int) *testutil.Response {
+		return server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{
+			"username": fmt.Sprintf("nobody%d", i), "password": "wrong-password",
+		}, Headers: map[string]string{"X-Forwarded-For": fmt.Sprintf("198.51.100.%d", i)}})
+	}
+
+	server := testutil.NewServer(t, func(cfg *config.Config) {
+		cfg.Auth.Lockout.IPMaxAttempts = 3
+	})
+	for i := 0; i < 3; i++ {
+		if resp := forged(server, i); resp.Code != http.StatusUnauthorized {
+			t.Fatalf("failure %d: got status %d (body %s)", i+1, resp.Code, resp.Body)
+		}
+	}
+	if resp := forged(server, 3); resp.Code != http.StatusTooManyRequests {
+		t.Fatalf("forged X-Forwarded-For after IP lockout: got status %d (body %s)", resp.Code, resp.Body)
+	}
+
+	// httptest 请求的对端地址是 192.0.2.1
+	server = testutil.NewServer(t, func(cfg *config.Config) {
+		cfg.Auth.Lockout.IPMaxAttempts = 3
+		cfg.Server.TrustedProxies = []string{"192.0.2.1"}
+	})
+	for i := 0; i < 4; i++ {
+		if resp := forged(server, i); resp.Code != http.StatusUnauthorized {
+			t.Fatalf("forwarded client %d: got status %d (body %s)", i+1, resp.Code, resp.Body)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package routes_test
+
+import (
+	"blog/docs"
+	"blog/testutil"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestEveryRouteIsDocumented SetupRoutes 中的每个路由都必须在 docs.Endpoints 中有描述
+func TestEveryRouteIsDocumented(t *testing.T) {
+	doc := docs.Build()
+	for _, route := range testutil.Routes(t) {
+		if !doc.Has(route.Method, route.Path) {
+			t.Errorf("route %s %s has no OpenAPI entry in docs.Endpoints", route.Method, route.Path)
+		}
+	}
+}
+
+// TestNoStaleDocEntries docs.Endpoints 中不能有已经不存在的路由
+func TestNoStaleDocEntries(t *testing.T) {
+	registered := map[string]bool{}
+	for _, route := range testutil.Routes(t) {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, endpoint := range docs.Endpoints() {
+		if !registered[endpoint.Key()] {
+			t.Errorf("docs.Endpoints describes %s, which is not registered in SetupRoutes", endpoint.Key())
+		}
+	}
+}
+
+func TestDocsRoutes(t *testing.T) {
+	server := testutil.NewServer(t)
+
+	resp := server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/openapi.json"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.json: status %d", resp.Code)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(resp.Body, &doc); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	if doc["openapi"] != "3.0.3" {
+		t.Fatalf("openapi version: got %v", doc["openapi"])
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/openapi.yaml"})
+	if err := yaml.Unmarshal(resp.Body, &doc); err != nil || resp.Code != http.StatusOK {
+		t.Fatalf("GET /api/openapi.yaml: status %d, err %v", resp.Code, err)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/openapi", Headers: map[string]string{"Accept": "application/yaml"}})
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/yaml") {
+		t.Fatalf("GET /api/openapi with Accept yaml: content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/docs"})
+	if resp.Code != http.StatusOK || !strings.Contains(string(resp.Body), "/api/openapi.json") {
+		t.Fatalf("GET /api/docs: status %d", resp.Code)
+	}
+	// 页面只引用本地提供的文件
+	if body := string(resp.Body); strings.Contains(body, "://") || !strings.Contains(body, "/api/docs/assets/swagger-ui-bundle.js") {
+		t.Fatalf("GET /api/docs: page loads external assets:\n%s", body)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/docs/assets/VERSION"})
+	if resp.Code != http.StatusOK || strings.TrimSpace(string(resp.Body)) != "5.11.0" {
+		t.Fatalf("GET /api/docs/assets/VERSION: status %d, body %q", resp.Code, resp.Body)
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/docs/assets/missing.js"})
+	if resp.Code != http.StatusNotFound {
+		t.Fatalf("GET /api/docs/assets/missing.js: got status %d, want 404", resp.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestPersonalTokens 个人访问令牌只能访问拥有 scope 的接口，吊销后立即失效
+func TestPersonalTokens(t *testing.T) {
+	f := newFixture(t)
+
+	resp := f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/tokens", as: "alice", body: map[string]interface{}{
+		"name": "publish script", "scopes": []string{"posts:write"},
+	}, wantCode: http.StatusOK, golden: "auth_token_create"})
+	var created struct {
+		ID    uint   `json:"id"`
+		Token string `json:"token"`
+	}
+	resp.Decode(t, &created)
+
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/tokens", as: "alice", body: map[string]interface{}{
+		"name": "bad", "scopes": []string{"admin"},
+	}, wantCode: http.StatusBadRequest})
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/tokens", as: "alice", body: map[string]interface{}{
+		"name": "expired", "scopes": []string{"read"}, "expires_at": time.Now().Add(-time.Hour).Format(time.RFC3339),
+	}, wantCode: http.StatusBadRequest})
+
+	// posts:write 可以发文和读取个人信息，不能评论，也不能管理令牌
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/posts", token: created.Token, body: map[string]string{
+		"title": "From script", "content": "Published with a token",
+	}, wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodPut, path: "/api/posts/{post}", token: created.Token, body: map[string]string{
+		"title": "Hello again", "content": "Edited with a token",
+	}, wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", token: created.Token, wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/posts/{post}/comments", token: created.Token, body: map[string]string{
+		"content": "From script",
+	}, wantCode: http.StatusForbidden, golden: "auth_token_scope_denied"})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/tokens", token: created.Token, wantCode: http.StatusForbidden, golden: "auth_token_not_accepted"})
+
+	// 只读令牌不能写
+	resp = f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/tokens", as: "bob", body: map[string]interface{}{
+		"name": "reader", "scopes": []string{"read"}, "expires_at": time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	}, wantCode: http.StatusOK})
+	var readOnly struct {
+		ID    uint   `json:"id"`
+		Token string `json:"token"`
+	}
+	resp.Decode(t, &readOnly)
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/posts/{post}/comments/{comment}", token: readOnly.Token, wantCode: http.StatusForbidden})
+
+	// 公开的只读接口同样认证令牌：有效令牌可以访问，吊销后返回 401
+	publicReads := []string{"/api/search?q=Hello", "/api/categories", "/api/tags"}
+	for _, path := range publicReads {
+		f.run(t, routeCase{method: http.MethodGet, path: path, token: readOnly.Token, wantCode: http.StatusOK})
+	}
+	f.run(t, routeCase{method: http.MethodDelete, path: fmt.Sprintf("/api/auth/tokens/%d", readOnly.ID), as: "bob", wantCode: http.StatusOK})
+	for _, path := range publicReads {
+		f.run(t, routeCase{method: http.MethodGet, path: path, token: readOnly.Token, wantCode: http.StatusUnauthorized})
+	}
+
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/tokens", as: "alice", wantCode: http.StatusOK, golden: "auth_token_list"})
+
+	revoke := fmt.Sprintf("/api/auth/tokens/%d", created.ID)
+	f.run(t, routeCase{method: http.MethodDelete, path: revoke, as: "bob", wantCode: http.StatusNotFound})
+	f.run(t, routeCase{method: http.MethodDelete, path: revoke, as: "alice", wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/posts/{post}", token: created.Token, wantCode: http.StatusUnauthorized})
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/auth/tokens/abc", as: "alice", wantCode: http.StatusBadRequest})
+}
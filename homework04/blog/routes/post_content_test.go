@@ -0,0 +1,61 @@
+package routes_test
+
+import (
+	"blog/handlers"
+	"blog/testutil"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPostContentRoutes(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+
+	source := "# 简介\n\nHello **world** <script>alert(1)</script>\n\n## Usage\n\n[link](javascript:alert(1))"
+	post := createPostWithSlug(t, server, alice, map[string]string{"title": "Markdown", "content": source})
+	if post.ContentFormat != "markdown" {
+		t.Fatalf("default content_format: got %q", post.ContentFormat)
+	}
+
+	resp := server.Do(testutil.Request{Method: http.MethodGet, Path: fmt.Sprintf("/api/posts/%d", post.ID)})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("get post: status %d, body %s", resp.Code, resp.Body)
+	}
+	testutil.AssertGolden(t, "post_get_markdown", resp.Body)
+	var detail handlers.PostDetailResponse
+	resp.Decode(t, &detail)
+	if strings.Contains(detail.ContentHTML, "<script") || strings.Contains(detail.ContentHTML, "javascript:") {
+		t.Fatalf("content_html is not sanitized: %s", detail.ContentHTML)
+	}
+
+	// 改为纯文本后 Markdown 语法原样显示
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/posts/%d", post.ID), Token: alice.Token, Body: map[string]string{
+		"title": "Markdown", "content": "# not a heading\n<b>bold</b>", "content_format": "plain",
+	}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("update to plain: status %d, body %s", resp.Code, resp.Body)
+	}
+	var updated handlers.UpdatePostResponse
+	resp.Decode(t, &updated)
+	if want := "<p># not a heading<br>\n&lt;b&gt;bold&lt;/b&gt;</p>\n"; updated.ContentHTML != want {
+		t.Fatalf("plain content_html: got %q, want %q", updated.ContentHTML, want)
+	}
+
+	// 不传 content_format 时保持原格式
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/posts/%d", post.ID), Token: alice.Token, Body: map[string]string{
+		"title": "Markdown", "content": "*still plain*",
+	}})
+	resp.Decode(t, &updated)
+	if updated.ContentFormat != "plain" || updated.ContentHTML != "<p>*still plain*</p>\n" {
+		t.Fatalf("keep content_format: got %q, %q", updated.ContentFormat, updated.ContentHTML)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: alice.Token, Body: map[string]string{
+		"title": "x", "content": "x", "content_format": "html",
+	}})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("unknown content_format: status %d, body %s", resp.Code, resp.Body)
+	}
+}
@@ -0,0 +1,99 @@
+package routes_test
+
+import (
+	"blog/handlers"
+	"blog/testutil"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestPostRevisionRoutes(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+	bob := server.RegisterAndLogin("bob")
+	carol := server.RegisterAndLogin("carol")
+	server.SetRole(carol, "editor")
+
+	post := createPostWithSlug(t, server, alice, map[string]string{
+		"title": "Draft title", "content": "one\ntwo\nthree\n", "content_format": "plain",
+	})
+	base := fmt.Sprintf("/api/posts/%d/revisions", post.ID)
+	update := func(user *testutil.User, body map[string]string) {
+		t.Helper()
+		resp := server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/posts/%d", post.ID), Token: user.Token, Body: body})
+		if resp.Code != http.StatusOK {
+			t.Fatalf("update post: status %d, body %s", resp.Code, resp.Body)
+		}
+	}
+	update(alice, map[string]string{"title": "Final title", "content": "one\n2\nthree\nfour\n", "note": "fix numbers"})
+	update(carol, map[string]string{"title": "Final title", "content": "one\n2\nthree\nfour\nfive\n"})
+
+	resp := server.Do(testutil.Request{Method: http.MethodGet, Path: base, Token: alice.Token})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("list revisions: status %d, body %s", resp.Code, resp.Body)
+	}
+	testutil.AssertGolden(t, "post_revisions", resp.Body)
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: base + "/diff?from=1&to=2", Token: alice.Token})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("diff revisions: status %d, body %s", resp.Code, resp.Body)
+	}
+	testutil.AssertGolden(t, "post_revision_diff", resp.Body)
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: base + "/diff?from=1&to=3&by=word", Token: carol.Token})
+	var wordDiff handlers.RevisionDiffResponse
+	resp.Decode(t, &wordDiff)
+	if wordDiff.By != "word" || wordDiff.Insertions != 3 || wordDiff.Deletions != 1 {
+		t.Fatalf("word diff: got %+v", wordDiff)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		user   *testutil.User
+		want   int
+	}{
+		{"list as other user", http.MethodGet, base, bob, http.StatusForbidden},
+		{"list anonymously", http.MethodGet, base, nil, http.StatusUnauthorized},
+		{"list missing post", http.MethodGet, "/api/posts/999/revisions", alice, http.StatusNotFound},
+		{"diff missing revision", http.MethodGet, base + "/diff?from=1&to=9", alice, http.StatusNotFound},
+		{"diff without from", http.MethodGet, base + "/diff?to=2", alice, http.StatusBadRequest},
+		{"restore as other user", http.MethodPost, base + "/1/restore", bob, http.StatusForbidden},
+		{"restore missing revision", http.MethodPost, base + "/9/restore", alice, http.StatusNotFound},
+		{"restore invalid revision", http.MethodPost, base + "/x/restore", alice, http.StatusBadRequest},
+	} {
+		req := testutil.Request{Method: tc.method, Path: tc.path}
+		if tc.user != nil {
+			req.Token = tc.user.Token
+		}
+		if resp := server.Do(req); resp.Code != tc.want {
+			t.Fatalf("%s: got status %d, want %d (body %s)", tc.name, resp.Code, tc.want, resp.Body)
+		}
+	}
+
+	// 恢复第 1 个版本，作为第 4 个版本保存
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: base + "/1/restore", Token: alice.Token, Body: map[string]string{"note": "undo"}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("restore revision: status %d, body %s", resp.Code, resp.Body)
+	}
+	var restored handlers.UpdatePostResponse
+	resp.Decode(t, &restored)
+	if restored.Title != "Draft title" || restored.Content != "one\ntwo\nthree\n" || restored.Slug != "draft-title" {
+		t.Fatalf("restored post: got %+v", restored)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: base, Token: alice.Token})
+	var revisions []handlers.RevisionResponse
+	resp.Decode(t, &revisions)
+	if len(revisions) != 4 || revisions[0].Number != 4 || revisions[0].RestoredFrom == nil || *revisions[0].RestoredFrom != 1 || revisions[0].Note != "undo" {
+		t.Fatalf("revisions after restore: got %+v", revisions)
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: base + "/diff?from=1&to=4", Token: alice.Token})
+	var same handlers.RevisionDiffResponse
+	resp.Decode(t, &same)
+	if same.Insertions != 0 || same.Deletions != 0 || len(same.Content) != 1 || same.Content[0].Op != "equal" {
+		t.Fatalf("diff of restored revision: got %+v", same)
+	}
+}
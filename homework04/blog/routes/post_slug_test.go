@@ -0,0 +1,119 @@
+package routes_test
+
+import (
+	"blog/handlers"
+	"blog/testutil"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// createPostWithSlug 创建文章并返回响应中的 slug
+func createPostWithSlug(t *testing.T, server *testutil.Server, user *testutil.User, body map[string]string) handlers.CreatePostResponse {
+	t.Helper()
+	resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: user.Token, Body: body})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create post %v: status %d, body %s", body, resp.Code, resp.Body)
+	}
+	var post handlers.CreatePostResponse
+	resp.Decode(t, &post)
+	return post
+}
+
+func TestPostSlugRoutes(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+	bob := server.RegisterAndLogin("bob")
+
+	first := createPostWithSlug(t, server, alice, map[string]string{"title": "Go 语言入门", "content": "hello"})
+	if first.Slug != "go-yu-yan-ru-men" {
+		t.Fatalf("slug of chinese title: got %q", first.Slug)
+	}
+	second := createPostWithSlug(t, server, alice, map[string]string{"title": "Go 语言入门!", "content": "again"})
+	if second.Slug != "go-yu-yan-ru-men-2" {
+		t.Fatalf("slug of duplicate title: got %q", second.Slug)
+	}
+	custom := createPostWithSlug(t, server, bob, map[string]string{"title": "Anything", "slug": "My Custom Slug", "content": "x"})
+	if custom.Slug != "my-custom-slug" {
+		t.Fatalf("custom slug: got %q", custom.Slug)
+	}
+
+	for _, tc := range []struct {
+		name string
+		slug string
+		want string
+	}{
+		{"slug taken", "go-yu-yan-ru-men", "Slug already in use"},
+		{"slug without letters", "---", "Slug must contain letters or digits"},
+	} {
+		resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: bob.Token, Body: map[string]string{
+			"title": "Other", "slug": tc.slug, "content": "x",
+		}})
+		if resp.Code != http.StatusBadRequest || resp.Envelope(t).Message != tc.want {
+			t.Fatalf("%s: status %d, body %s", tc.name, resp.Code, resp.Body)
+		}
+	}
+
+	resp := server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/posts/by-slug/go-yu-yan-ru-men"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("get by slug: status %d, body %s", resp.Code, resp.Body)
+	}
+	testutil.AssertGolden(t, "post_get_by_slug", resp.Body)
+
+	// 改标题后旧 slug 重定向到新 slug
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/posts/%d", first.ID), Token: alice.Token, Body: map[string]string{
+		"title": "Go 并发编程", "content": "hello",
+	}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("rename post: status %d, body %s", resp.Code, resp.Body)
+	}
+	var updated handlers.UpdatePostResponse
+	resp.Decode(t, &updated)
+	if updated.Slug != "go-bing-fa-bian-cheng" {
+		t.Fatalf("slug after rename: got %q", updated.Slug)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/posts/by-slug/go-yu-yan-ru-men"})
+	if resp.Code != http.StatusMovedPermanently {
+		t.Fatalf("old slug: status %d, body %s", resp.Code, resp.Body)
+	}
+	if got := resp.Header.Get("Location"); got != "/api/posts/by-slug/go-bing-fa-bian-cheng" {
+		t.Fatalf("old slug Location: got %q", got)
+	}
+	testutil.AssertGolden(t, "post_slug_redirect", resp.Body)
+
+	// 旧 slug 仍属于原来的文章，其他文章不能使用
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/posts/%d", custom.ID), Token: bob.Token, Body: map[string]string{
+		"title": "Anything", "slug": "go-yu-yan-ru-men", "content": "x",
+	}})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("reuse old slug: status %d, body %s", resp.Code, resp.Body)
+	}
+
+	// 改回原来的 slug 后不再重定向
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/posts/%d", first.ID), Token: alice.Token, Body: map[string]string{
+		"title": "Go 并发编程", "slug": "go-yu-yan-ru-men", "content": "hello",
+	}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("restore old slug: status %d, body %s", resp.Code, resp.Body)
+	}
+	for path, want := range map[string]int{
+		"/api/posts/by-slug/go-yu-yan-ru-men":      http.StatusOK,
+		"/api/posts/by-slug/go-bing-fa-bian-cheng": http.StatusMovedPermanently,
+		"/api/posts/by-slug/missing":               http.StatusNotFound,
+	} {
+		if resp := server.Do(testutil.Request{Method: http.MethodGet, Path: path}); resp.Code != want {
+			t.Fatalf("GET %s: got status %d, want %d", path, resp.Code, want)
+		}
+	}
+
+	// 草稿按 slug 也只有作者可见
+	draft := createPostWithSlug(t, server, alice, map[string]string{"title": "Secret plan", "content": "x", "status": "draft"})
+	draftPath := "/api/posts/by-slug/" + draft.Slug
+	if resp := server.Do(testutil.Request{Method: http.MethodGet, Path: draftPath, Token: bob.Token}); resp.Code != http.StatusNotFound {
+		t.Fatalf("draft as bob: status %d", resp.Code)
+	}
+	if resp := server.Do(testutil.Request{Method: http.MethodGet, Path: draftPath, Token: alice.Token}); resp.Code != http.StatusOK {
+		t.Fatalf("draft as author: status %d", resp.Code)
+	}
+}
@@ -0,0 +1,170 @@
+package routes_test
+
+import (
+	"blog/handlers"
+	"blog/repository"
+	"blog/search"
+	"blog/services"
+	"blog/testutil"
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// visibleTitles 以 user 身份（nil 表示匿名）获取文章列表的标题
+func visibleTitles(t *testing.T, server *testutil.Server, user *testutil.User, path string) []string {
+	t.Helper()
+	req := testutil.Request{Method: http.MethodGet, Path: path}
+	if user != nil {
+		req.Token = user.Token
+	}
+	resp := server.Do(req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("GET %s: got status %d (body %s)", path, resp.Code, resp.Body)
+	}
+	var posts []handlers.PostResponse
+	resp.Decode(t, &posts)
+	titles := make([]string, 0, len(posts))
+	for _, post := range posts {
+		titles = append(titles, post.Title)
+	}
+	return titles
+}
+
+// newInstancePostService 模拟共用同一数据库的另一个服务实例
+func newInstancePostService(server *testutil.Server) *services.PostService {
+	posts := repository.NewGormPostRepository(server.DB)
+	comments := repository.NewGormCommentRepository(server.DB)
+	searchService := services.NewSearchService(search.NewMemoryIndex(), repository.NewGormUserRepository(server.DB), posts, comments, zap.NewNop())
+	return services.NewPostService(posts, comments, repository.NewGormTaxonomyRepository(server.DB), searchService)
+}
+
+func TestPostStatusRoutes(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+	bob := server.RegisterAndLogin("bob")
+
+	server.CreatePost(alice, "Public", "hello")
+	resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: alice.Token, Body: map[string]string{
+		"title": "Draft", "content": "work in progress", "status": "draft",
+	}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create draft: status %d, body %s", resp.Code, resp.Body)
+	}
+	testutil.AssertGolden(t, "post_create_draft", resp.Body)
+	var draft handlers.CreatePostResponse
+	resp.Decode(t, &draft)
+
+	if got, want := visibleTitles(t, server, nil, "/api/posts"), []string{"Public"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("anonymous list: got %v, want %v", got, want)
+	}
+	if got, want := visibleTitles(t, server, bob, "/api/posts"), []string{"Public"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("list as bob: got %v, want %v", got, want)
+	}
+	if got, want := visibleTitles(t, server, alice, "/api/posts?status=draft"), []string{"Draft"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("alice's drafts: got %v, want %v", got, want)
+	}
+
+	draftPath := fmt.Sprintf("/api/posts/%d", draft.ID)
+	cases := []struct {
+		name string
+		path string
+		user *testutil.User
+		want int
+	}{
+		{"anonymous get", draftPath, nil, http.StatusNotFound},
+		{"other user get", draftPath, bob, http.StatusNotFound},
+		{"author get", draftPath, alice, http.StatusOK},
+		{"other user comments", draftPath + "/comments", bob, http.StatusNotFound},
+		{"author comments", draftPath + "/comments", alice, http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := testutil.Request{Method: http.MethodGet, Path: tc.path}
+		if tc.user != nil {
+			req.Token = tc.user.Token
+		}
+		if resp := server.Do(req); resp.Code != tc.want {
+			t.Errorf("%s: got status %d, want %d (body %s)", tc.name, resp.Code, tc.want, resp.Body)
+		}
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: draftPath, Token: "invalid"})
+	if resp.Code != http.StatusUnauthorized {
+		t.Fatalf("get with invalid token: got status %d, want 401", resp.Code)
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: draftPath + "/comments", Token: bob.Token, Body: map[string]string{"content": "hi"}})
+	if resp.Code != http.StatusNotFound {
+		t.Fatalf("comment on draft: got status %d, want 404", resp.Code)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: draftPath, Token: alice.Token, Body: map[string]interface{}{
+		"title": "Draft", "content": "done", "status": "scheduled", "published_at": time.Now().Add(-time.Minute),
+	}})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("schedule in the past: got status %d, want 400", resp.Code)
+	}
+	at := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: draftPath, Token: alice.Token, Body: map[string]interface{}{
+		"title": "Scheduled", "content": "done", "status": "scheduled", "published_at": at,
+	}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("schedule draft: status %d, body %s", resp.Code, resp.Body)
+	}
+	testutil.AssertGolden(t, "post_schedule", resp.Body)
+
+	// 两个实例先后执行定时发布，文章只被发布一次
+	ctx := context.Background()
+	first, second := newInstancePostService(server), newInstancePostService(server)
+	if published, err := first.PublishDue(ctx, time.Now()); err != nil || published != 0 {
+		t.Fatalf("publish before due: got %d, %v", published, err)
+	}
+	if published, err := first.PublishDue(ctx, at); err != nil || published != 1 {
+		t.Fatalf("publish when due: got %d, %v", published, err)
+	}
+	if published, err := second.PublishDue(ctx, at); err != nil || published != 0 {
+		t.Fatalf("publish on second instance: got %d, %v", published, err)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: draftPath})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("get published post: status %d, body %s", resp.Code, resp.Body)
+	}
+	var post handlers.PostDetailResponse
+	resp.Decode(t, &post)
+	if post.Status != "published" || post.PublishedAt == nil || !post.PublishedAt.Equal(at) {
+		t.Fatalf("published post: got %s at %v, want published at %v", post.Status, post.PublishedAt, at)
+	}
+	if got, want := visibleTitles(t, server, bob, "/api/posts"), []string{"Scheduled", "Public"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("list after publishing: got %v, want %v", got, want)
+	}
+}
+
+// TestPostScheduleOutsideUTC 服务器时区不是 UTC、客户端按 UTC 提交发布时间时，定时文章仍在到期后才发布
+func TestPostScheduleOutsideUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+	at := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: alice.Token, Body: map[string]interface{}{
+		"title": "Later", "content": "soon", "status": "scheduled", "published_at": at,
+	}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("schedule post: status %d, body %s", resp.Code, resp.Body)
+	}
+
+	ctx := context.Background()
+	posts := newInstancePostService(server)
+	if published, err := posts.PublishDue(ctx, time.Now()); err != nil || published != 0 {
+		t.Fatalf("publish before due: got %d, %v", published, err)
+	}
+	if published, err := posts.PublishDue(ctx, at.In(time.Local)); err != nil || published != 1 {
+		t.Fatalf("publish when due: got %d, %v", published, err)
+	}
+}
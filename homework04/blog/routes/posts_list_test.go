@@ -0,0 +1,169 @@
+package routes_test
+
+import (
+	"blog/handlers"
+	"blog/models"
+	"blog/testutil"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// listFixture alice 依次发表 post 1..5，bob 发表一篇 "Bob on Go"
+func listFixture(t *testing.T) *testutil.Server {
+	t.Helper()
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+	bob := server.RegisterAndLogin("bob")
+	for i := 1; i <= 5; i++ {
+		server.CreatePost(alice, fmt.Sprintf("post %d", i), fmt.Sprintf("content of post %d", i))
+	}
+	server.CreatePost(bob, "Bob on Go", "Learning 100% of Go")
+	return server
+}
+
+func listTitles(t *testing.T, server *testutil.Server, path string) ([]string, testutil.Envelope) {
+	t.Helper()
+	resp := server.Do(testutil.Request{Method: http.MethodGet, Path: path})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("GET %s: got status %d (body %s)", path, resp.Code, resp.Body)
+	}
+	var posts []handlers.PostResponse
+	resp.Decode(t, &posts)
+	titles := make([]string, 0, len(posts))
+	for _, post := range posts {
+		titles = append(titles, post.Title)
+	}
+	return titles, resp.Envelope(t)
+}
+
+func TestPostListPagination(t *testing.T) {
+	server := listFixture(t)
+
+	titles, env := listTitles(t, server, "/api/posts?page=2&page_size=2")
+	if want := []string{"post 4", "post 3"}; !reflect.DeepEqual(titles, want) {
+		t.Fatalf("page 2: got %v, want %v", titles, want)
+	}
+	if env.Meta == nil || env.Meta.Total != 6 || env.Meta.Page != 2 {
+		t.Fatalf("page 2: unexpected meta %+v", env.Meta)
+	}
+	if env.Meta.Links.Next != "/api/posts?page=3&page_size=2" || env.Meta.Links.Prev != "/api/posts?page=1&page_size=2" {
+		t.Fatalf("page 2: unexpected links %+v", env.Meta.Links)
+	}
+
+	// 游标翻页：从第一页一直向后，再从最后一页向前，结果应与整体排序一致
+	var forward []string
+	path := "/api/posts?sort=title&order=asc&page_size=4"
+	var last testutil.Envelope
+	for path != "" {
+		titles, env := listTitles(t, server, path)
+		forward = append(forward, titles...)
+		path, last = env.Meta.Links.Next, env
+	}
+	want := []string{"Bob on Go", "post 1", "post 2", "post 3", "post 4", "post 5"}
+	if !reflect.DeepEqual(forward, want) {
+		t.Fatalf("forward walk: got %v, want %v", forward, want)
+	}
+
+	titles, _ = listTitles(t, server, last.Meta.Links.Prev)
+	if want := []string{"Bob on Go", "post 1", "post 2", "post 3"}; !reflect.DeepEqual(titles, want) {
+		t.Fatalf("backward page: got %v, want %v", titles, want)
+	}
+}
+
+func TestPostListFilters(t *testing.T) {
+	server := listFixture(t)
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"author=bob", []string{"Bob on Go"}},
+		{"q=POST+2", []string{"post 2"}},
+		{"q=" + url.QueryEscape("100%"), []string{"Bob on Go"}},
+		{"q=_", nil},
+		{"from=2000-01-01&to=2000-01-02", nil},
+		{"from=2000-01-01&author=alice&sort=updated_at&order=asc&page_size=2", []string{"post 1", "post 2"}},
+	}
+	for _, tc := range cases {
+		titles, _ := listTitles(t, server, "/api/posts?"+tc.query)
+		if len(titles) == 0 && len(tc.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(titles, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.query, titles, tc.want)
+		}
+	}
+}
+
+func TestPostListInvalidQuery(t *testing.T) {
+	server := testutil.NewServer(t)
+	for _, query := range []string{
+		"page=-1",
+		"page_size=101",
+		"sort=author",
+		"order=up",
+		"from=yesterday",
+		"cursor=not-a-cursor",
+		"page=1&cursor=abc",
+	} {
+		resp := server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/posts?" + query})
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400 (body %s)", query, resp.Code, resp.Body)
+		}
+	}
+}
+
+// TestPostListCursorWalk 服务器时区不是 UTC 时，按 created_at 游标向后、向前翻页，排序值相同的文章按 ID 区分
+func TestPostListCursorWalk(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	server := listFixture(t)
+	// post 2、3、4 的创建时间相同，按 ID 倒序排列
+	var tie models.Post
+	if err := server.DB.Where("title = ?", "post 3").First(&tie).Error; err != nil {
+		t.Fatalf("load post 3: %v", err)
+	}
+	if err := server.DB.Model(&models.Post{}).Where("title IN ?", []string{"post 2", "post 4"}).
+		Update("created_at", tie.CreatedAt).Error; err != nil {
+		t.Fatalf("set created_at: %v", err)
+	}
+
+	cursorPath := func(cursor string) string {
+		if cursor == "" {
+			return ""
+		}
+		return "/api/posts?page_size=2&cursor=" + cursor
+	}
+
+	// 按游标向后翻到最后一页
+	var forward []string
+	var sizes []int
+	var last testutil.Envelope
+	for path := "/api/posts?page_size=2"; path != ""; path = cursorPath(last.Meta.NextCursor) {
+		var titles []string
+		titles, last = listTitles(t, server, path)
+		forward = append(forward, titles...)
+		sizes = append(sizes, len(titles))
+	}
+	want := []string{"Bob on Go", "post 5", "post 4", "post 3", "post 2", "post 1"}
+	if !reflect.DeepEqual(forward, want) || !reflect.DeepEqual(sizes, []int{2, 2, 2}) {
+		t.Fatalf("forward walk: got %v (pages %v), want %v", forward, sizes, want)
+	}
+
+	// 再从最后一页向前翻回第一页，页内顺序不变
+	var backward []string
+	for path := cursorPath(last.Meta.PrevCursor); path != ""; path = cursorPath(last.Meta.PrevCursor) {
+		var titles []string
+		titles, last = listTitles(t, server, path)
+		backward = append(titles, backward...)
+	}
+	if want := want[:4]; !reflect.DeepEqual(backward, want) {
+		t.Fatalf("backward walk: got %v, want %v", backward, want)
+	}
+}
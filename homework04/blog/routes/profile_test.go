@@ -0,0 +1,153 @@
+package routes_test
+
+import (
+	"blog/config"
+	"blog/testutil"
+	"net/http"
+	"testing"
+)
+
+// TestUpdateProfile 修改公开资料；修改邮箱需要当前密码，之后需要重新验证并向新邮箱发送验证邮件
+func TestUpdateProfile(t *testing.T) {
+	f := newFixture(t)
+	alice := f.users["alice"]
+
+	f.run(t, routeCase{method: http.MethodPut, path: "/api/auth/profile", as: "alice", body: map[string]string{
+		"email": alice.Email, "display_name": "Alice", "bio": "Writes about Go", "avatar_url": "https://cdn.example.com/alice.png",
+	}, wantCode: http.StatusOK, golden: "auth_profile_update"})
+	f.run(t, routeCase{method: http.MethodPut, path: "/api/auth/profile", as: "alice", body: map[string]string{
+		"email": alice.Email, "avatar_url": "javascript:alert(1)",
+	}, wantCode: http.StatusBadRequest})
+	f.run(t, routeCase{method: http.MethodPut, path: "/api/auth/profile", as: "alice", body: map[string]string{
+		"email": "alice@example.org",
+	}, wantCode: http.StatusBadRequest, golden: "auth_profile_email_password"})
+	f.run(t, routeCase{method: http.MethodPut, path: "/api/auth/profile", as: "alice", body: map[string]string{
+		"email": "bob@example.com", "password": alice.Password,
+	}, wantCode: http.StatusBadRequest})
+
+	resp := f.run(t, routeCase{method: http.MethodPut, path: "/api/auth/profile", as: "alice", body: map[string]string{
+		"email": "alice@example.org", "display_name": "Alice", "password": alice.Password,
+	}, wantCode: http.StatusOK})
+	var profile struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Bio           string `json:"bio"`
+	}
+	resp.Decode(t, &profile)
+	if profile.Email != "alice@example.org" || profile.EmailVerified || profile.Bio != "" {
+		t.Fatalf("profile after changing email: %+v", profile)
+	}
+	if mails := f.server.Mails("alice@example.org"); len(mails) != 1 {
+		t.Fatalf("verification mails to new email: got %d, want 1", len(mails))
+	}
+
+	// 文章中的作者信息带上显示名称
+	var post struct {
+		User struct {
+			DisplayName string `json:"display_name"`
+		} `json:"user"`
+	}
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/posts/{post}", wantCode: http.StatusOK}).Decode(t, &post)
+	if post.User.DisplayName != "Alice" {
+		t.Fatalf("post author display name: %q", post.User.DisplayName)
+	}
+}
+
+// TestChangePassword 修改密码需要当前密码，当前会话保留，其他会话退出
+func TestChangePassword(t *testing.T) {
+	f := newFixture(t)
+	alice := f.users["alice"]
+	other := *alice
+	f.server.Login(&other)
+
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/password", as: "alice", body: map[string]string{
+		"current_password": "wrong-password", "new_password": "newpassword",
+	}, wantCode: http.StatusBadRequest, golden: "auth_password_incorrect"})
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/password", as: "alice", body: map[string]string{
+		"current_password": alice.Password, "new_password": "short",
+	}, wantCode: http.StatusBadRequest})
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/password", as: "alice", body: map[string]string{
+		"current_password": alice.Password, "new_password": "newpassword",
+	}, wantCode: http.StatusOK, golden: "auth_password_change"})
+
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", as: "alice", wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", token: other.Token, wantCode: http.StatusUnauthorized})
+	if resp := login(f.server, "alice", alice.Password); resp.Code != http.StatusUnauthorized {
+		t.Fatalf("login with old password: got status %d", resp.Code)
+	}
+	if resp := login(f.server, "alice", "newpassword"); resp.Code != http.StatusOK {
+		t.Fatalf("login with new password: got status %d", resp.Code)
+	}
+}
+
+// TestDeleteAccount 注销账号后令牌失效，用户名可以重新注册；默认保留文章和评论并隐藏作者
+func TestDeleteAccount(t *testing.T) {
+	for _, policy := range []string{config.DeletionAnonymize, config.DeletionDelete} {
+		server := testutil.NewServer(t, func(cfg *config.Config) {
+			cfg.Auth.AccountDeletion.Posts = policy
+			cfg.Auth.AccountDeletion.Comments = policy
+		})
+		alice := server.RegisterAndLogin("alice")
+		bob := server.RegisterAndLogin("bob")
+		postID := server.CreatePost(alice, "Hello", "First post")
+		server.CreateComment(bob, postID, "Nice post")
+		server.CreateComment(alice, postID, "Thanks")
+		f := &fixture{server: server, users: map[string]*testutil.User{"alice": alice, "bob": bob}, postID: postID}
+
+		f.run(t, routeCase{method: http.MethodDelete, path: "/api/auth/account", as: "alice", body: map[string]string{
+			"password": "wrong-password",
+		}, wantCode: http.StatusBadRequest})
+		f.run(t, routeCase{method: http.MethodDelete, path: "/api/auth/account", as: "alice", body: map[string]string{
+			"password": alice.Password,
+		}, wantCode: http.StatusOK, golden: "auth_account_delete"})
+		f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", as: "alice", wantCode: http.StatusUnauthorized})
+		if resp := login(server, "alice", alice.Password); resp.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: login after deletion: got status %d", policy, resp.Code)
+		}
+
+		if policy == config.DeletionAnonymize {
+			f.run(t, routeCase{method: http.MethodGet, path: "/api/posts/{post}", wantCode: http.StatusOK, golden: "post_author_deleted"})
+		} else {
+			f.run(t, routeCase{method: http.MethodGet, path: "/api/posts/{post}", wantCode: http.StatusNotFound})
+		}
+		server.Register("alice")
+	}
+}
+
+// TestDeleteAccountRollsBack 注销账号中途失败时所有修改一起回滚，账号、文章和登录会话保持原样
+func TestDeleteAccountRollsBack(t *testing.T) {
+	server := testutil.NewServer(t, func(cfg *config.Config) {
+		cfg.Auth.AccountDeletion.Posts = config.DeletionDelete
+		cfg.Auth.AccountDeletion.Comments = config.DeletionDelete
+	})
+	alice := server.RegisterAndLogin("alice")
+	postID := server.CreatePost(alice, "Hello", "First post")
+	server.CreateComment(alice, postID, "Thanks")
+	f := &fixture{server: server, users: map[string]*testutil.User{"alice": alice}, postID: postID}
+
+	// 吊销刷新令牌是事务中的最后一步
+	if err := server.DB.Exec("DROP TABLE refresh_tokens").Error; err != nil {
+		t.Fatalf("drop refresh_tokens: %v", err)
+	}
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/auth/account", as: "alice", body: map[string]string{
+		"password": alice.Password,
+	}, wantCode: http.StatusInternalServerError})
+
+	resp := f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", as: "alice", wantCode: http.StatusOK})
+	var profile struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	}
+	resp.Decode(t, &profile)
+	if profile.Username != "alice" || profile.Email != alice.Email {
+		t.Fatalf("profile after failed deletion: %+v", profile)
+	}
+	resp = f.run(t, routeCase{method: http.MethodGet, path: "/api/posts/{post}/comments", wantCode: http.StatusOK})
+	var comments []struct {
+		ID uint `json:"id"`
+	}
+	resp.Decode(t, &comments)
+	if len(comments) != 1 {
+		t.Fatalf("comments after failed deletion: %+v", comments)
+	}
+}
@@ -1,50 +1,258 @@
 package routes
 
 import (
+	"blog/config"
+	"blog/docs"
 	"blog/handlers"
+	"blog/mailer"
 	"blog/middleware"
+	"blog/rbac"
+	"blog/repository"
+	"blog/search"
+	"blog/services"
+	"blog/utils"
+	"blog/workers"
+	"context"
+	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(r *gin.Engine, db *gorm.DB) {
+const (
+	// tokenCleanupInterval 清理过期令牌的间隔
+	tokenCleanupInterval = time.Hour
+	// userCacheTTL 认证中间件缓存用户的时间，其他实例上的角色变更、禁用最多延迟这么久生效
+	userCacheTTL = 30 * time.Second
+	// keyCheckInterval 检查签名密钥是否需要轮换、加载其他实例轮换出的密钥的间隔
+	keyCheckInterval = time.Minute
+	// keyLoadTimeout 启动时加载签名密钥的超时时间
+	keyLoadTimeout = 30 * time.Second
+	// loginAttemptCleanupInterval 清理过期登录失败计数的间隔
+	loginAttemptCleanupInterval = 10 * time.Minute
+	// rolePolicyCacheTTL 角色安全策略的缓存时间，其他实例上的修改最多延迟这么久生效
+	rolePolicyCacheTTL = 30 * time.Second
+	// postPublishInterval 检查到期定时文章的间隔，文章最多比计划时间晚这么久发布
+	postPublishInterval = 30 * time.Second
+	// searchRetryInterval 重试失败的索引更新的间隔
+	searchRetryInterval = time.Minute
+)
+
+// SetupRoutes 创建服务并注册所有路由，后台任务注册到 workerManager；
+// 签名密钥、邮件等依赖初始化失败时返回错误
+func SetupRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, workerManager *workers.Manager) error {
+	// gin 默认信任所有代理，客户端可以用 X-Forwarded-For 伪造 ClientIP，绕过按 IP 的登录限制
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("failed to set trusted proxies: %w", err)
+	}
+
+	jwtManager := utils.NewJWTManager(cfg.JWT)
+
+	// 初始化仓储和服务
+	userRepo := repository.NewGormUserRepository(db)
+	postRepo := repository.NewGormPostRepository(db)
+	commentRepo := repository.NewGormCommentRepository(db)
+	taxonomyRepo := repository.NewGormTaxonomyRepository(db)
+	tokenRepo := repository.NewGormTokenRepository(db)
+	keyRepo := repository.NewGormKeyRepository(db)
+	twoFactorRepo := repository.NewGormTwoFactorRepository(db)
+	policyRepo := repository.NewGormPolicyRepository(db)
+	loginAttemptRepo := repository.NewGormLoginAttemptRepository(db)
+	personalTokenRepo := repository.NewGormPersonalTokenRepository(db)
+	if cfg.Auth.Lockout.Store == config.LockoutStoreMemory {
+		loginAttemptRepo = repository.NewMemoryStore().LoginAttempts()
+	}
+
+	var searchIndex search.Index = search.NewMemoryIndex()
+	if cfg.SearchEngine() == config.SearchEngineMySQL {
+		searchIndex = search.NewMySQLIndex(db)
+	}
+
+	// 退役密钥至少保留到它签发的最后一个访问令牌过期，再加上其他实例加载新密钥前可能继续用它签名的时间
+	keyService, err := services.NewKeyService(keyRepo, jwtManager, cfg.JWT, cfg.JWT.Expiration.Std()+keyCheckInterval)
+	if err != nil {
+		return fmt.Errorf("failed to create key service: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), keyLoadTimeout)
+	err = keyService.Ensure(ctx)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	// TOTP 密钥与签名私钥一样用 jwt.secret 派生的密钥加密保存
+	secretBox, err := utils.NewSecretBox(cfg.JWT.Secret)
+	if err != nil {
+		return fmt.Errorf("failed to create secret box: %w", err)
+	}
+	actionSigner := utils.NewActionTokenSigner(cfg.JWT.Secret)
+	userCache := services.NewUserCache(userRepo, userCacheTTL)
+	lockout := cfg.Auth.Lockout
+	loginGuard := services.NewLoginGuard(loginAttemptRepo, userRepo, services.LoginGuardConfig{
+		MaxAttempts:     lockout.MaxAttempts,
+		IPMaxAttempts:   lockout.IPMaxAttempts,
+		FreeAttempts:    lockout.FreeAttempts,
+		BaseDelay:       lockout.BaseDelay.Std(),
+		MaxDelay:        lockout.MaxDelay.Std(),
+		Window:          lockout.Window.Std(),
+		LockoutDuration: lockout.Duration.Std(),
+	})
+
+	tokenService := services.NewTokenService(userRepo, tokenRepo, jwtManager, cfg.JWT.RefreshExpiration.Std())
+	twoFactorService := services.NewTwoFactorService(userRepo, twoFactorRepo, policyRepo, tokenService, actionSigner, secretBox, userCache, loginGuard, services.TwoFactorConfig{
+		Issuer:         cfg.Auth.TOTPIssuer,
+		ChallengeTTL:   cfg.Auth.TwoFactorChallengeExpiration.Std(),
+		PolicyCacheTTL: rolePolicyCacheTTL,
+	})
+	authService := services.NewAuthService(userRepo, tokenService, twoFactorService, loginGuard, cfg.Auth.BcryptCost)
+	mail, err := mailer.New(cfg.Mail, workerManager.Logger())
+	if err != nil {
+		return fmt.Errorf("failed to create mailer: %w", err)
+	}
+	personalTokenService := services.NewPersonalTokenService(personalTokenRepo)
+	searchService := services.NewSearchService(searchIndex, userRepo, postRepo, commentRepo, workerManager.Logger())
+	accountService := services.NewAccountService(userRepo, postRepo, commentRepo, tokenService, personalTokenService, searchService, userCache, actionSigner, mail, services.AccountConfig{
+		BaseURL:          cfg.Mail.BaseURL,
+		VerificationTTL:  cfg.Auth.VerificationExpiration.Std(),
+		PasswordResetTTL: cfg.Auth.PasswordResetExpiration.Std(),
+		BcryptCost:       cfg.Auth.BcryptCost,
+		DeletePosts:      cfg.Auth.AccountDeletion.Posts == config.DeletionDelete,
+		DeleteComments:   cfg.Auth.AccountDeletion.Comments == config.DeletionDelete,
+	})
+	postService := services.NewPostService(postRepo, commentRepo, taxonomyRepo, searchService)
+	taxonomyService := services.NewTaxonomyService(taxonomyRepo)
+	commentService := services.NewCommentService(postRepo, commentRepo, searchService)
+	userService := services.NewUserService(userRepo, userCache)
+
+	authMiddleware := middleware.AuthMiddleware(jwtManager, tokenService, userCache, twoFactorService, personalTokenService)
+	// 角色要求两步验证但尚未启用的用户只能访问以下接口，用于完成启用
+	setupAuthMiddleware := []gin.HandlerFunc{middleware.AllowTwoFactorSetup, authMiddleware}
+	// 公开接口可选登录，登录用户可以看到自己未发布的文章
+	optionalAuthMiddleware := middleware.OptionalAuth(authMiddleware)
+	// 个人访问令牌只能访问以下标记了 scope 的接口
+	readScope := middleware.AcceptPersonalToken(rbac.ScopeRead)
+	postsWriteScope := middleware.AcceptPersonalToken(rbac.ScopePostsWrite)
+	commentsWriteScope := middleware.AcceptPersonalToken(rbac.ScopeCommentsWrite)
+	workerManager.Register(tokenService.CleanupWorker(tokenCleanupInterval, workerManager.Logger()))
+	workerManager.Register(loginGuard.CleanupWorker(loginAttemptCleanupInterval, workerManager.Logger()))
+	workerManager.Register(postService.PublishWorker(postPublishInterval, workerManager.Logger()))
+	workerManager.Register(searchService.RetryWorker(searchRetryInterval, workerManager.Logger()))
+	if keyService.Enabled() {
+		workerManager.Register(keyService.RotationWorker(keyCheckInterval, workerManager.Logger()))
+	}
+
+	// 内存索引在启动时从数据库重建
+	if cfg.SearchEngine() == config.SearchEngineMemory {
+		workerManager.Register(searchService.RebuildWorker())
+	}
+
 	// 初始化处理器
-	authHandler := handlers.NewAuthHandler(db)
-	postHandler := handlers.NewPostHandler(db)
-	commentHandler := handlers.NewCommentHandler(db)
+	authHandler := handlers.NewAuthHandler(authService, tokenService, accountService)
+	postHandler := handlers.NewPostHandler(postService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	categoryHandler := handlers.NewCategoryHandler(taxonomyService)
+	tagHandler := handlers.NewTagHandler(taxonomyService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	personalTokenHandler := handlers.NewPersonalTokenHandler(personalTokenService)
+	sessionHandler := handlers.NewSessionHandler(tokenService)
+	adminHandler := handlers.NewAdminHandler(userService, twoFactorService, loginGuard)
+	healthHandler := handlers.NewHealthHandler(db, workerManager)
+	jwksHandler := handlers.NewJWKSHandler(jwtManager)
 
 	// 认证路由
 	auth := r.Group("/api/auth")
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
-		auth.GET("/profile", middleware.AuthMiddleware(), authHandler.GetProfile)
+		auth.POST("/login/2fa", twoFactorHandler.Login)
+		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/logout", append(setupAuthMiddleware, authHandler.Logout)...)
+		auth.GET("/profile", readScope, middleware.AllowTwoFactorSetup, authMiddleware, authHandler.GetProfile)
+		auth.PUT("/profile", authMiddleware, authHandler.UpdateProfile)
+		auth.POST("/password", authMiddleware, authHandler.ChangePassword)
+		auth.DELETE("/account", authMiddleware, authHandler.DeleteAccount)
+		auth.POST("/verify", authHandler.VerifyEmail)
+		auth.POST("/verify/resend", authMiddleware, authHandler.ResendVerification)
+		auth.POST("/forgot-password", authHandler.ForgotPassword)
+		auth.POST("/reset-password", authHandler.ResetPassword)
+		auth.POST("/2fa/setup", append(setupAuthMiddleware, twoFactorHandler.Setup)...)
+		auth.POST("/2fa/confirm", append(setupAuthMiddleware, twoFactorHandler.Confirm)...)
+		auth.POST("/2fa/recovery-codes", authMiddleware, twoFactorHandler.RegenerateRecoveryCodes)
+		auth.DELETE("/2fa", authMiddleware, twoFactorHandler.Disable)
+		auth.GET("/tokens", authMiddleware, personalTokenHandler.List)
+		auth.POST("/tokens", authMiddleware, personalTokenHandler.Create)
+		auth.DELETE("/tokens/:id", authMiddleware, personalTokenHandler.Revoke)
+		auth.GET("/sessions", authMiddleware, sessionHandler.List)
+		auth.DELETE("/sessions", authMiddleware, sessionHandler.RevokeAll)
+		auth.DELETE("/sessions/:id", authMiddleware, sessionHandler.Revoke)
 	}
 
+	r.GET("/.well-known/jwks.json", jwksHandler.JWKS)
+
 	// 文章路由
 	posts := r.Group("/api/posts")
 	{
-		posts.GET("", postHandler.GetPosts)
-		posts.GET("/:id", postHandler.GetPost)
-		posts.POST("", middleware.AuthMiddleware(), postHandler.CreatePost)
-		posts.PUT("/:id", middleware.AuthMiddleware(), postHandler.UpdatePost)
-		posts.DELETE("/:id", middleware.AuthMiddleware(), postHandler.DeletePost)
+		posts.GET("", readScope, optionalAuthMiddleware, postHandler.GetPosts)
+		posts.GET("/:id", readScope, optionalAuthMiddleware, postHandler.GetPost)
+		posts.GET("/by-slug/:slug", readScope, optionalAuthMiddleware, postHandler.GetPostBySlug)
+		posts.POST("", postsWriteScope, authMiddleware, middleware.RequirePermission(rbac.PostCreate), postHandler.CreatePost)
+		posts.PUT("/:id", postsWriteScope, authMiddleware, postHandler.UpdatePost)
+		posts.DELETE("/:id", postsWriteScope, authMiddleware, postHandler.DeletePost)
+		posts.GET("/:id/revisions", readScope, authMiddleware, postHandler.ListRevisions)
+		posts.GET("/:id/revisions/diff", readScope, authMiddleware, postHandler.DiffRevisions)
+		posts.POST("/:id/revisions/:revision/restore", postsWriteScope, authMiddleware, postHandler.RestoreRevision)
 	}
 
 	// 评论路由
 	comments := r.Group("/api/posts/:id/comments")
 	{
-		comments.GET("", commentHandler.GetComments)
-		comments.POST("", middleware.AuthMiddleware(), commentHandler.CreateComment)
-		comments.DELETE("/:commentId", middleware.AuthMiddleware(), commentHandler.DeleteComment)
+		comments.GET("", readScope, optionalAuthMiddleware, commentHandler.GetComments)
+		comments.POST("", commentsWriteScope, authMiddleware, middleware.RequirePermission(rbac.CommentCreate), commentHandler.CreateComment)
+		comments.DELETE("/:commentId", commentsWriteScope, authMiddleware, commentHandler.DeleteComment)
 	}
 
+	// 分类和标签
+	categories := r.Group("/api/categories")
+	{
+		categories.GET("", readScope, optionalAuthMiddleware, categoryHandler.List)
+		categories.POST("", authMiddleware, middleware.RequirePermission(rbac.CategoryManage), categoryHandler.Create)
+		categories.PUT("/:id", authMiddleware, middleware.RequirePermission(rbac.CategoryManage), categoryHandler.Update)
+		categories.DELETE("/:id", authMiddleware, middleware.RequirePermission(rbac.CategoryManage), categoryHandler.Delete)
+	}
+	r.GET("/api/tags", readScope, optionalAuthMiddleware, tagHandler.List)
+
+	// 管理路由：每个接口单独要求权限，编辑可以下架任意文章和评论，只有管理员可以管理用户
+	admin := r.Group("/api/admin", authMiddleware)
+	{
+		admin.PUT("/users/:id/role", middleware.RequirePermission(rbac.UserManage), adminHandler.SetUserRole)
+		admin.PUT("/users/:id/status", middleware.RequirePermission(rbac.UserManage), adminHandler.SetUserStatus)
+		admin.POST("/users/:id/unlock", middleware.RequirePermission(rbac.UserManage), adminHandler.UnlockUser)
+		admin.GET("/login-events", middleware.RequirePermission(rbac.UserManage), adminHandler.LoginEvents)
+		admin.GET("/roles", middleware.RequirePermission(rbac.UserManage), adminHandler.ListRoles)
+		admin.PUT("/roles/:role", middleware.RequirePermission(rbac.UserManage), adminHandler.SetRolePolicy)
+		admin.DELETE("/posts/:id", middleware.RequirePermission(rbac.PostDeleteAny), postHandler.DeletePost)
+		admin.DELETE("/comments/:commentId", middleware.RequirePermission(rbac.CommentDeleteAny), commentHandler.DeleteComment)
+	}
+
+	// 全文检索
+	r.GET("/api/search", readScope, optionalAuthMiddleware, searchHandler.Search)
+
 	// 健康检查
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "OK",
-			"message": "Blog API is running",
-		})
-	})
+	r.GET("/health", healthHandler.Health)
+	r.GET("/livez", healthHandler.Livez)
+	r.GET("/readyz", healthHandler.Readyz)
+
+	// 接口文档
+	docsHandler, err := docs.NewHandler(docs.Build())
+	if err != nil {
+		return fmt.Errorf("failed to build OpenAPI document: %w", err)
+	}
+	r.GET("/api/openapi", docsHandler.Spec)
+	r.GET("/api/openapi.json", docsHandler.JSON)
+	r.GET("/api/openapi.yaml", docsHandler.YAML)
+	r.GET("/api/docs", docsHandler.UI)
+	r.GET("/api/docs/assets/:file", docsHandler.Asset)
+	return nil
 }
@@ -0,0 +1,310 @@
+package routes_test
+
+import (
+	"blog/testutil"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain 在所有测试结束后检查 SetupRoutes 中的每个路由都至少被请求过一次
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if code == 0 {
+		if missing := testutil.UncoveredRoutes(); len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "routes without integration tests:\n  %s\n", strings.Join(missing, "\n  "))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// fixture 每个用例独立的数据：alice 发表了一篇文章，bob 在下面评论
+type fixture struct {
+	server  *testutil.Server
+	users   map[string]*testutil.User
+	postID  uint
+	comment uint
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+	bob := server.RegisterAndLogin("bob")
+	postID := server.CreatePost(alice, "Hello", "First post")
+	commentID := server.CreateComment(bob, postID, "Nice post")
+
+	return &fixture{
+		server: server,
+		users: map[string]*testutil.User{
+			"alice": alice,
+			"bob":   bob,
+		},
+		postID:  postID,
+		comment: commentID,
+	}
+}
+
+// routeCase 一个路由用例；path 中的 {post}、{comment} 会替换为 fixture 中的 ID，{用户名} 替换为用户 ID
+type routeCase struct {
+	name     string
+	method   string
+	path     string
+	as       string
+	token    string
+	body     interface{}
+	wantCode int
+	golden   string
+}
+
+func (f *fixture) run(t *testing.T, tc routeCase) *testutil.Response {
+	t.Helper()
+	replacements := []string{
+		"{post}", fmt.Sprint(f.postID),
+		"{comment}", fmt.Sprint(f.comment),
+	}
+	for name, user := range f.users {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(user.ID))
+	}
+	path := strings.NewReplacer(replacements...).Replace(tc.path)
+
+	token := tc.token
+	if user, ok := f.users[tc.as]; ok {
+		token = user.Token
+	}
+
+	resp := f.server.Do(testutil.Request{Method: tc.method, Path: path, Body: tc.body, Token: token})
+	if resp.Code != tc.wantCode {
+		t.Fatalf("%s %s: got status %d, want %d (body %s)", tc.method, path, resp.Code, tc.wantCode, resp.Body)
+	}
+	if tc.golden != "" {
+		testutil.AssertGolden(t, tc.golden, resp.Body)
+	}
+	return resp
+}
+
+func runCases(t *testing.T, cases []routeCase) {
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			newFixture(t).run(t, tc)
+		})
+	}
+}
+
+func TestAuthRoutes(t *testing.T) {
+	runCases(t, []routeCase{
+		{
+			name: "register", method: http.MethodPost, path: "/api/auth/register",
+			body:     map[string]string{"username": "carol", "password": "password123", "email": "carol@example.com"},
+			wantCode: http.StatusOK, golden: "auth_register",
+		},
+		{
+			name: "register duplicate username", method: http.MethodPost, path: "/api/auth/register",
+			body:     map[string]string{"username": "alice", "password": "password123", "email": "other@example.com"},
+			wantCode: http.StatusBadRequest, golden: "auth_register_duplicate_username",
+		},
+		{
+			name: "register duplicate email", method: http.MethodPost, path: "/api/auth/register",
+			body:     map[string]string{"username": "carol", "password": "password123", "email": "alice@example.com"},
+			wantCode: http.StatusBadRequest, golden: "auth_register_duplicate_email",
+		},
+		{
+			name: "register invalid body", method: http.MethodPost, path: "/api/auth/register",
+			body:     map[string]string{"username": "c", "password": "123", "email": "not-an-email"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "login", method: http.MethodPost, path: "/api/auth/login",
+			body:     map[string]string{"username": "alice", "password": "password123"},
+			wantCode: http.StatusOK, golden: "auth_login",
+		},
+		{
+			name: "login wrong password", method: http.MethodPost, path: "/api/auth/login",
+			body:     map[string]string{"username": "alice", "password": "wrong-password"},
+			wantCode: http.StatusUnauthorized, golden: "auth_login_invalid",
+		},
+		{
+			name: "login unknown user", method: http.MethodPost, path: "/api/auth/login",
+			body:     map[string]string{"username": "nobody", "password": "password123"},
+			wantCode: http.StatusUnauthorized, golden: "auth_login_invalid",
+		},
+		{
+			name: "refresh unknown token", method: http.MethodPost, path: "/api/auth/refresh",
+			body:     map[string]string{"refresh_token": "unknown"},
+			wantCode: http.StatusUnauthorized, golden: "auth_refresh_invalid",
+		},
+		{
+			name: "refresh without token", method: http.MethodPost, path: "/api/auth/refresh",
+			body: map[string]string{}, wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "logout", method: http.MethodPost, path: "/api/auth/logout", as: "alice",
+			wantCode: http.StatusOK, golden: "auth_logout",
+		},
+		{
+			name: "logout without token", method: http.MethodPost, path: "/api/auth/logout",
+			wantCode: http.StatusUnauthorized, golden: "auth_missing_token",
+		},
+		{
+			name: "profile", method: http.MethodGet, path: "/api/auth/profile", as: "alice",
+			wantCode: http.StatusOK, golden: "auth_profile",
+		},
+		{
+			name: "profile without token", method: http.MethodGet, path: "/api/auth/profile",
+			wantCode: http.StatusUnauthorized, golden: "auth_missing_token",
+		},
+		{
+			name: "profile with invalid token", method: http.MethodGet, path: "/api/auth/profile", token: "not-a-jwt",
+			wantCode: http.StatusUnauthorized, golden: "auth_invalid_token",
+		},
+	})
+}
+
+func TestPostRoutes(t *testing.T) {
+	update := map[string]string{"title": "Updated", "content": "Updated content"}
+	runCases(t, []routeCase{
+		{
+			name: "list", method: http.MethodGet, path: "/api/posts",
+			wantCode: http.StatusOK, golden: "posts_list",
+		},
+		{
+			name: "get", method: http.MethodGet, path: "/api/posts/{post}",
+			wantCode: http.StatusOK, golden: "posts_get",
+		},
+		{
+			name: "get invalid id", method: http.MethodGet, path: "/api/posts/abc",
+			wantCode: http.StatusBadRequest, golden: "posts_invalid_id",
+		},
+		{
+			name: "get missing", method: http.MethodGet, path: "/api/posts/999",
+			wantCode: http.StatusNotFound, golden: "posts_not_found",
+		},
+		{
+			name: "create", method: http.MethodPost, path: "/api/posts", as: "bob",
+			body:     map[string]string{"title": "Bob's post", "content": "Hi from bob"},
+			wantCode: http.StatusOK, golden: "posts_create",
+		},
+		{
+			name: "create without token", method: http.MethodPost, path: "/api/posts",
+			body:     map[string]string{"title": "x", "content": "y"},
+			wantCode: http.StatusUnauthorized, golden: "auth_missing_token",
+		},
+		{
+			name: "create invalid body", method: http.MethodPost, path: "/api/posts", as: "bob",
+			body:     map[string]string{"title": ""},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "update by author", method: http.MethodPut, path: "/api/posts/{post}", as: "alice",
+			body: update, wantCode: http.StatusOK, golden: "posts_update",
+		},
+		{
+			name: "update by other user", method: http.MethodPut, path: "/api/posts/{post}", as: "bob",
+			body: update, wantCode: http.StatusForbidden, golden: "posts_update_forbidden",
+		},
+		{
+			name: "update missing", method: http.MethodPut, path: "/api/posts/999", as: "alice",
+			body: update, wantCode: http.StatusNotFound, golden: "posts_not_found",
+		},
+		{
+			name: "delete by other user", method: http.MethodDelete, path: "/api/posts/{post}", as: "bob",
+			wantCode: http.StatusForbidden, golden: "posts_delete_forbidden",
+		},
+		{
+			name: "delete by author", method: http.MethodDelete, path: "/api/posts/{post}", as: "alice",
+			wantCode: http.StatusOK, golden: "posts_delete",
+		},
+		{
+			name: "delete without token", method: http.MethodDelete, path: "/api/posts/{post}",
+			wantCode: http.StatusUnauthorized, golden: "auth_missing_token",
+		},
+	})
+}
+
+func TestDeletedPostIsGone(t *testing.T) {
+	f := newFixture(t)
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/posts/{post}", as: "alice", wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/posts/{post}", wantCode: http.StatusNotFound})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/posts", wantCode: http.StatusOK, golden: "posts_list_empty"})
+}
+
+func TestCommentRoutes(t *testing.T) {
+	runCases(t, []routeCase{
+		{
+			name: "list", method: http.MethodGet, path: "/api/posts/{post}/comments",
+			wantCode: http.StatusOK, golden: "comments_list",
+		},
+		{
+			name: "list for missing post", method: http.MethodGet, path: "/api/posts/999/comments",
+			wantCode: http.StatusNotFound, golden: "posts_not_found",
+		},
+		{
+			name: "create", method: http.MethodPost, path: "/api/posts/{post}/comments", as: "alice",
+			body: map[string]string{"content": "Thanks!"}, wantCode: http.StatusOK, golden: "comments_create",
+		},
+		{
+			name: "create on missing post", method: http.MethodPost, path: "/api/posts/999/comments", as: "alice",
+			body: map[string]string{"content": "Thanks!"}, wantCode: http.StatusNotFound, golden: "posts_not_found",
+		},
+		{
+			name: "create without token", method: http.MethodPost, path: "/api/posts/{post}/comments",
+			body: map[string]string{"content": "Thanks!"}, wantCode: http.StatusUnauthorized, golden: "auth_missing_token",
+		},
+		{
+			name: "delete by post author", method: http.MethodDelete, path: "/api/posts/{post}/comments/{comment}", as: "alice",
+			wantCode: http.StatusForbidden, golden: "comments_delete_forbidden",
+		},
+		{
+			name: "delete by commenter", method: http.MethodDelete, path: "/api/posts/{post}/comments/{comment}", as: "bob",
+			wantCode: http.StatusOK, golden: "comments_delete",
+		},
+		{
+			name: "delete missing", method: http.MethodDelete, path: "/api/posts/{post}/comments/999", as: "bob",
+			wantCode: http.StatusNotFound, golden: "comments_not_found",
+		},
+	})
+}
+
+func TestHealthRoutes(t *testing.T) {
+	runCases(t, []routeCase{
+		{name: "health", method: http.MethodGet, path: "/health", wantCode: http.StatusOK, golden: "health"},
+		{name: "livez", method: http.MethodGet, path: "/livez", wantCode: http.StatusOK, golden: "livez"},
+		{name: "readyz", method: http.MethodGet, path: "/readyz", wantCode: http.StatusOK},
+	})
+}
+
+// TestReadyzPendingMigrations 数据库版本落后于代码中的迁移时 /readyz 返回 503
+func TestReadyzPendingMigrations(t *testing.T) {
+	server := testutil.NewServer(t)
+	latest := server.DB.Table("schema_migrations").Select("MAX(version)")
+	if err := server.DB.Exec("DELETE FROM schema_migrations WHERE version = (?)", latest).Error; err != nil {
+		t.Fatalf("revert latest migration record: %v", err)
+	}
+
+	resp := server.Do(testutil.Request{Method: http.MethodGet, Path: "/readyz"})
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz: got status %d (body %s)", resp.Code, resp.Body)
+	}
+	var body struct {
+		Status string `json:"status"`
+		Checks map[string]struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(resp.Body, &body); err != nil {
+		t.Fatalf("decode readyz: %v (body %s)", err, resp.Body)
+	}
+	if check := body.Checks["migrations"]; body.Status != "unhealthy" || check.Status != "unhealthy" || check.Error != "database has pending migrations" {
+		t.Fatalf("readyz body: %+v", body)
+	}
+	if check := body.Checks["database"]; check.Status != "ok" {
+		t.Fatalf("database check: %+v", check)
+	}
+}
@@ -0,0 +1,71 @@
+package routes_test
+
+import (
+	"blog/handlers"
+	"blog/testutil"
+	"net/http"
+	"testing"
+)
+
+func TestSearchRoutes(t *testing.T) {
+	runCases(t, []routeCase{
+		{
+			name: "search", method: http.MethodGet, path: "/api/search?q=post",
+			wantCode: http.StatusOK, golden: "search",
+		},
+		{
+			name: "search comments only", method: http.MethodGet, path: "/api/search?q=nice&type=comment",
+			wantCode: http.StatusOK, golden: "search_comments",
+		},
+		{
+			name: "search without query", method: http.MethodGet, path: "/api/search",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "search invalid type", method: http.MethodGet, path: "/api/search?q=post&type=user",
+			wantCode: http.StatusBadRequest,
+		},
+	})
+}
+
+// TestSearchIndexFollowsWrites 创建、更新、删除文章后索引随之更新
+func TestSearchIndexFollowsWrites(t *testing.T) {
+	f := newFixture(t)
+	alice := f.users["alice"]
+
+	search := func(q string) []handlers.SearchHitResponse {
+		t.Helper()
+		resp := f.server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/search?q=" + q})
+		if resp.Code != http.StatusOK {
+			t.Fatalf("search %q: got status %d (body %s)", q, resp.Code, resp.Body)
+		}
+		var hits []handlers.SearchHitResponse
+		resp.Decode(t, &hits)
+		return hits
+	}
+
+	postID := f.server.CreatePost(alice, "中文全文检索", "博客支持中文检索了")
+	if hits := search("检索"); len(hits) != 1 || hits[0].ID != postID || hits[0].Title != "中文全文<mark>检索</mark>" {
+		t.Fatalf("after create: got %+v", hits)
+	}
+
+	f.run(t, routeCase{
+		method: http.MethodPut, path: "/api/posts/{post}", as: "alice",
+		body:     map[string]string{"title": "Renamed", "content": "brand new words"},
+		wantCode: http.StatusOK,
+	})
+	if hits := search("hello"); len(hits) != 0 {
+		t.Fatalf("old title still indexed: %+v", hits)
+	}
+	if hits := search("brand"); len(hits) != 1 || hits[0].ID != f.postID {
+		t.Fatalf("after update: got %+v", hits)
+	}
+
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/posts/{post}", as: "alice", wantCode: http.StatusOK})
+	if hits := search("brand"); len(hits) != 0 {
+		t.Fatalf("deleted post still indexed: %+v", hits)
+	}
+	if hits := search("nice"); len(hits) != 0 {
+		t.Fatalf("comments of deleted post still indexed: %+v", hits)
+	}
+}
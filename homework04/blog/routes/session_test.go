@@ -0,0 +1,103 @@
+package routes_test
+
+import (
+	"blog/config"
+	"blog/testutil"
+	"net/http"
+	"testing"
+)
+
+// TestSessions 每次登录产生一个会话，吊销后该会话的访问令牌和刷新令牌立即失效
+func TestSessions(t *testing.T) {
+	f := newFixture(t)
+	alice := f.users["alice"]
+	first := alice.Token
+
+	// 换一台设备再登录一次
+	resp := f.server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{
+		"username": alice.Username, "password": alice.Password,
+	}, Headers: map[string]string{"User-Agent": "Mozilla/5.0 (iPhone)"}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("second login: got status %d (body %s)", resp.Code, resp.Body)
+	}
+	var phone struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	resp.Decode(t, &phone)
+
+	resp = f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/sessions", token: phone.Token, wantCode: http.StatusOK, golden: "auth_session_list"})
+	var sessions []struct {
+		ID      string `json:"id"`
+		Current bool   `json:"current"`
+	}
+	resp.Decode(t, &sessions)
+	if len(sessions) != 2 || !sessions[0].Current || sessions[1].Current {
+		t.Fatalf("sessions: %+v", sessions)
+	}
+	other := "/api/auth/sessions/" + sessions[1].ID
+
+	f.run(t, routeCase{method: http.MethodDelete, path: other, as: "bob", wantCode: http.StatusNotFound})
+	f.run(t, routeCase{method: http.MethodDelete, path: other, token: phone.Token, wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", token: first, wantCode: http.StatusUnauthorized, golden: "auth_session_revoked"})
+	f.run(t, routeCase{method: http.MethodDelete, path: other, token: phone.Token, wantCode: http.StatusNotFound})
+
+	// 退出其他设备：当前会话保留
+	f.server.Login(alice)
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/auth/sessions?keep_current=maybe", as: "alice", wantCode: http.StatusBadRequest})
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/auth/sessions?keep_current=true", as: "alice", wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", token: phone.Token, wantCode: http.StatusUnauthorized})
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/refresh", body: map[string]string{
+		"refresh_token": phone.RefreshToken,
+	}, wantCode: http.StatusUnauthorized})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", as: "alice", wantCode: http.StatusOK})
+
+	// 退出所有设备，包括当前会话
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/auth/sessions", as: "alice", wantCode: http.StatusOK})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/sessions", as: "alice", wantCode: http.StatusUnauthorized})
+}
+
+// TestSessionIPIgnoresForgedForwardedFor 会话记录的 IP 取连接的对端地址，只有可信代理转发的 X-Forwarded-For 才被采信
+func TestSessionIPIgnoresForgedForwardedFor(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		proxies []string
+		wantIP  string
+	}{
+		{name: "untrusted", wantIP: "192.0.2.1"},
+		// httptest 请求的对端地址是 192.0.2.1
+		{name: "trusted proxy", proxies: []string{"192.0.2.1"}, wantIP: "198.51.100.7"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := testutil.NewServer(t, func(cfg *config.Config) {
+				cfg.Server.TrustedProxies = tc.proxies
+			})
+			alice := server.Register("alice")
+			forwarded := map[string]string{"X-Forwarded-For": "198.51.100.7"}
+
+			resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{
+				"username": alice.Username, "password": alice.Password,
+			}, Headers: forwarded})
+			if resp.Code != http.StatusOK {
+				t.Fatalf("login: got status %d (body %s)", resp.Code, resp.Body)
+			}
+			var login struct {
+				Token string `json:"token"`
+			}
+			resp.Decode(t, &login)
+
+			// 列出会话的请求同时刷新会话的最近访问 IP
+			resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/auth/sessions", Token: login.Token, Headers: forwarded})
+			if resp.Code != http.StatusOK {
+				t.Fatalf("list sessions: got status %d (body %s)", resp.Code, resp.Body)
+			}
+			var sessions []struct {
+				IP string `json:"ip"`
+			}
+			resp.Decode(t, &sessions)
+			if len(sessions) != 1 || sessions[0].IP != tc.wantIP {
+				t.Fatalf("sessions: %+v, want IP %s", sessions, tc.wantIP)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package routes_test
+
+import (
+	"blog/handlers"
+	"blog/testutil"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// createCategory 以 user 身份创建分类，返回分类 ID
+func createCategory(t *testing.T, server *testutil.Server, user *testutil.User, name string, parentID uint) uint {
+	t.Helper()
+	body := map[string]interface{}{"name": name}
+	if parentID != 0 {
+		body["parent_id"] = parentID
+	}
+	resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/categories", Token: user.Token, Body: body})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create category %s: status %d, body %s", name, resp.Code, resp.Body)
+	}
+	var category handlers.CategoryResponse
+	resp.Decode(t, &category)
+	return category.ID
+}
+
+func TestTaxonomyRoutes(t *testing.T) {
+	server := testutil.NewServer(t)
+	alice := server.RegisterAndLogin("alice")
+	dave := server.RegisterAndLogin("dave")
+	server.SetRole(dave, "editor")
+
+	resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/categories", Token: alice.Token, Body: map[string]string{"name": "Programming"}})
+	if resp.Code != http.StatusForbidden {
+		t.Fatalf("create category as author: got status %d, want 403", resp.Code)
+	}
+	programming := createCategory(t, server, dave, "Programming", 0)
+	golang := createCategory(t, server, dave, "Go", programming)
+	life := createCategory(t, server, dave, "Life", 0)
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/categories", Token: dave.Token, Body: map[string]string{"name": "Go"}})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("create duplicate category: got status %d, want 400", resp.Code)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: alice.Token, Body: map[string]interface{}{
+		"title": "Web servers in Go", "content": "net/http basics", "category_id": golang, "tags": []string{"Go", " web ", "go"},
+	}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create post: status %d, body %s", resp.Code, resp.Body)
+	}
+	testutil.AssertGolden(t, "post_create_tagged", resp.Body)
+	var web handlers.CreatePostResponse
+	resp.Decode(t, &web)
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: alice.Token, Body: map[string]interface{}{
+		"title": "Why types matter", "content": "About type systems", "category_id": programming, "tags": []string{"go"},
+	}})
+	var types handlers.CreatePostResponse
+	resp.Decode(t, &types)
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: alice.Token, Body: map[string]interface{}{
+		"title": "A walk", "content": "Outside", "category_id": life,
+	}})
+	var walk handlers.CreatePostResponse
+	resp.Decode(t, &walk)
+	resp = server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/posts", Token: alice.Token, Body: map[string]interface{}{
+		"title": "Lost", "content": "Nowhere", "category_id": 999,
+	}})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("create post in unknown category: got status %d, want 400", resp.Code)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/categories"})
+	testutil.AssertGolden(t, "category_tree", resp.Body)
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/tags"})
+	testutil.AssertGolden(t, "tag_list", resp.Body)
+
+	for query, want := range map[string][]string{
+		"tag=GO":               {"Why types matter", "Web servers in Go"},
+		"tag=web":              {"Web servers in Go"},
+		"category=Programming": {"Why types matter", "Web servers in Go"},
+		"category=Go":          {"Web servers in Go"},
+		"category=Unknown":     {},
+		"category=Life&tag=go": {},
+	} {
+		titles, _ := listTitles(t, server, "/api/posts?"+query)
+		if !reflect.DeepEqual(titles, want) {
+			t.Errorf("?%s: got %v, want %v", query, titles, want)
+		}
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/search?q=net/http&tag=web"})
+	if env := resp.Envelope(t); env.Meta == nil || env.Meta.Total != 1 {
+		t.Fatalf("search by tag: body %s", resp.Body)
+	}
+
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/categories/%d", programming), Token: dave.Token, Body: map[string]interface{}{"name": "Programming", "parent_id": golang}})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("move category under its child: got status %d, want 400", resp.Code)
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/categories/%d", golang), Token: dave.Token, Body: map[string]interface{}{"name": "Golang", "parent_id": programming}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("rename category: status %d, body %s", resp.Code, resp.Body)
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodDelete, Path: fmt.Sprintf("/api/categories/%d", programming), Token: dave.Token})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("delete category with subcategories: got status %d, want 400", resp.Code)
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodDelete, Path: fmt.Sprintf("/api/categories/%d", life), Token: dave.Token})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("delete category: status %d, body %s", resp.Code, resp.Body)
+	}
+	resp = server.Do(testutil.Request{Method: http.MethodGet, Path: fmt.Sprintf("/api/posts/%d", walk.ID)})
+	var detail handlers.PostDetailResponse
+	resp.Decode(t, &detail)
+	if detail.Category != nil {
+		t.Fatalf("post still in deleted category: %+v", detail.Category)
+	}
+
+	// 不再有文章使用的标签随文章修改和删除一起清理
+	resp = server.Do(testutil.Request{Method: http.MethodPut, Path: fmt.Sprintf("/api/posts/%d", web.ID), Token: alice.Token, Body: map[string]interface{}{
+		"title": "Web servers in Go", "content": "net/http basics",
+	}})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("update post: status %d, body %s", resp.Code, resp.Body)
+	}
+	if got := tagList(t, server); !reflect.DeepEqual(got, []string{"go"}) {
+		t.Fatalf("tags after update: got %v", got)
+	}
+	for _, id := range []uint{web.ID, types.ID} {
+		resp = server.Do(testutil.Request{Method: http.MethodDelete, Path: fmt.Sprintf("/api/posts/%d", id), Token: alice.Token})
+		if resp.Code != http.StatusOK {
+			t.Fatalf("delete post: status %d, body %s", resp.Code, resp.Body)
+		}
+	}
+	if got := tagList(t, server); len(got) != 0 {
+		t.Fatalf("tags after delete: got %v", got)
+	}
+	var stored int64
+	server.DB.Table("tags").Count(&stored)
+	if stored != 0 {
+		t.Fatalf("%d unused tags left in the database", stored)
+	}
+}
+
+func tagList(t *testing.T, server *testutil.Server) []string {
+	t.Helper()
+	var tags []handlers.TagResponse
+	server.Do(testutil.Request{Method: http.MethodGet, Path: "/api/tags"}).Decode(t, &tags)
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
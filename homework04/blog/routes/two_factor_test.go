@@ -0,0 +1,169 @@
+package routes_test
+
+import (
+	"blog/testutil"
+	"blog/utils"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// enableTwoFactor 为 user 启用两步验证，返回生成验证码的函数和恢复码
+// 每个验证码只能使用一次，offset 取 -1、0、1 得到当前时间窗口内三个不同的验证码
+func enableTwoFactor(t *testing.T, f *fixture, as string) (func(offset int64) string, []string) {
+	t.Helper()
+	var setup struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/2fa/setup", as: as, wantCode: http.StatusOK}).Decode(t, &setup)
+	if setup.Secret == "" || setup.OTPAuthURI == "" {
+		t.Fatalf("setup returned no secret: %+v", setup)
+	}
+
+	code := func(offset int64) string {
+		t.Helper()
+		code, err := utils.TOTPCode(setup.Secret, utils.TOTPStep(time.Now())+offset)
+		if err != nil {
+			t.Fatalf("generate code: %v", err)
+		}
+		return code
+	}
+
+	f.run(t, routeCase{
+		method: http.MethodPost, path: "/api/auth/2fa/confirm", as: as,
+		body: map[string]string{"code": "000000x"}, wantCode: http.StatusBadRequest,
+	})
+	var confirm struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	f.run(t, routeCase{
+		method: http.MethodPost, path: "/api/auth/2fa/confirm", as: as,
+		body: map[string]string{"code": code(-1)}, wantCode: http.StatusOK,
+	}).Decode(t, &confirm)
+	if len(confirm.RecoveryCodes) != 10 {
+		t.Fatalf("got %d recovery codes, want 10", len(confirm.RecoveryCodes))
+	}
+	return code, confirm.RecoveryCodes
+}
+
+// loginChallenge 用密码登录，返回两步登录的 challenge_token
+func loginChallenge(t *testing.T, server *testutil.Server, user *testutil.User) string {
+	t.Helper()
+	resp := server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{
+		"username": user.Username, "password": user.Password,
+	}})
+	var data struct {
+		Token             string `json:"token"`
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token"`
+	}
+	resp.Decode(t, &data)
+	if resp.Code != http.StatusOK || !data.TwoFactorRequired || data.ChallengeToken == "" || data.Token != "" {
+		t.Fatalf("login of %s: got status %d, want a challenge (body %s)", user.Username, resp.Code, resp.Body)
+	}
+	return data.ChallengeToken
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	f := newFixture(t)
+	alice := f.users["alice"]
+	code, recoveryCodes := enableTwoFactor(t, f, "alice")
+
+	var profile struct {
+		TwoFactorEnabled bool `json:"two_factor_enabled"`
+	}
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", as: "alice", wantCode: http.StatusOK}).Decode(t, &profile)
+	if !profile.TwoFactorEnabled {
+		t.Fatal("profile should report two_factor_enabled")
+	}
+	f.run(t, routeCase{method: http.MethodPost, path: "/api/auth/2fa/setup", as: "alice", wantCode: http.StatusBadRequest, golden: "two_factor_already_enabled"})
+
+	// 密码正确只得到 challenge
+	resp := f.server.Do(testutil.Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{
+		"username": alice.Username, "password": alice.Password,
+	}})
+	testutil.AssertGolden(t, "two_factor_login_challenge", resp.Body)
+
+	login := func(challenge, code string, wantCode int, golden string) {
+		t.Helper()
+		f.run(t, routeCase{
+			method: http.MethodPost, path: "/api/auth/login/2fa",
+			body:     map[string]string{"challenge_token": challenge, "code": code},
+			wantCode: wantCode, golden: golden,
+		})
+	}
+
+	challenge := loginChallenge(t, f.server, alice)
+	login(challenge, "123456x", http.StatusUnauthorized, "two_factor_invalid_code")
+	login("not-a-challenge", code(0), http.StatusUnauthorized, "two_factor_invalid_challenge")
+	login(challenge, code(0), http.StatusOK, "two_factor_login")
+	// 同一个验证码不能再用
+	login(loginChallenge(t, f.server, alice), code(0), http.StatusUnauthorized, "two_factor_invalid_code")
+
+	// 恢复码只能使用一次，格式上忽略大小写和连字符
+	recovery := recoveryCodes[0]
+	login(loginChallenge(t, f.server, alice), recovery, http.StatusOK, "")
+	login(loginChallenge(t, f.server, alice), recovery, http.StatusUnauthorized, "")
+
+	// 重新生成后旧的恢复码作废
+	var regenerated struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	f.run(t, routeCase{
+		method: http.MethodPost, path: "/api/auth/2fa/recovery-codes", as: "alice",
+		body: map[string]string{"code": code(1)}, wantCode: http.StatusOK,
+	}).Decode(t, &regenerated)
+	login(loginChallenge(t, f.server, alice), recoveryCodes[1], http.StatusUnauthorized, "")
+	login(loginChallenge(t, f.server, alice), regenerated.RecoveryCodes[0], http.StatusOK, "")
+
+	// 关闭后恢复为只用密码登录
+	f.run(t, routeCase{
+		method: http.MethodDelete, path: "/api/auth/2fa", as: "alice",
+		body:     map[string]string{"password": "wrong-password", "code": regenerated.RecoveryCodes[1]},
+		wantCode: http.StatusBadRequest,
+	})
+	f.run(t, routeCase{
+		method: http.MethodDelete, path: "/api/auth/2fa", as: "alice",
+		body:     map[string]string{"password": alice.Password, "code": regenerated.RecoveryCodes[1]},
+		wantCode: http.StatusOK, golden: "two_factor_disable",
+	})
+	f.server.Login(alice)
+	login(challenge, code(0), http.StatusUnauthorized, "two_factor_invalid_challenge")
+}
+
+func TestTwoFactorRequiredByRole(t *testing.T) {
+	f := newRBACFixture(t)
+
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/admin/roles", as: "carol", wantCode: http.StatusOK, golden: "admin_roles"})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/admin/roles", as: "dave", wantCode: http.StatusForbidden})
+	f.run(t, routeCase{
+		method: http.MethodPut, path: "/api/admin/roles/root", as: "carol",
+		body: map[string]bool{"require_two_factor": true}, wantCode: http.StatusNotFound,
+	})
+	f.run(t, routeCase{
+		method: http.MethodPut, path: "/api/admin/roles/editor", as: "carol",
+		body: map[string]bool{"require_two_factor": true}, wantCode: http.StatusOK, golden: "admin_set_role_policy",
+	})
+
+	// 编辑 dave 的令牌仍然有效，但在启用两步验证前只能访问个人信息和启用接口
+	f.run(t, routeCase{
+		method: http.MethodDelete, path: "/api/admin/posts/{post}", as: "dave",
+		wantCode: http.StatusForbidden, golden: "two_factor_required",
+	})
+	f.run(t, routeCase{method: http.MethodGet, path: "/api/auth/profile", as: "dave", wantCode: http.StatusOK})
+	code, _ := enableTwoFactor(t, f, "dave")
+	f.run(t, routeCase{method: http.MethodDelete, path: "/api/admin/posts/{post}", as: "dave", wantCode: http.StatusOK})
+
+	// 角色要求两步验证时不能关闭
+	f.run(t, routeCase{
+		method: http.MethodDelete, path: "/api/auth/2fa", as: "dave",
+		body:     map[string]string{"password": f.users["dave"].Password, "code": code(0)},
+		wantCode: http.StatusForbidden,
+	})
+	// 其他角色不受影响
+	f.run(t, routeCase{
+		method: http.MethodPost, path: "/api/posts", as: "bob",
+		body: map[string]string{"title": "x", "content": "y"}, wantCode: http.StatusOK,
+	})
+}
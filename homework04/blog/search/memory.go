@@ -0,0 +1,208 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 参数；标题中的词按 titleWeight 倍计入词频
+const (
+	bm25K1      = 1.2
+	bm25B       = 0.75
+	titleWeight = 2
+)
+
+// snippetWidth 摘要片段的字符数
+const snippetWidth = 120
+
+type docKey struct {
+	kind Kind
+	id   uint
+}
+
+type memoryDoc struct {
+	Document
+	length float64
+	terms  map[string]float64
+}
+
+// MemoryIndex 嵌入式倒排索引，使用 BM25 排序
+// 索引只保存在内存中，进程启动时需要从数据库重建
+type MemoryIndex struct {
+	mu          sync.RWMutex
+	docs        map[docKey]*memoryDoc
+	postings    map[string]map[docKey]float64
+	totalLength float64
+}
+
+// NewMemoryIndex 创建空的内存索引
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     map[docKey]*memoryDoc{},
+		postings: map[string]map[docKey]float64{},
+	}
+}
+
+func (m *MemoryIndex) Put(ctx context.Context, docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, doc := range docs {
+		key := docKey{doc.Kind, doc.ID}
+		m.remove(key)
+
+		entry := &memoryDoc{Document: doc, terms: map[string]float64{}}
+		for _, token := range Tokenize(doc.Title) {
+			entry.terms[token.Text] += titleWeight
+			entry.length += titleWeight
+		}
+		for _, token := range Tokenize(doc.Content) {
+			entry.terms[token.Text]++
+			entry.length++
+		}
+		for term, tf := range entry.terms {
+			if m.postings[term] == nil {
+				m.postings[term] = map[docKey]float64{}
+			}
+			m.postings[term][key] = tf
+		}
+		m.docs[key] = entry
+		m.totalLength += entry.length
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Remove(ctx context.Context, kind Kind, id uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(docKey{kind, id})
+	if kind == KindPost {
+		for key, doc := range m.docs {
+			if key.kind == KindComment && doc.PostID == id {
+				m.remove(key)
+			}
+		}
+	}
+	return nil
+}
+
+// remove 调用方需持有写锁
+func (m *MemoryIndex) remove(key docKey) {
+	doc, ok := m.docs[key]
+	if !ok {
+		return
+	}
+	for term := range doc.terms {
+		delete(m.postings[term], key)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+		}
+	}
+	m.totalLength -= doc.length
+	delete(m.docs, key)
+}
+
+func (m *MemoryIndex) Search(ctx context.Context, q Query) (*Result, error) {
+	terms := Terms(q.Text)
+	if len(terms) == 0 {
+		return &Result{}, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := float64(len(m.docs))
+	avgLength := m.totalLength / math.Max(n, 1)
+	scores := map[docKey]float64{}
+	for _, term := range terms {
+		postings := m.postings[term]
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for key, tf := range postings {
+			doc := m.docs[key]
+			if !matches(doc.Document, q) {
+				continue
+			}
+			norm := bm25K1 * (1 - bm25B + bm25B*doc.length/avgLength)
+			scores[key] += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for key, score := range scores {
+		hits = append(hits, Hit{Document: m.docs[key].Document, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind
+		}
+		return a.ID > b.ID
+	})
+
+	result := &Result{Total: int64(len(hits))}
+	hits = paginate(hits, q.Offset, q.Limit)
+	for i := range hits {
+		highlight(&hits[i], terms)
+	}
+	result.Hits = hits
+	return result, nil
+}
+
+// matches 判断文档是否满足过滤条件
+func matches(doc Document, q Query) bool {
+	if !q.matchesKind(doc.Kind) {
+		return false
+	}
+	if q.AuthorID != 0 && doc.AuthorID != q.AuthorID {
+		return false
+	}
+	if q.Author != "" && doc.Author != q.Author {
+		return false
+	}
+	if !q.From.IsZero() && doc.CreatedAt.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !doc.CreatedAt.Before(q.To) {
+		return false
+	}
+	if q.Tag != "" {
+		found := false
+		for _, tag := range doc.Tags {
+			if tag == q.Tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func paginate(hits []Hit, offset, limit int) []Hit {
+	if offset > len(hits) {
+		offset = len(hits)
+	}
+	hits = hits[offset:]
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// highlight 生成标题和正文片段的高亮
+func highlight(hit *Hit, terms []string) {
+	if hit.Title != "" {
+		hit.HighlightedTitle = Highlight(hit.Title, terms, 0)
+	}
+	hit.Snippet = Highlight(hit.Content, terms, snippetWidth)
+}
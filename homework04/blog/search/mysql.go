@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MySQLIndex 基于 MySQL FULLTEXT（ngram 解析器）的检索
+// 直接查询 posts、comments 表上的全文索引（见迁移 0002），数据写入时由 MySQL 自动维护索引，
+// 所以 Put 和 Remove 什么都不做；只检索已发布的文章及其评论
+type MySQLIndex struct {
+	db *gorm.DB
+}
+
+// NewMySQLIndex 创建 MySQL 全文检索
+func NewMySQLIndex(db *gorm.DB) *MySQLIndex {
+	return &MySQLIndex{db: db}
+}
+
+func (m *MySQLIndex) Put(ctx context.Context, docs ...Document) error { return nil }
+
+func (m *MySQLIndex) Remove(ctx context.Context, kind Kind, id uint) error { return nil }
+
+// mysqlRow 查询结果行
+type mysqlRow struct {
+	Kind      Kind
+	ID        uint
+	PostID    uint
+	AuthorID  uint
+	Author    string
+	Title     string
+	Content   string
+	CreatedAt time.Time
+	Score     float64
+}
+
+func (m *MySQLIndex) Search(ctx context.Context, q Query) (*Result, error) {
+	terms := Terms(q.Text)
+	if len(terms) == 0 {
+		return &Result{}, nil
+	}
+
+	var parts []string
+	var args []interface{}
+	if q.matchesKind(KindPost) {
+		sql, partArgs := mysqlPart(
+			"SELECT 'post' AS kind, p.id, p.id AS post_id, p.user_id AS author_id, COALESCE(u.username, '') AS author, p.title, p.content, p.created_at, "+
+				"MATCH(p.title, p.content) AGAINST (? IN NATURAL LANGUAGE MODE) AS score "+
+				"FROM posts p LEFT JOIN users u ON u.id = p.user_id AND u.deleted_at IS NULL "+
+				"WHERE p.deleted_at IS NULL AND p.status = 'published' AND MATCH(p.title, p.content) AGAINST (? IN NATURAL LANGUAGE MODE)",
+			"p", q,
+		)
+		parts = append(parts, sql)
+		args = append(args, partArgs...)
+	}
+	// 评论没有标签，按标签过滤时只检索文章
+	if q.matchesKind(KindComment) && q.Tag == "" {
+		sql, partArgs := mysqlPart(
+			"SELECT 'comment' AS kind, c.id, c.post_id, c.user_id AS author_id, COALESCE(u.username, '') AS author, '' AS title, c.content, c.created_at, "+
+				"MATCH(c.content) AGAINST (? IN NATURAL LANGUAGE MODE) AS score "+
+				"FROM comments c LEFT JOIN users u ON u.id = c.user_id AND u.deleted_at IS NULL JOIN posts p ON p.id = c.post_id AND p.deleted_at IS NULL AND p.status = 'published' "+
+				"WHERE c.deleted_at IS NULL AND MATCH(c.content) AGAINST (? IN NATURAL LANGUAGE MODE)",
+			"c", q,
+		)
+		parts = append(parts, sql)
+		args = append(args, partArgs...)
+	}
+	if len(parts) == 0 {
+		return &Result{}, nil
+	}
+	union := strings.Join(parts, " UNION ALL ")
+
+	db := m.db.WithContext(ctx)
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM ("+union+") AS hits", args...).Scan(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	query := "SELECT * FROM (" + union + ") AS hits ORDER BY score DESC, created_at DESC, kind DESC, id DESC"
+	pageArgs := append([]interface{}{}, args...)
+	if q.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		pageArgs = append(pageArgs, q.Limit, q.Offset)
+	}
+	var rows []mysqlRow
+	if err := db.Raw(query, pageArgs...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	result := &Result{Total: total, Hits: make([]Hit, 0, len(rows))}
+	for _, row := range rows {
+		hit := Hit{
+			Document: Document{
+				Kind:      row.Kind,
+				ID:        row.ID,
+				PostID:    row.PostID,
+				AuthorID:  row.AuthorID,
+				Author:    row.Author,
+				Title:     row.Title,
+				Content:   row.Content,
+				CreatedAt: row.CreatedAt,
+			},
+			Score: row.Score,
+		}
+		highlight(&hit, terms)
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, nil
+}
+
+// mysqlPart 给一个子查询追加过滤条件，alias 为主表别名
+func mysqlPart(sql, alias string, q Query) (string, []interface{}) {
+	args := []interface{}{q.Text, q.Text}
+	if q.AuthorID != 0 {
+		sql += " AND " + alias + ".user_id = ?"
+		args = append(args, q.AuthorID)
+	}
+	if q.Author != "" {
+		sql += " AND u.username = ?"
+		args = append(args, q.Author)
+	}
+	if !q.From.IsZero() {
+		sql += " AND " + alias + ".created_at >= ?"
+		args = append(args, q.From)
+	}
+	if !q.To.IsZero() {
+		sql += " AND " + alias + ".created_at < ?"
+		args = append(args, q.To)
+	}
+	if q.Tag != "" {
+		sql += " AND p.id IN (SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE t.name = ?)"
+		args = append(args, q.Tag)
+	}
+	return sql, args
+}
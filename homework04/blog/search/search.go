@@ -0,0 +1,80 @@
+// Package search 全文检索：定义检索索引接口，提供内存倒排索引和 MySQL FULLTEXT 两种实现，
+// 以及分词和高亮等公共工具。
+package search
+
+import (
+	"context"
+	"time"
+)
+
+// Kind 文档类型
+type Kind string
+
+const (
+	KindPost    Kind = "post"
+	KindComment Kind = "comment"
+)
+
+// Document 被索引的文章或评论
+// 评论的 PostID 为所属文章，Title 为空；文章的 PostID 等于 ID
+type Document struct {
+	Kind      Kind
+	ID        uint
+	PostID    uint
+	AuthorID  uint
+	Author    string
+	Title     string
+	Content   string
+	Tags      []string
+	CreatedAt time.Time
+}
+
+// Query 检索条件，零值字段表示不过滤
+type Query struct {
+	Text     string
+	Kinds    []Kind
+	AuthorID uint
+	Author   string
+	Tag      string
+	From     time.Time // 包含
+	To       time.Time // 不包含
+	Limit    int
+	Offset   int
+}
+
+// Hit 一条命中结果，HighlightedTitle 和 Snippet 为转义后的 HTML，命中词用 <mark> 包裹
+type Hit struct {
+	Document
+	Score            float64
+	HighlightedTitle string
+	Snippet          string
+}
+
+// Result 检索结果，Total 为满足条件的总数
+type Result struct {
+	Hits  []Hit
+	Total int64
+}
+
+// Index 检索索引
+type Index interface {
+	// Put 新增或更新文档
+	Put(ctx context.Context, docs ...Document) error
+	// Remove 删除文档，删除文章时同时删除其评论
+	Remove(ctx context.Context, kind Kind, id uint) error
+	// Search 按相关度从高到低返回结果
+	Search(ctx context.Context, q Query) (*Result, error)
+}
+
+// matchesKind 判断文档类型是否在过滤范围内
+func (q Query) matchesKind(kind Kind) bool {
+	if len(q.Kinds) == 0 {
+		return true
+	}
+	for _, k := range q.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
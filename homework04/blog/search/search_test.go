@@ -0,0 +1,83 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	var got []string
+	for _, token := range Tokenize("Go 语言的全文检索, v1.2 好") {
+		got = append(got, token.Text)
+	}
+	want := []string{"go", "语言", "言的", "的全", "全文", "文检", "检索", "v1", "2", "好"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text  string
+		query string
+		width int
+		want  string
+	}{
+		{"学习全文检索的方法", "全文检索", 0, "学习<mark>全文检索</mark>的方法"},
+		{"Go <b>is</b> fun, go!", "go", 0, "<mark>Go</mark> &lt;b&gt;is&lt;/b&gt; fun, <mark>go</mark>!"},
+		{"aaaa bbbb cccc dddd target eeee", "target", 12, "…dd <mark>target</mark> ee…"},
+		{"nothing here", "missing", 0, "nothing here"},
+	}
+	for _, tc := range tests {
+		if got := Highlight(tc.text, Terms(tc.query), tc.width); got != tc.want {
+			t.Errorf("Highlight(%q, %q): got %q, want %q", tc.text, tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryIndex(t *testing.T) {
+	ctx := context.Background()
+	index := NewMemoryIndex()
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	index.Put(ctx,
+		Document{Kind: KindPost, ID: 1, PostID: 1, AuthorID: 1, Author: "alice", Title: "全文检索入门", Content: "介绍倒排索引", Tags: []string{"search"}, CreatedAt: day},
+		Document{Kind: KindPost, ID: 2, PostID: 2, AuthorID: 2, Author: "bob", Title: "随笔", Content: "今天研究了检索", CreatedAt: day.AddDate(0, 0, 1)},
+		Document{Kind: KindComment, ID: 1, PostID: 1, AuthorID: 2, Author: "bob", Content: "检索写得很好", CreatedAt: day.AddDate(0, 0, 2)},
+	)
+
+	ids := func(q Query) []string {
+		t.Helper()
+		result, err := index.Search(ctx, q)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		var got []string
+		for _, hit := range result.Hits {
+			got = append(got, fmt.Sprintf("%s:%d", hit.Kind, hit.ID))
+		}
+		return got
+	}
+
+	// 较短的文档和标题命中排在前面
+	if got := ids(Query{Text: "检索"}); !reflect.DeepEqual(got, []string{"comment:1", "post:1", "post:2"}) {
+		t.Fatalf("ranking: got %v", got)
+	}
+	if got := ids(Query{Text: "检索", Kinds: []Kind{KindComment}}); !reflect.DeepEqual(got, []string{"comment:1"}) {
+		t.Fatalf("kind filter: got %v", got)
+	}
+	if got := ids(Query{Text: "检索", Author: "bob", From: day.AddDate(0, 0, 2)}); !reflect.DeepEqual(got, []string{"comment:1"}) {
+		t.Fatalf("author/date filter: got %v", got)
+	}
+	if got := ids(Query{Text: "检索", Tag: "search"}); !reflect.DeepEqual(got, []string{"post:1"}) {
+		t.Fatalf("tag filter: got %v", got)
+	}
+
+	// 删除文章时同时删除其评论
+	index.Remove(ctx, KindPost, 1)
+	if got := ids(Query{Text: "检索"}); !reflect.DeepEqual(got, []string{"post:2"}) {
+		t.Fatalf("after remove: got %v", got)
+	}
+}
@@ -0,0 +1,134 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Token 分词结果，Start/End 为原文中的字符（rune）下标
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Tokenize 分词：拉丁字母和数字按单词切分并转为小写；
+// 中日韩文字没有空格分隔，按相邻两字（bigram）切分，与 MySQL ngram 解析器的默认行为一致，单个字单独成词
+func Tokenize(text string) []Token {
+	runes := []rune(text)
+	var tokens []Token
+
+	for i := 0; i < len(runes); {
+		switch {
+		case isCJK(runes[i]):
+			start := i
+			for i < len(runes) && isCJK(runes[i]) {
+				i++
+			}
+			if i-start == 1 {
+				tokens = append(tokens, Token{Text: string(runes[start:i]), Start: start, End: i})
+				continue
+			}
+			for j := start; j+1 < i; j++ {
+				tokens = append(tokens, Token{Text: string(runes[j : j+2]), Start: j, End: j + 2})
+			}
+		case isWordRune(runes[i]):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) && !isCJK(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, Token{Text: strings.ToLower(string(runes[start:i])), Start: start, End: i})
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// Terms 查询中去重后的词
+func Terms(text string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, token := range Tokenize(text) {
+		if !seen[token.Text] {
+			seen[token.Text] = true
+			terms = append(terms, token.Text)
+		}
+	}
+	return terms
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Highlight 截取 text 中第一个命中词附近约 width 个字符的片段，转义 HTML 后用 <mark> 包裹命中词
+// width <= 0 时返回全文；没有命中时返回开头部分
+func Highlight(text string, terms []string, width int) string {
+	runes := []rune(text)
+	want := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		want[term] = true
+	}
+
+	// 收集命中区间并合并重叠部分（中文 bigram 会相互重叠）
+	var spans [][2]int
+	for _, token := range Tokenize(text) {
+		if want[token.Text] {
+			spans = append(spans, [2]int{token.Start, token.End})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	var merged [][2]int
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span[0] <= merged[n-1][1] {
+			if span[1] > merged[n-1][1] {
+				merged[n-1][1] = span[1]
+			}
+			continue
+		}
+		merged = append(merged, span)
+	}
+
+	start, end := 0, len(runes)
+	if width > 0 && len(runes) > width {
+		if len(merged) > 0 {
+			start = merged[0][0] - width/4
+			if start < 0 {
+				start = 0
+			}
+		}
+		end = start + width
+		if end > len(runes) {
+			end, start = len(runes), len(runes)-width
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, span := range merged {
+		if span[1] <= start || span[0] >= end {
+			continue
+		}
+		s, e := max(span[0], start), min(span[1], end)
+		b.WriteString(html.EscapeString(string(runes[pos:s])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[s:e])))
+		b.WriteString("</mark>")
+		pos = e
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"blog/mailer"
+	"blog/models"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 一次性令牌的用途，写入令牌内容，验证令牌不能用于重置密码，反之亦然
+const (
+	purposeVerifyEmail   = "verify_email"
+	purposeResetPassword = "reset_password"
+)
+
+// AccountConfig 邮件链接的地址和有效期、密码哈希的 cost，以及注销账号时对文章和评论的处理方式
+type AccountConfig struct {
+	// BaseURL 邮件中链接指向的前端地址
+	BaseURL          string
+	VerificationTTL  time.Duration
+	PasswordResetTTL time.Duration
+	BcryptCost       int
+	// DeletePosts、DeleteComments 注销账号时删除其文章、评论；为 false 时保留内容，作者显示为已注销用户
+	DeletePosts    bool
+	DeleteComments bool
+}
+
+// AccountService 邮箱验证、找回密码，以及用户修改资料、修改密码和注销账号
+// 令牌不保存在服务端：令牌中的指纹由邮箱、验证状态和密码哈希计算，验证成功或修改密码后旧令牌即失效
+type AccountService struct {
+	users    repository.UserRepository
+	posts    repository.PostRepository
+	comments repository.CommentRepository
+	tokens   *TokenService
+	personal *PersonalTokenService
+	search   *SearchService
+	cache    *UserCache
+	signer   *utils.ActionTokenSigner
+	mailer   mailer.Mailer
+	cfg      AccountConfig
+}
+
+func NewAccountService(users repository.UserRepository, posts repository.PostRepository, comments repository.CommentRepository, tokens *TokenService, personal *PersonalTokenService, search *SearchService, cache *UserCache, signer *utils.ActionTokenSigner, m mailer.Mailer, cfg AccountConfig) *AccountService {
+	return &AccountService{users: users, posts: posts, comments: comments, tokens: tokens, personal: personal, search: search, cache: cache, signer: signer, mailer: m, cfg: cfg}
+}
+
+// SendVerification 向用户的邮箱发送验证链接
+func (s *AccountService) SendVerification(ctx context.Context, user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	token, err := s.signer.Sign(purposeVerifyEmail, user.ID, fingerprint(purposeVerifyEmail, user), time.Now().Add(s.cfg.VerificationTTL))
+	if err != nil {
+		return fmt.Errorf("failed to sign verification token: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s. If you did not create an account, you can ignore this email.\n",
+			user.Username, s.link("/verify-email", token), s.cfg.VerificationTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+	return nil
+}
+
+// ResendVerification 为已登录的用户重新发送验证链接
+func (s *AccountService) ResendVerification(ctx context.Context, userID uint) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// VerifyEmail 校验验证令牌并标记邮箱已验证
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.consume(ctx, token, purposeVerifyEmail)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return user, nil
+}
+
+// ForgotPassword 向邮箱对应的用户发送重置密码链接
+// 邮箱未注册或账号已禁用时同样返回 nil，不泄露邮箱是否存在
+func (s *AccountService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Disabled {
+		return nil
+	}
+
+	token, err := s.signer.Sign(purposeResetPassword, user.ID, fingerprint(purposeResetPassword, user), time.Now().Add(s.cfg.PasswordResetTTL))
+	if err != nil {
+		return fmt.Errorf("failed to sign password reset token: %w", err)
+	}
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. To choose a new password, open the link below:\n\n%s\n\nThe link expires in %s and can be used once. If you did not ask for this, you can ignore this email; your password has not been changed.\n",
+			user.Username, s.link("/reset-password", token), s.cfg.PasswordResetTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword 校验重置令牌并设置新密码，同时退出所有登录会话并吊销所有个人访问令牌
+// 能收到重置邮件也证明了邮箱归属，未验证的邮箱一并标记为已验证
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	user, err := s.consume(ctx, token, purposeResetPassword)
+	if err != nil {
+		return err
+	}
+	if user.Disabled {
+		return ErrAccountDisabled
+	}
+
+	if err := user.HashPasswordWithCost(password, s.cfg.BcryptCost); err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return s.revokeCredentials(ctx, user.ID, "")
+}
+
+// revokeCredentials 密码变更后吊销旧密码换来的所有凭证：除 keepSessionID 以外的登录会话，以及所有个人访问令牌
+// 令牌可能是在账号被盗期间创建的，不能在修改密码后继续有效
+func (s *AccountService) revokeCredentials(ctx context.Context, userID uint, keepSessionID string) error {
+	if err := s.tokens.RevokeSessions(ctx, userID, keepSessionID); err != nil {
+		return err
+	}
+	return s.personal.RevokeAll(ctx, userID)
+}
+
+// consume 校验令牌的签名、用途和有效期，并确认账号状态与签发时一致
+func (s *AccountService) consume(ctx context.Context, token, purpose string) (*models.User, error) {
+	claims, err := s.signer.Verify(token, purpose, time.Now())
+	if err != nil {
+		return nil, ErrInvalidActionToken
+	}
+	user, err := s.users.FindByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidActionToken
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Fingerprint), []byte(fingerprint(purpose, user))) != 1 {
+		return nil, ErrInvalidActionToken
+	}
+	return user, nil
+}
+
+// link 邮件中的链接：BaseURL + path?token=...
+func (s *AccountService) link(path, token string) string {
+	return strings.TrimRight(s.cfg.BaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// fingerprint 令牌绑定的账号状态
+// 验证邮箱：邮箱和验证状态，邮箱验证后或修改邮箱后令牌失效
+// 重置密码：邮箱和密码哈希，密码修改后（包括用该令牌重置后）令牌失效
+// 两步登录：密码哈希和 TOTP 密钥，修改密码、关闭或重新启用两步验证后失效
+func fingerprint(purpose string, user *models.User) string {
+	state := []string{purpose, user.Email}
+	switch purpose {
+	case purposeVerifyEmail:
+		state = append(state, fmt.Sprint(user.EmailVerifiedAt != nil))
+	case purposeResetPassword:
+		state = append(state, user.Password)
+	case purposeLoginChallenge:
+		state = append(state, user.Password, user.TOTPSecret)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(state, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
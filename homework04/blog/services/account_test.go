@@ -0,0 +1,145 @@
+package services
+
+import (
+	"blog/mailer"
+	"blog/models"
+	"blog/repository"
+	"blog/search"
+	"blog/utils"
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recordingMailer 记录发送的邮件
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var tokenLinkPattern = regexp.MustCompile(`https?://\S+`)
+
+// lastToken 最近一封邮件链接中的 token
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	if len(m.sent) == 0 {
+		t.Fatal("no mail sent")
+	}
+	u, err := url.Parse(tokenLinkPattern.FindString(m.sent[len(m.sent)-1].Body))
+	if err != nil {
+		t.Fatalf("parse link: %v", err)
+	}
+	return u.Query().Get("token")
+}
+
+func newTestAccountService(t *testing.T, ttl time.Duration) (*AccountService, *recordingMailer, repository.UserRepository) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	accounts, m := newTestAccountServiceWithStore(t, store, AccountConfig{
+		BaseURL:          "https://blog.example.com/",
+		VerificationTTL:  ttl,
+		PasswordResetTTL: ttl,
+		BcryptCost:       bcrypt.MinCost,
+	})
+	return accounts, m, store.Users()
+}
+
+// newTestAccountServiceWithStore 在 store 中创建用户 alice（密码 password123），返回使用 store 的 AccountService
+func newTestAccountServiceWithStore(t *testing.T, store *repository.MemoryStore, cfg AccountConfig) (*AccountService, *recordingMailer) {
+	t.Helper()
+	user := &models.User{Username: "alice", Email: "alice@example.com", Role: "author"}
+	if err := user.HashPassword("password123"); err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if err := store.Users().Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	tokens, _ := newTestTokenService(t)
+	searchService := NewSearchService(search.NewMemoryIndex(), store.Users(), store.Posts(), store.Comments(), zap.NewNop())
+	m := &recordingMailer{}
+	accounts := NewAccountService(store.Users(), store.Posts(), store.Comments(), tokens, NewPersonalTokenService(store.PersonalTokens()), searchService, NewUserCache(store.Users(), time.Minute),
+		utils.NewActionTokenSigner("test-secret-0123456789abcdefghijklmnopqrstuvwxyz"), m, cfg)
+	return accounts, m
+}
+
+func TestAccountService_ExpiredTokens(t *testing.T) {
+	ctx := context.Background()
+	accounts, m, users := newTestAccountService(t, -time.Second)
+	user, err := users.FindByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("FindByUsername: %v", err)
+	}
+
+	if err := accounts.SendVerification(ctx, user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	if _, err := accounts.VerifyEmail(ctx, m.lastToken(t)); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("expired verification token: got %v, want ErrInvalidActionToken", err)
+	}
+	if err := accounts.ForgotPassword(ctx, user.Email); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	if err := accounts.ResetPassword(ctx, m.lastToken(t), "newpassword"); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("expired reset token: got %v, want ErrInvalidActionToken", err)
+	}
+}
+
+func TestAccountService_TokensBoundToAccountState(t *testing.T) {
+	ctx := context.Background()
+	accounts, m, users := newTestAccountService(t, time.Hour)
+	user, err := users.FindByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("FindByUsername: %v", err)
+	}
+
+	if err := accounts.SendVerification(ctx, user); err != nil {
+		t.Fatalf("SendVerification: %v", err)
+	}
+	if got := m.sent[0].Body; !regexp.MustCompile(`https://blog\.example\.com/verify-email\?token=`).MatchString(got) {
+		t.Fatalf("verification link: %q", got)
+	}
+	verifyToken := m.lastToken(t)
+
+	// 修改邮箱后，发往旧邮箱的验证链接失效
+	user.Email = "alice@example.org"
+	if err := users.Update(ctx, user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := accounts.VerifyEmail(ctx, verifyToken); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("token for old email: got %v, want ErrInvalidActionToken", err)
+	}
+
+	// 同时发出的两封重置邮件，任意一个使用后另一个也失效
+	for i := 0; i < 2; i++ {
+		if err := accounts.ForgotPassword(ctx, user.Email); err != nil {
+			t.Fatalf("ForgotPassword: %v", err)
+		}
+	}
+	second := m.lastToken(t)
+	m.sent = m.sent[:len(m.sent)-1]
+	first := m.lastToken(t)
+	if err := accounts.ResetPassword(ctx, first, "newpassword"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if err := accounts.ResetPassword(ctx, second, "otherpassword"); !errors.Is(err, ErrInvalidActionToken) {
+		t.Fatalf("second reset token: got %v, want ErrInvalidActionToken", err)
+	}
+
+	updated, err := users.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if updated.CheckPassword("newpassword") != nil {
+		t.Fatal("password not updated")
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AuthService 注册、登录和用户信息
+// bcryptCost 为新密码哈希使用的 cost，登录时发现旧哈希的 cost 不同会重新哈希
+type AuthService struct {
+	users      repository.UserRepository
+	tokens     *TokenService
+	twoFactor  *TwoFactorService
+	guard      *LoginGuard
+	bcryptCost int
+}
+
+func NewAuthService(users repository.UserRepository, tokens *TokenService, twoFactor *TwoFactorService, guard *LoginGuard, bcryptCost int) *AuthService {
+	return &AuthService{users: users, tokens: tokens, twoFactor: twoFactor, guard: guard, bcryptCost: bcryptCost}
+}
+
+// LoginInput 登录参数，客户端 IP 用于防暴力破解计数，并和 User-Agent 一起记录在会话中
+type LoginInput struct {
+	Username string
+	Password string
+	ClientInfo
+}
+
+// LoginResult 登录结果：启用了两步验证时只有 Challenge，需要再提交验证码；否则只有 Tokens
+type LoginResult struct {
+	Tokens    *TokenPair
+	User      *models.User
+	Challenge *TwoFactorChallenge
+}
+
+// RegisterInput 注册参数
+type RegisterInput struct {
+	Username string
+	Email    string
+	Password string
+}
+
+// Register 注册新用户，用户名和邮箱都必须未被使用
+func (s *AuthService) Register(ctx context.Context, input RegisterInput) (*models.User, error) {
+	if err := s.ensureAvailable(ctx, input.Username, input.Email); err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username: input.Username,
+		Email:    input.Email,
+		Role:     string(rbac.DefaultRole),
+	}
+	if err := user.HashPasswordWithCost(input.Password, s.bcryptCost); err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ensureAvailable 检查用户名和邮箱是否已存在
+func (s *AuthService) ensureAvailable(ctx context.Context, username, email string) error {
+	if _, err := s.users.FindByUsername(ctx, username); err == nil {
+		return ErrUsernameTaken
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	if _, err := s.users.FindByEmail(ctx, email); err == nil {
+		return ErrEmailTaken
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Login 校验用户名和密码，成功后签发令牌；启用了两步验证时返回 challenge，由 TwoFactorService.CompleteLogin 完成登录
+// 连续失败过多时返回 *LoginThrottledError，此时不再校验密码
+func (s *AuthService) Login(ctx context.Context, input LoginInput) (*LoginResult, error) {
+	attempt, err := s.guard.Begin(ctx, input.Username, input.IP)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.authenticate(ctx, input)
+	if errors.Is(err, ErrInvalidCredentials) {
+		if failErr := attempt.Fail(ctx); failErr != nil {
+			return nil, failErr
+		}
+		return nil, err
+	}
+	// 账号被禁用、读写数据库出错等不计为失败
+	if releaseErr := attempt.Release(ctx); releaseErr != nil {
+		return nil, releaseErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 失败计数在两步登录完成后才清除，否则知道密码的人可以反复登录来重置验证码的失败次数
+	if user.TwoFactorEnabled() {
+		challenge, err := s.twoFactor.Challenge(user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, Challenge: challenge}, nil
+	}
+	if err := s.guard.Succeed(ctx, user.Username); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.tokens.Issue(ctx, user, input.ClientInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens, User: user}, nil
+}
+
+// authenticate 校验用户名和密码，用户名不存在或密码错误时返回 ErrInvalidCredentials
+func (s *AuthService) authenticate(ctx context.Context, input LoginInput) (*models.User, error) {
+	user, err := s.users.FindByUsername(ctx, input.Username)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if err := user.CheckPassword(input.Password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	// 密码正确之后再检查禁用状态，避免泄露账号是否存在
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+	// 修改 bcrypt cost 后，只有在登录时拿到明文密码才能按新的 cost 重新哈希；需在签发两步登录的 challenge 之前完成
+	if user.NeedsRehash(s.bcryptCost) {
+		if err := user.HashPasswordWithCost(input.Password, s.bcryptCost); err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		if err := s.users.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+	return user, nil
+}
+
+// Profile 获取用户信息
+func (s *AuthService) Profile(ctx context.Context, userID uint) (*models.User, error) {
+	return s.users.FindByID(ctx, userID)
+}
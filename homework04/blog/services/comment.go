@@ -0,0 +1,74 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"context"
+)
+
+// CommentService 评论业务规则：只能评论已发布的文章，删除评论受 rbac 策略约束
+type CommentService struct {
+	posts    repository.PostRepository
+	comments repository.CommentRepository
+	search   *SearchService
+}
+
+func NewCommentService(posts repository.PostRepository, comments repository.CommentRepository, search *SearchService) *CommentService {
+	return &CommentService{posts: posts, comments: comments, search: search}
+}
+
+// Create 为文章创建评论，文章不存在或未发布时返回 ErrNotFound
+func (s *CommentService) Create(ctx context.Context, userID, postID uint, content string) (*models.Comment, error) {
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.Status != models.PostStatusPublished {
+		return nil, ErrNotFound
+	}
+
+	comment := &models.Comment{
+		Content: content,
+		UserID:  userID,
+		PostID:  postID,
+	}
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	// 评论已经保存，索引失败时稍后重试，不返回错误以免客户端重复提交
+	if err := s.search.IndexComment(ctx, comment); err != nil {
+		s.search.RetryComment(comment.ID, err)
+	}
+	return comment, nil
+}
+
+// ListByPost 获取文章的评论，按创建时间倒序；文章对 viewerID 不可见时返回 ErrNotFound，见 PostService.Get
+func (s *CommentService) ListByPost(ctx context.Context, viewerID, postID uint) ([]models.Comment, error) {
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if !post.VisibleTo(viewerID) {
+		return nil, ErrNotFound
+	}
+	return s.comments.ListByPost(ctx, postID)
+}
+
+// Delete 删除评论，见 rbac.CanDeleteComment
+func (s *CommentService) Delete(ctx context.Context, actor rbac.Actor, commentID uint) error {
+	comment, err := s.comments.FindByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if !rbac.CanDeleteComment(actor, comment) {
+		return ErrForbidden
+	}
+	if err := s.comments.Delete(ctx, commentID); err != nil {
+		return err
+	}
+	if err := s.search.RemoveComment(ctx, commentID); err != nil {
+		s.search.RetryComment(commentID, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"blog/repository"
+	"errors"
+)
+
+var (
+	// ErrNotFound 资源不存在
+	ErrNotFound = repository.ErrNotFound
+	// ErrForbidden 当前用户无权操作该资源
+	ErrForbidden = errors.New("forbidden")
+	// ErrUsernameTaken 用户名已被注册
+	ErrUsernameTaken = errors.New("username already exists")
+	// ErrEmailTaken 邮箱已被注册
+	ErrEmailTaken = errors.New("email already exists")
+	// ErrInvalidCredentials 用户名或密码错误
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	// ErrIncorrectPassword 已登录用户修改密码、修改邮箱或注销账号时输入的当前密码错误
+	ErrIncorrectPassword = errors.New("incorrect password")
+	// ErrInvalidRefreshToken 刷新令牌不存在、已过期或不属于当前用户
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrInvalidRole 角色不存在
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrOwnRole 管理员不能修改自己的角色，避免系统失去最后一个管理员
+	ErrOwnRole = errors.New("cannot change own role")
+	// ErrOwnAccount 管理员不能禁用自己的账号
+	ErrOwnAccount = errors.New("cannot disable own account")
+	// ErrAccountDisabled 账号已被管理员禁用
+	ErrAccountDisabled = errors.New("account disabled")
+	// ErrInvalidActionToken 邮箱验证或重置密码令牌无效、已过期或已使用
+	ErrInvalidActionToken = errors.New("invalid or expired token")
+	// ErrEmailAlreadyVerified 邮箱已经验证过
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+	// ErrTwoFactorEnabled 已经启用了两步验证
+	ErrTwoFactorEnabled = errors.New("two-factor authentication already enabled")
+	// ErrTwoFactorNotEnabled 没有启用两步验证（或还没有生成密钥）
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication not enabled")
+	// ErrTwoFactorRequired 角色要求两步验证，不能关闭，未启用时只能访问启用两步验证的接口
+	ErrTwoFactorRequired = errors.New("two-factor authentication required")
+	// ErrInvalidTwoFactorCode 验证码或恢复码错误、已使用
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge 两步登录的 challenge 令牌无效或已过期
+	ErrInvalidChallenge = errors.New("invalid or expired challenge")
+	// ErrLoginThrottled 连续登录失败后被延迟或锁定，具体等待时间见 *LoginThrottledError
+	ErrLoginThrottled = errors.New("too many failed login attempts")
+	// ErrInvalidScope 个人访问令牌的 scope 为空或不存在
+	ErrInvalidScope = errors.New("invalid scope")
+	// ErrInvalidExpiration 个人访问令牌的过期时间早于当前时间
+	ErrInvalidExpiration = errors.New("expiration must be in the future")
+	// ErrCategoryNotFound 文章或子分类引用的分类不存在
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrCategoryExists 分类名称已被使用
+	ErrCategoryExists = errors.New("category already exists")
+	// ErrInvalidParent 分类不能移动到自身或其子分类下
+	ErrInvalidParent = errors.New("category cannot be moved under itself or its subcategories")
+	// ErrCategoryHasChildren 分类下还有子分类，不能删除
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	// ErrInvalidSchedule 定时发布缺少发布时间，或发布时间不在将来
+	ErrInvalidSchedule = errors.New("scheduled posts require a future published_at")
+	// ErrInvalidStatus 文章状态不存在
+	ErrInvalidStatus = errors.New("invalid post status")
+	// ErrInvalidSlug 作者指定的 slug 中没有可用的字符
+	ErrInvalidSlug = errors.New("invalid slug")
+	// ErrSlugTaken slug 已被其他文章使用，包括其他文章用过的旧 slug
+	ErrSlugTaken = errors.New("slug already in use")
+	// ErrInvalidContentFormat 不支持的正文格式
+	ErrInvalidContentFormat = errors.New("invalid content format")
+	// ErrRevisionNotFound 文章没有该版本
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrRefreshTokenReused 已轮换的刷新令牌被再次使用，整个 family 已被吊销
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// keyReloadMinInterval 遇到未知 kid 时重新加载密钥的最小间隔，避免伪造的 kid 打满数据库
+	keyReloadMinInterval = 10 * time.Second
+	// keyReloadTimeout 按需重新加载密钥的超时时间
+	keyReloadTimeout = 5 * time.Second
+)
+
+// KeyService 管理 RS256/EdDSA 签名密钥：生成、加密保存、按计划轮换，并把密钥交给 JWTManager
+// 多个实例共享数据库中的密钥；某个实例轮换后，其他实例在下一次检查或遇到新 kid 时重新加载
+type KeyService struct {
+	keys       repository.KeyRepository
+	jwtManager *utils.JWTManager
+	algorithm  string
+	rotation   time.Duration
+	retention  time.Duration
+	box        *utils.SecretBox
+
+	mu         sync.Mutex
+	lastReload time.Time
+}
+
+// NewKeyService 创建密钥服务；retention 为退役密钥继续用于验证的时间，应不短于访问令牌有效期
+func NewKeyService(keys repository.KeyRepository, jwtManager *utils.JWTManager, cfg config.JWTConfig, retention time.Duration) (*KeyService, error) {
+	// 私钥加密密钥由 jwt.secret 派生，数据库泄露时私钥不会直接暴露
+	box, err := utils.NewSecretBox(cfg.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key cipher: %w", err)
+	}
+
+	s := &KeyService{
+		keys:       keys,
+		jwtManager: jwtManager,
+		algorithm:  cfg.Algorithm,
+		rotation:   cfg.KeyRotation.Std(),
+		retention:  retention,
+		box:        box,
+	}
+	if cfg.Asymmetric() {
+		jwtManager.OnUnknownKey(s.reloadOnUnknownKey)
+	}
+	return s, nil
+}
+
+// Enabled 是否使用非对称密钥；HS256 时 KeyService 不做任何事
+func (s *KeyService) Enabled() bool {
+	return s.algorithm == config.JWTAlgorithmRS256 || s.algorithm == config.JWTAlgorithmEdDSA
+}
+
+// Ensure 加载密钥；没有签名密钥、签名密钥到了轮换时间或配置的算法发生变化时生成新密钥
+func (s *KeyService) Ensure(ctx context.Context) error {
+	if !s.Enabled() {
+		return nil
+	}
+	signing, err := s.Reload(ctx)
+	if err != nil {
+		return err
+	}
+	if signing != nil && signing.Algorithm == s.algorithm && time.Since(signing.CreatedAt) < s.rotation {
+		return nil
+	}
+	return s.Rotate(ctx)
+}
+
+// Rotate 生成新的签名密钥，其余密钥退役为只验证
+func (s *KeyService) Rotate(ctx context.Context) error {
+	key, err := utils.GenerateSigningKey(s.algorithm)
+	if err != nil {
+		return err
+	}
+	privateDER, err := key.MarshalPrivateKey()
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+	publicPEM, err := key.MarshalPublicKey()
+	if err != nil {
+		return fmt.Errorf("failed to encode public key: %w", err)
+	}
+	encrypted, err := s.box.Seal(privateDER)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = s.keys.CreateSigningKey(ctx, &models.SigningKey{
+		KID:        key.ID,
+		Algorithm:  key.Algorithm,
+		PrivateKey: encrypted,
+		PublicKey:  publicPEM,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store signing key: %w", err)
+	}
+	// 多个实例同时轮换时会各自生成一个密钥，后退役的一方会把先生成的密钥退役，两者都仍可用于验证
+	if err := s.keys.RetireSigningKeys(ctx, key.ID, now, now.Add(s.retention)); err != nil {
+		return fmt.Errorf("failed to retire signing keys: %w", err)
+	}
+	_, err = s.Reload(ctx)
+	return err
+}
+
+// Reload 从存储中重新加载密钥，返回当前的签名密钥（最新的未退役密钥），没有时返回 nil
+func (s *KeyService) Reload(ctx context.Context) (*models.SigningKey, error) {
+	stored, err := s.keys.ListSigningKeys(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	var (
+		signing       *utils.SigningKey
+		signingRecord *models.SigningKey
+		verifyOnly    []*utils.SigningKey
+	)
+	for i := range stored {
+		record := &stored[i]
+		key, err := s.decode(record)
+		if err != nil {
+			return nil, err
+		}
+		if signing == nil && record.RetiredAt == nil {
+			signing, signingRecord = key, record
+			continue
+		}
+		verifyOnly = append(verifyOnly, key)
+	}
+
+	s.jwtManager.SetKeys(signing, verifyOnly)
+	s.mu.Lock()
+	s.lastReload = time.Now()
+	s.mu.Unlock()
+	return signingRecord, nil
+}
+
+// reloadOnUnknownKey 遇到未知 kid 时按需重新加载，限制频率
+func (s *KeyService) reloadOnUnknownKey() {
+	s.mu.Lock()
+	if time.Since(s.lastReload) < keyReloadMinInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastReload = time.Now()
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), keyReloadTimeout)
+	defer cancel()
+	// 失败时保留当前密钥，令牌按未知 kid 处理
+	_, _ = s.Reload(ctx)
+}
+
+func (s *KeyService) decode(record *models.SigningKey) (*utils.SigningKey, error) {
+	// jwt.secret 被修改后旧密钥无法解密，需要清空 signing_keys 表
+	privateDER, err := s.box.Open(record.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt signing key %s: %w", record.KID, err)
+	}
+	return utils.ParseSigningKey(record.KID, record.Algorithm, privateDER)
+}
+
+// RotationWorker 定期检查是否需要轮换密钥，加载其他实例轮换出的密钥并清理过期密钥
+func (s *KeyService) RotationWorker(interval time.Duration, logger *zap.Logger) *KeyRotationWorker {
+	return &KeyRotationWorker{keys: s, interval: interval, logger: logger}
+}
+
+// KeyRotationWorker 见 KeyService.RotationWorker
+type KeyRotationWorker struct {
+	keys     *KeyService
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func (w *KeyRotationWorker) Name() string { return "jwt-key-rotation" }
+
+func (w *KeyRotationWorker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := w.keys.Ensure(ctx); err != nil {
+				// 轮换失败时继续使用当前密钥，下个周期重试
+				w.logger.Warn("Failed to rotate signing keys", zap.Error(err))
+				continue
+			}
+			deleted, err := w.keys.keys.DeleteExpiredSigningKeys(ctx, time.Now())
+			if err != nil {
+				w.logger.Warn("Failed to delete expired signing keys", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				w.logger.Info("Deleted expired signing keys", zap.Int64("count", deleted))
+			}
+		}
+	}
+}
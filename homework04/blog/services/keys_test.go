@@ -0,0 +1,196 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestKeyService(t *testing.T, algorithm string) (*KeyService, *utils.JWTManager, *repository.MemoryStore) {
+	t.Helper()
+	cfg := config.JWTConfig{
+		Secret:      "test-secret-0123456789abcdefghijklmnopqrstuvwxyz",
+		Issuer:      "blog",
+		Audience:    "blog-api",
+		Algorithm:   algorithm,
+		Expiration:  config.Duration(time.Minute),
+		KeyRotation: config.Duration(time.Hour),
+	}
+	store := repository.NewMemoryStore()
+	jwtManager := utils.NewJWTManager(cfg)
+	keys, err := NewKeyService(store.Keys(), jwtManager, cfg, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyService: %v", err)
+	}
+	return keys, jwtManager, store
+}
+
+func TestKeyService_RotationKeepsOldTokensValid(t *testing.T) {
+	for _, algorithm := range []string{config.JWTAlgorithmEdDSA, config.JWTAlgorithmRS256} {
+		algorithm := algorithm
+		t.Run(algorithm, func(t *testing.T) {
+			ctx := context.Background()
+			keys, jwtManager, store := newTestKeyService(t, algorithm)
+			if err := keys.Ensure(ctx); err != nil {
+				t.Fatalf("Ensure: %v", err)
+			}
+			user := &models.User{ID: 1, Username: "alice", Role: "author"}
+			before, err := jwtManager.GenerateToken(user, "")
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+
+			// 未到轮换时间时 Ensure 不生成新密钥
+			if err := keys.Ensure(ctx); err != nil {
+				t.Fatalf("Ensure: %v", err)
+			}
+			if got := len(jwtManager.JWKS().Keys); got != 1 {
+				t.Fatalf("keys after second Ensure: got %d, want 1", got)
+			}
+
+			if err := keys.Rotate(ctx); err != nil {
+				t.Fatalf("Rotate: %v", err)
+			}
+			after, err := jwtManager.GenerateToken(user, "")
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			if kid(t, before) == kid(t, after) {
+				t.Fatal("token signed after rotation uses the old key")
+			}
+			for name, token := range map[string]string{"before": before, "after": after} {
+				if _, err := jwtManager.ParseToken(token); err != nil {
+					t.Fatalf("ParseToken(%s rotation): %v", name, err)
+				}
+			}
+
+			set := jwtManager.JWKS()
+			if len(set.Keys) != 2 || set.Keys[0].Kid != kid(t, after) {
+				t.Fatalf("JWKS: got %+v, want the new signing key first", set.Keys)
+			}
+
+			// 另一个实例从同一存储加载密钥后能验证这两个令牌
+			other := utils.NewJWTManager(config.JWTConfig{Issuer: "blog", Audience: "blog-api", Algorithm: algorithm, Expiration: config.Duration(time.Minute)})
+			otherKeys, err := NewKeyService(store.Keys(), other, config.JWTConfig{Secret: "test-secret-0123456789abcdefghijklmnopqrstuvwxyz", Algorithm: algorithm, KeyRotation: config.Duration(time.Hour)}, time.Hour)
+			if err != nil {
+				t.Fatalf("NewKeyService: %v", err)
+			}
+			if err := otherKeys.Ensure(ctx); err != nil {
+				t.Fatalf("Ensure on other instance: %v", err)
+			}
+			if _, err := other.ParseToken(before); err != nil {
+				t.Fatalf("other instance ParseToken: %v", err)
+			}
+
+			// 退役密钥过期清理后，它签发的令牌不再有效
+			if _, err := store.Keys().DeleteExpiredSigningKeys(ctx, time.Now().Add(2*time.Hour)); err != nil {
+				t.Fatalf("DeleteExpiredSigningKeys: %v", err)
+			}
+			if _, err := keys.Reload(ctx); err != nil {
+				t.Fatalf("Reload: %v", err)
+			}
+			if _, err := jwtManager.ParseToken(before); err == nil {
+				t.Fatal("token signed by an expired key is still accepted")
+			}
+		})
+	}
+}
+
+func TestJWTManager_RejectsForgedTokens(t *testing.T) {
+	keys, jwtManager, _ := newTestKeyService(t, config.JWTAlgorithmEdDSA)
+	if err := keys.Ensure(context.Background()); err != nil {
+		t.Fatalf("Ensure: %v", err)
+	}
+	signing := jwtManager.JWKS().Keys[0].Kid
+
+	claims := func(issuer, audience string) *utils.Claims {
+		now := time.Now()
+		return &utils.Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{
+			Issuer: issuer, Audience: jwt.ClaimStrings{audience},
+			IssuedAt: jwt.NewNumericDate(now), ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}}
+	}
+	hs256 := func(c *utils.Claims, kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString([]byte("test-secret-0123456789abcdefghijklmnopqrstuvwxyz"))
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return signed
+	}
+	eddsa := func(c *utils.Claims) string {
+		key, err := utils.GenerateSigningKey(config.JWTAlgorithmEdDSA)
+		if err != nil {
+			t.Fatalf("GenerateSigningKey: %v", err)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, c)
+		token.Header["kid"] = signing
+		signed, err := token.SignedString(key.Private)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return signed
+	}
+	none := func(c *utils.Claims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, c)
+		token.Header["kid"] = signing
+		signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return signed
+	}
+
+	tests := map[string]string{
+		"hs256 with the secret":    hs256(claims("blog", "blog-api"), signing),
+		"hs256 with hs256 kid":     hs256(claims("blog", "blog-api"), "hs256"),
+		"alg none":                 none(claims("blog", "blog-api")),
+		"signed by an unknown key": eddsa(claims("blog", "blog-api")),
+		"garbage":                  "not.a.token",
+	}
+	for name, token := range tests {
+		if _, err := jwtManager.ParseToken(token); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+
+	// 签名正确但签发者或受众不符
+	user := &models.User{ID: 1}
+	for name, cfg := range map[string]config.JWTConfig{
+		"wrong issuer":   {Issuer: "other", Audience: "blog-api", Algorithm: config.JWTAlgorithmEdDSA, Expiration: config.Duration(time.Minute)},
+		"wrong audience": {Issuer: "blog", Audience: "other-api", Algorithm: config.JWTAlgorithmEdDSA, Expiration: config.Duration(time.Minute)},
+	} {
+		issuer := utils.NewJWTManager(cfg)
+		issuerKeys, err := NewKeyService(keys.keys, issuer, config.JWTConfig{Secret: "test-secret-0123456789abcdefghijklmnopqrstuvwxyz", Algorithm: config.JWTAlgorithmEdDSA, KeyRotation: config.Duration(time.Hour)}, time.Hour)
+		if err != nil {
+			t.Fatalf("NewKeyService: %v", err)
+		}
+		if _, err := issuerKeys.Reload(context.Background()); err != nil {
+			t.Fatalf("Reload: %v", err)
+		}
+		token, err := issuer.GenerateToken(user, "")
+		if err != nil {
+			t.Fatalf("GenerateToken: %v", err)
+		}
+		if _, err := jwtManager.ParseToken(token); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
+
+func kid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &utils.Claims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// defaultLoginEventLimit 查询锁定事件时的默认条数
+const defaultLoginEventLimit = 50
+
+// LoginGuardConfig 见 config.LockoutConfig
+type LoginGuardConfig struct {
+	MaxAttempts     int
+	IPMaxAttempts   int
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	Window          time.Duration
+	LockoutDuration time.Duration
+}
+
+// LoginThrottledError 登录因连续失败被延迟或锁定，RetryAfter 后可以重试
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	// Locked 为 true 表示达到失败上限被锁定，否则只是两次尝试间隔太短
+	Locked bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("login locked, retry after %s", e.RetryAfter)
+	}
+	return fmt.Sprintf("login delayed, retry after %s", e.RetryAfter)
+}
+
+func (e *LoginThrottledError) Is(target error) bool { return target == ErrLoginThrottled }
+
+// LoginGuard 防暴力破解：按用户名和 IP 统计连续登录失败（包括两步登录的验证码错误），
+// 失败次数增加后要求逐渐拉长重试间隔，达到上限后临时锁定，并记录锁定和解锁事件
+// 不存在的用户名同样计数，响应中不会暴露账号是否存在
+type LoginGuard struct {
+	attempts repository.LoginAttemptRepository
+	users    repository.UserRepository
+	cfg      LoginGuardConfig
+	now      func() time.Time
+}
+
+func NewLoginGuard(attempts repository.LoginAttemptRepository, users repository.UserRepository, cfg LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{attempts: attempts, users: users, cfg: cfg, now: time.Now}
+}
+
+func userSubject(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
+
+// maxSwapAttempts 并发修改同一个计数时比较并交换的最大尝试次数
+const maxSwapAttempts = 10
+
+// LoginAttempt 一次登录尝试预占的失败计数，见 LoginGuard.Begin
+type LoginAttempt struct {
+	guard        *LoginGuard
+	ip           string
+	reservations []reservation
+}
+
+// reservation 预占的一个计数
+type reservation struct {
+	subject     string
+	maxAttempts int
+	// previous 预占前的计数，nil 表示没有记录
+	previous *models.LoginCounter
+	// counter 预占后的计数
+	counter models.LoginCounter
+}
+
+// Begin 在校验密码之前调用：用户名或 IP 处于锁定或延迟中时返回 *LoginThrottledError，
+// 否则先把这次尝试计为一次失败，检查和计数是一次比较并交换，并发的尝试不能都跳过延迟
+// 校验失败后调用 LoginAttempt.Fail，校验通过或因其他原因没有完成校验时调用 LoginAttempt.Release 撤销计数
+func (g *LoginGuard) Begin(ctx context.Context, username, ip string) (*LoginAttempt, error) {
+	attempt := &LoginAttempt{guard: g, ip: ip}
+	subjects := []struct {
+		subject     string
+		maxAttempts int
+	}{
+		{userSubject(username), g.cfg.MaxAttempts},
+		{ipSubject(ip), g.cfg.IPMaxAttempts},
+	}
+	for _, s := range subjects {
+		r, err := g.reserve(ctx, s.subject)
+		if err != nil {
+			if releaseErr := attempt.Release(ctx); releaseErr != nil {
+				return nil, releaseErr
+			}
+			return nil, err
+		}
+		r.maxAttempts = s.maxAttempts
+		attempt.reservations = append(attempt.reservations, *r)
+	}
+	return attempt, nil
+}
+
+// counter 读取计数，没有记录时返回 nil
+func (g *LoginGuard) counter(ctx context.Context, subject string) (*models.LoginCounter, error) {
+	counter, err := g.attempts.FindLoginCounter(ctx, subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login attempts: %w", err)
+	}
+	return counter, nil
+}
+
+// reserve 没有处于限制中时把计数加一
+func (g *LoginGuard) reserve(ctx context.Context, subject string) (*reservation, error) {
+	for i := 0; i < maxSwapAttempts; i++ {
+		now := g.now()
+		previous, err := g.counter(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		next := models.LoginCounter{Subject: subject, Failures: 1, LastFailureAt: now}
+		if previous != nil {
+			if throttled := g.throttle(previous, now); throttled != nil {
+				return nil, throttled
+			}
+			next.LockedUntil = previous.LockedUntil
+			// 距上次失败超过 Window 的计数已经作废，从 1 重新计数
+			if now.Sub(previous.LastFailureAt) <= g.cfg.Window {
+				next.Failures = previous.Failures + 1
+			}
+		}
+		swapped, err := g.attempts.SwapLoginCounter(ctx, subject, previous, &next)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record login attempt: %w", err)
+		}
+		if swapped {
+			return &reservation{subject: subject, previous: previous, counter: next}, nil
+		}
+	}
+	// 同一个用户名或 IP 上的并发尝试太多，按延迟处理
+	return nil, &LoginThrottledError{RetryAfter: time.Second}
+}
+
+// release 撤销预占的计数：期间没有其他尝试时恢复原来的计数（包括上次失败的时间），否则只把失败次数减一
+func (g *LoginGuard) release(ctx context.Context, r reservation) error {
+	swapped, err := g.attempts.SwapLoginCounter(ctx, r.subject, &r.counter, r.previous)
+	if err != nil {
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+	for i := 0; !swapped && i < maxSwapAttempts; i++ {
+		current, err := g.counter(ctx, r.subject)
+		if err != nil {
+			return err
+		}
+		// 计数已被清除（登录成功或管理员解锁）
+		if current == nil || current.Failures == 0 {
+			return nil
+		}
+		next := *current
+		next.Failures--
+		if swapped, err = g.attempts.SwapLoginCounter(ctx, r.subject, current, &next); err != nil {
+			return fmt.Errorf("failed to release login attempt: %w", err)
+		}
+	}
+	// 仍然没有撤销成功时保留这次计数，相当于多记了一次失败
+	return nil
+}
+
+// throttle 计数对应的等待时间，无需等待时返回 nil
+func (g *LoginGuard) throttle(counter *models.LoginCounter, now time.Time) *LoginThrottledError {
+	if counter.LockedUntil != nil && now.Before(*counter.LockedUntil) {
+		return &LoginThrottledError{RetryAfter: counter.LockedUntil.Sub(now), Locked: true}
+	}
+	// 距上次失败超过 Window 的计数已经作废
+	if now.Sub(counter.LastFailureAt) > g.cfg.Window {
+		return nil
+	}
+	if until := counter.LastFailureAt.Add(g.delay(counter.Failures)); now.Before(until) {
+		return &LoginThrottledError{RetryAfter: until.Sub(now)}
+	}
+	return nil
+}
+
+// delay 连续失败 failures 次后下一次尝试前需要等待的时间：超过 FreeAttempts 后从 BaseDelay 开始逐次翻倍
+func (g *LoginGuard) delay(failures int) time.Duration {
+	extra := failures - g.cfg.FreeAttempts
+	if extra <= 0 || g.cfg.BaseDelay <= 0 {
+		return 0
+	}
+	delay := g.cfg.BaseDelay
+	for i := 1; i < extra && delay < g.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.cfg.MaxDelay {
+		delay = g.cfg.MaxDelay
+	}
+	return delay
+}
+
+// Fail 校验失败：预占的计数就是这次失败，达到上限时锁定并记录事件
+func (a *LoginAttempt) Fail(ctx context.Context) error {
+	g := a.guard
+	now := g.now()
+	reservations := a.reservations
+	a.reservations = nil
+	for _, r := range reservations {
+		counter := r.counter
+		if counter.Failures < r.maxAttempts || counter.LockedUntil != nil && now.Before(*counter.LockedUntil) {
+			continue
+		}
+		// 锁定期满后计数未过期时，再失败一次就重新锁定
+		until := now.Add(g.cfg.LockoutDuration)
+		if err := g.attempts.LockLogin(ctx, r.subject, until); err != nil {
+			return fmt.Errorf("failed to lock login: %w", err)
+		}
+		event := &models.LoginEvent{
+			Type:        models.LoginEventLockout,
+			Subject:     r.subject,
+			IP:          a.ip,
+			Failures:    counter.Failures,
+			LockedUntil: &until,
+			CreatedAt:   now,
+		}
+		if strings.HasPrefix(r.subject, "user:") {
+			event.Username = strings.TrimPrefix(r.subject, "user:")
+		}
+		if err := g.attempts.CreateLoginEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to record lockout: %w", err)
+		}
+	}
+	return nil
+}
+
+// Release 撤销这次尝试预占的计数
+func (a *LoginAttempt) Release(ctx context.Context) error {
+	reservations := a.reservations
+	a.reservations = nil
+	for _, r := range reservations {
+		if err := a.guard.release(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Succeed 登录成功后清除用户名的失败计数；IP 的计数不清除，避免用自己的账号给撞库的 IP 解除限制
+func (g *LoginGuard) Succeed(ctx context.Context, username string) error {
+	if err := g.attempts.ResetLoginCounter(ctx, userSubject(username)); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	return nil
+}
+
+// Unlock 管理员解除用户的登录锁定和失败计数；需要 user:manage 权限
+func (g *LoginGuard) Unlock(ctx context.Context, actor rbac.Actor, userID uint) (*models.User, error) {
+	if !actor.Can(rbac.UserManage) {
+		return nil, ErrForbidden
+	}
+	user, err := g.users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	subject := userSubject(user.Username)
+	counter, err := g.attempts.FindLoginCounter(ctx, subject)
+	if errors.Is(err, repository.ErrNotFound) {
+		return user, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login attempts: %w", err)
+	}
+	if err := g.attempts.ResetLoginCounter(ctx, subject); err != nil {
+		return nil, fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+
+	// 只有确实处于锁定时才记录解锁事件
+	now := g.now()
+	if counter.LockedUntil != nil && now.Before(*counter.LockedUntil) {
+		actorID := actor.UserID
+		err := g.attempts.CreateLoginEvent(ctx, &models.LoginEvent{
+			Type:      models.LoginEventUnlock,
+			Subject:   subject,
+			Username:  strings.TrimPrefix(subject, "user:"),
+			Failures:  counter.Failures,
+			ActorID:   &actorID,
+			CreatedAt: now,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to record unlock: %w", err)
+		}
+	}
+	return user, nil
+}
+
+// Events 最近的锁定和解锁事件；需要 user:manage 权限
+func (g *LoginGuard) Events(ctx context.Context, actor rbac.Actor, filter repository.LoginEventFilter) ([]models.LoginEvent, error) {
+	if !actor.Can(rbac.UserManage) {
+		return nil, ErrForbidden
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultLoginEventLimit
+	}
+	filter.Username = strings.ToLower(strings.TrimSpace(filter.Username))
+	return g.attempts.ListLoginEvents(ctx, filter)
+}
+
+// CleanupWorker 定期清理已过期且未锁定的失败计数
+func (g *LoginGuard) CleanupWorker(interval time.Duration, logger *zap.Logger) *LoginAttemptCleanupWorker {
+	return &LoginAttemptCleanupWorker{guard: g, interval: interval, logger: logger}
+}
+
+// LoginAttemptCleanupWorker 见 LoginGuard.CleanupWorker
+type LoginAttemptCleanupWorker struct {
+	guard    *LoginGuard
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func (w *LoginAttemptCleanupWorker) Name() string { return "login-attempt-cleanup" }
+
+func (w *LoginAttemptCleanupWorker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			now := w.guard.now()
+			deleted, err := w.guard.attempts.DeleteStaleLoginCounters(ctx, now.Add(-w.guard.cfg.Window), now)
+			if err != nil {
+				// 清理失败不影响服务，下个周期重试
+				w.logger.Warn("Failed to delete stale login attempts", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				w.logger.Info("Deleted stale login attempts", zap.Int64("count", deleted))
+			}
+		}
+	}
+}
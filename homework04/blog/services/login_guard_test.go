@@ -0,0 +1,200 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestLoginGuard 用户名失败 5 次、IP 失败 8 次锁定 15 分钟，前 2 次失败不延迟；返回可以拨动的时钟
+func newTestLoginGuard(t *testing.T) (*LoginGuard, *time.Time, *models.User) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	user := &models.User{Username: "alice", Email: "alice@example.com", Role: "author"}
+	if err := store.Users().Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	guard := NewLoginGuard(store.LoginAttempts(), store.Users(), LoginGuardConfig{
+		MaxAttempts:     5,
+		IPMaxAttempts:   8,
+		FreeAttempts:    2,
+		BaseDelay:       time.Second,
+		MaxDelay:        4 * time.Second,
+		Window:          10 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	})
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+	return guard, &now, user
+}
+
+// retryAfter Begin 返回的等待时间，未被限制时为 0 并撤销这次尝试
+func retryAfter(t *testing.T, g *LoginGuard, username, ip string) (time.Duration, bool) {
+	t.Helper()
+	attempt, err := g.Begin(context.Background(), username, ip)
+	if err == nil {
+		if err := attempt.Release(context.Background()); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		return 0, false
+	}
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("Begin: %v", err)
+	}
+	return throttled.RetryAfter, throttled.Locked
+}
+
+// fail 记录一次失败的尝试
+func fail(t *testing.T, g *LoginGuard, username, ip string) {
+	t.Helper()
+	attempt, err := g.Begin(context.Background(), username, ip)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := attempt.Fail(context.Background()); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+}
+
+func TestLoginGuardProgressiveDelayAndLockout(t *testing.T) {
+	g, now, user := newTestLoginGuard(t)
+	ctx := context.Background()
+
+	wantDelays := []time.Duration{0, 0, time.Second, 2 * time.Second}
+	for i, want := range wantDelays {
+		fail(t, g, "Alice", "192.0.2.1")
+		got, locked := retryAfter(t, g, "alice", "192.0.2.2")
+		if got != want || locked {
+			t.Fatalf("after %d failures: retry after %s (locked %v), want %s", i+1, got, locked, want)
+		}
+		*now = now.Add(want)
+	}
+
+	// 第 5 次失败锁定
+	fail(t, g, "alice", "192.0.2.1")
+	if got, locked := retryAfter(t, g, "alice", "192.0.2.2"); got != 15*time.Minute || !locked {
+		t.Fatalf("after lockout: retry after %s (locked %v)", got, locked)
+	}
+	// 其他用户名不受影响
+	if got, _ := retryAfter(t, g, "bob", "192.0.2.2"); got != 0 {
+		t.Fatalf("bob is throttled for %s", got)
+	}
+
+	if _, err := g.Unlock(ctx, rbac.Actor{UserID: 2, Role: rbac.RoleEditor}, user.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Unlock as editor: got %v, want ErrForbidden", err)
+	}
+	if _, err := g.Unlock(ctx, rbac.Actor{UserID: 2, Role: rbac.RoleAdmin}, user.ID); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if got, _ := retryAfter(t, g, "alice", "192.0.2.2"); got != 0 {
+		t.Fatalf("after unlock: retry after %s", got)
+	}
+
+	events, err := g.Events(ctx, rbac.Actor{UserID: 2, Role: rbac.RoleAdmin}, repository.LoginEventFilter{Username: "alice"})
+	if err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if len(events) != 2 || events[0].Type != models.LoginEventUnlock || events[1].Type != models.LoginEventLockout || events[1].Failures != 5 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestLoginGuardIPLockout(t *testing.T) {
+	g, now, _ := newTestLoginGuard(t)
+	ctx := context.Background()
+
+	// 撞库：每个用户名只试一次，按 IP 计数
+	for i := 0; i < 8; i++ {
+		*now = now.Add(5 * time.Second)
+		fail(t, g, "user"+string(rune('a'+i)), "192.0.2.1")
+	}
+	if got, locked := retryAfter(t, g, "somebody", "192.0.2.1"); got != 15*time.Minute || !locked {
+		t.Fatalf("IP lockout: retry after %s (locked %v)", got, locked)
+	}
+	if got, _ := retryAfter(t, g, "somebody", "192.0.2.9"); got != 0 {
+		t.Fatalf("other IP is throttled for %s", got)
+	}
+
+	// 登录成功不清除 IP 的计数
+	if err := g.Succeed(ctx, "somebody"); err != nil {
+		t.Fatalf("Succeed: %v", err)
+	}
+	if _, locked := retryAfter(t, g, "somebody", "192.0.2.1"); !locked {
+		t.Fatal("IP lockout was cleared by a successful login")
+	}
+
+	// 锁定期满且距上次失败超过 window 后重新计数
+	*now = now.Add(15 * time.Minute)
+	if got, _ := retryAfter(t, g, "somebody", "192.0.2.1"); got != 0 {
+		t.Fatalf("after lockout expired: retry after %s", got)
+	}
+}
+
+// TestLoginGuardConcurrentAttempts 并发的尝试不能都跳过延迟：前 2 次失败不延迟，之后要等待，
+// 所以同一时刻最多只有 3 次尝试能进入密码校验
+func TestLoginGuardConcurrentAttempts(t *testing.T) {
+	g, _, _ := newTestLoginGuard(t)
+	ctx := context.Background()
+
+	var admitted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt, err := g.Begin(ctx, "alice", "192.0.2.1")
+			var throttled *LoginThrottledError
+			if errors.As(err, &throttled) {
+				return
+			}
+			if err != nil {
+				t.Errorf("Begin: %v", err)
+				return
+			}
+			admitted.Add(1)
+			if err := attempt.Fail(ctx); err != nil {
+				t.Errorf("Fail: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := admitted.Load(); got != 3 {
+		t.Fatalf("admitted %d concurrent attempts, want 3", got)
+	}
+}
+
+// TestLoginGuardReleaseRestoresCounter 校验通过的尝试不计数，也不推迟已有的延迟
+func TestLoginGuardReleaseRestoresCounter(t *testing.T) {
+	g, now, _ := newTestLoginGuard(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		fail(t, g, "alice", "192.0.2.1")
+	}
+	*now = now.Add(time.Second)
+	// 同一 IP 上另一个用户登录成功
+	attempt, err := g.Begin(ctx, "bob", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if err := attempt.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	counter, err := g.attempts.FindLoginCounter(ctx, ipSubject("192.0.2.1"))
+	if err != nil {
+		t.Fatalf("FindLoginCounter: %v", err)
+	}
+	if counter.Failures != 3 || !counter.LastFailureAt.Equal(now.Add(-time.Second)) {
+		t.Fatalf("IP counter after release: %+v", counter)
+	}
+	if _, err := g.attempts.FindLoginCounter(ctx, userSubject("bob")); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("bob counter after release: %v", err)
+	}
+}
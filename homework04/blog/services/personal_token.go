@@ -0,0 +1,133 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// personalTokenBytes 个人访问令牌的随机字节数
+	personalTokenBytes = 32
+	// personalTokenPrefixLength 列表中显示的令牌开头字符数（不含固定前缀）
+	personalTokenPrefixLength = 6
+	// personalTokenTouchInterval 最近使用时间的更新间隔，避免每个请求都写数据库
+	personalTokenTouchInterval = time.Minute
+)
+
+// PersonalTokenInput 创建个人访问令牌的参数
+type PersonalTokenInput struct {
+	Name   string
+	Scopes []rbac.Scope
+	// ExpiresAt 为 nil 时永不过期
+	ExpiresAt *time.Time
+}
+
+// PersonalTokenService 个人访问令牌的创建、列出、吊销和认证
+type PersonalTokenService struct {
+	tokens repository.PersonalTokenRepository
+	now    func() time.Time
+}
+
+func NewPersonalTokenService(tokens repository.PersonalTokenRepository) *PersonalTokenService {
+	return &PersonalTokenService{tokens: tokens, now: time.Now}
+}
+
+// Create 创建令牌，返回保存的记录和令牌明文；明文只在这里返回一次
+func (s *PersonalTokenService) Create(ctx context.Context, userID uint, input PersonalTokenInput) (*models.PersonalAccessToken, string, error) {
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(s.now()) {
+		return nil, "", ErrInvalidExpiration
+	}
+
+	random, err := utils.RandomToken(personalTokenBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secret := models.PersonalTokenPrefix + random
+	token := &models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(input.Name),
+		Prefix:    secret[:len(models.PersonalTokenPrefix)+personalTokenPrefixLength],
+		TokenHash: utils.HashToken(secret),
+		Scopes:    strings.Join(scopes, " "),
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err := s.tokens.CreatePersonalToken(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("failed to create personal access token: %w", err)
+	}
+	return token, secret, nil
+}
+
+// normalizeScopes 校验 scope 并去重排序
+func normalizeScopes(scopes []rbac.Scope) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	seen := map[rbac.Scope]bool{}
+	var out []string
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, string(scope))
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// List 用户的所有令牌，包括已过期的
+func (s *PersonalTokenService) List(ctx context.Context, userID uint) ([]models.PersonalAccessToken, error) {
+	return s.tokens.ListPersonalTokens(ctx, userID)
+}
+
+// Revoke 吊销用户自己的令牌，令牌不存在或属于其他用户时返回 ErrNotFound
+func (s *PersonalTokenService) Revoke(ctx context.Context, userID, id uint) error {
+	deleted, err := s.tokens.DeletePersonalToken(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke personal access token: %w", err)
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeAll 吊销用户的所有令牌，用于修改或重置密码
+func (s *PersonalTokenService) RevokeAll(ctx context.Context, userID uint) error {
+	if err := s.tokens.DeleteUserPersonalTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke personal access tokens: %w", err)
+	}
+	return nil
+}
+
+// AuthenticatePersonalToken 供认证中间件使用；令牌不存在、已吊销或已过期时返回 repository.ErrNotFound
+func (s *PersonalTokenService) AuthenticatePersonalToken(ctx context.Context, secret string) (*models.PersonalAccessToken, error) {
+	token, err := s.tokens.FindPersonalTokenByHash(ctx, utils.HashToken(secret))
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if token.Expired(now) {
+		return nil, repository.ErrNotFound
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= personalTokenTouchInterval {
+		if err := s.tokens.TouchPersonalToken(ctx, token.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to update personal access token: %w", err)
+		}
+		token.LastUsedAt = &now
+	}
+	return token, nil
+}
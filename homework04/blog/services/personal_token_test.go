@@ -0,0 +1,49 @@
+package services
+
+import (
+	"blog/rbac"
+	"blog/repository"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPersonalToken_ScopesAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := NewPersonalTokenService(repository.NewMemoryStore().PersonalTokens())
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	if _, _, err := s.Create(ctx, 1, PersonalTokenInput{Name: "none"}); !errors.Is(err, ErrInvalidScope) {
+		t.Fatalf("Create without scopes: got %v, want ErrInvalidScope", err)
+	}
+	if _, _, err := s.Create(ctx, 1, PersonalTokenInput{Name: "bad", Scopes: []rbac.Scope{"user:manage"}}); !errors.Is(err, ErrInvalidScope) {
+		t.Fatalf("Create with unknown scope: got %v, want ErrInvalidScope", err)
+	}
+
+	expiresAt := now.Add(time.Hour)
+	token, secret, err := s.Create(ctx, 1, PersonalTokenInput{
+		Name:      "script",
+		Scopes:    []rbac.Scope{rbac.ScopePostsWrite, rbac.ScopeRead, rbac.ScopePostsWrite},
+		ExpiresAt: &expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if token.Scopes != "posts:write read" || !strings.HasPrefix(secret, token.Prefix) {
+		t.Fatalf("Create: scopes %q, prefix %q", token.Scopes, token.Prefix)
+	}
+
+	if _, err := s.AuthenticatePersonalToken(ctx, secret); err != nil {
+		t.Fatalf("AuthenticatePersonalToken: %v", err)
+	}
+	now = expiresAt
+	if _, err := s.AuthenticatePersonalToken(ctx, secret); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("AuthenticatePersonalToken after expiry: got %v, want ErrNotFound", err)
+	}
+	if _, err := s.AuthenticatePersonalToken(ctx, secret+"x"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("AuthenticatePersonalToken with unknown token: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"blog/markup"
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PostService 文章业务规则：修改和删除文章受 rbac 策略约束，未发布的文章只有作者本人可见
+type PostService struct {
+	posts    repository.PostRepository
+	comments repository.CommentRepository
+	taxonomy repository.TaxonomyRepository
+	search   *SearchService
+}
+
+func NewPostService(posts repository.PostRepository, comments repository.CommentRepository, taxonomy repository.TaxonomyRepository, search *SearchService) *PostService {
+	return &PostService{posts: posts, comments: comments, taxonomy: taxonomy, search: search}
+}
+
+// PostInput 创建和更新文章的参数；更新时分类和标签整体替换，CategoryID 为 0 表示未分类
+// Status 为空时创建为已发布，更新时保持原状态；PublishedAt 是定时发布的时间，其他状态忽略
+// Slug 为空时由标题生成，更新时只有标题改变才重新生成
+// ContentFormat 为空时创建为 Markdown，更新时保持原格式；Note 为本次修改的说明，记录在版本历史中
+type PostInput struct {
+	Title         string
+	Slug          string
+	Content       string
+	ContentFormat string
+	CategoryID    uint
+	Tags          []string
+	Status        string
+	PublishedAt   *time.Time
+	Note          string
+}
+
+// Create 创建文章
+func (s *PostService) Create(ctx context.Context, userID uint, input PostInput) (*models.Post, error) {
+	post := &models.Post{
+		Title:         input.Title,
+		Content:       input.Content,
+		ContentFormat: markup.FormatMarkdown,
+		UserID:        userID,
+	}
+	if err := renderContent(post, input.ContentFormat); err != nil {
+		return nil, err
+	}
+	status := input.Status
+	if status == "" {
+		status = models.PostStatusPublished
+	}
+	if err := applyStatus(post, status, input.PublishedAt, time.Now()); err != nil {
+		return nil, err
+	}
+	slug, err := s.chooseSlug(ctx, 0, input.Slug, input.Title)
+	if err != nil {
+		return nil, err
+	}
+	post.Slug = slug
+	if err := s.applyTaxonomy(ctx, post, input); err != nil {
+		return nil, err
+	}
+	if err := s.posts.Create(ctx, post, newRevision(post, userID, input.Note)); err != nil {
+		return nil, err
+	}
+	// 文章已经保存，索引失败时稍后重试，不返回错误以免客户端重复提交
+	if err := s.search.IndexPost(ctx, post); err != nil {
+		s.search.RetryPost(post.ID, err)
+	}
+	return post, nil
+}
+
+// Get 获取文章及其评论，viewerID 为当前用户，0 表示匿名；对其不可见的文章返回 ErrNotFound
+func (s *PostService) Get(ctx context.Context, viewerID, id uint) (*models.Post, error) {
+	post, err := s.posts.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withComments(ctx, viewerID, post)
+}
+
+// GetBySlug 按 slug 获取文章及其评论，slug 也可以是文章用过的旧 slug，
+// 调用方比较返回文章当前的 Slug 判断是否需要重定向；可见性同 Get
+func (s *PostService) GetBySlug(ctx context.Context, viewerID uint, slug string) (*models.Post, error) {
+	post, err := s.posts.FindBySlug(ctx, slug)
+	if errors.Is(err, repository.ErrNotFound) {
+		post, err = s.posts.FindByOldSlug(ctx, slug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.withComments(ctx, viewerID, post)
+}
+
+// withComments 检查文章对 viewerID 是否可见并加载评论
+func (s *PostService) withComments(ctx context.Context, viewerID uint, post *models.Post) (*models.Post, error) {
+	if !post.VisibleTo(viewerID) {
+		return nil, ErrNotFound
+	}
+	comments, err := s.comments.ListByPost(ctx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	post.Comments = comments
+	return post, nil
+}
+
+// Update 更新文章，见 rbac.CanUpdatePost；更新前后的内容都保存在版本历史中
+func (s *PostService) Update(ctx context.Context, actor rbac.Actor, id uint, input PostInput) (*models.Post, error) {
+	return s.update(ctx, actor, id, input, nil)
+}
+
+// update 更新文章并保存一个新版本，restoredFrom 不为空时新版本由该版本恢复而来
+func (s *PostService) update(ctx context.Context, actor rbac.Actor, id uint, input PostInput, restoredFrom *int) (*models.Post, error) {
+	post, err := s.posts.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !rbac.CanUpdatePost(actor, post) {
+		return nil, ErrForbidden
+	}
+
+	wasPublished := post.Status == models.PostStatusPublished
+	switch {
+	case input.Slug != "":
+		post.Slug, err = s.chooseSlug(ctx, post.ID, input.Slug, "")
+	case utils.Slugify(input.Title) != utils.Slugify(post.Title):
+		post.Slug, err = s.chooseSlug(ctx, post.ID, "", input.Title)
+	}
+	if err != nil {
+		return nil, err
+	}
+	post.Title = input.Title
+	post.Content = input.Content
+	if err := renderContent(post, input.ContentFormat); err != nil {
+		return nil, err
+	}
+	if input.Status != "" {
+		if err := applyStatus(post, input.Status, input.PublishedAt, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.applyTaxonomy(ctx, post, input); err != nil {
+		return nil, err
+	}
+	revision := newRevision(post, actor.UserID, input.Note)
+	revision.RestoredFrom = restoredFrom
+	if err := s.posts.Update(ctx, post, revision); err != nil {
+		return nil, err
+	}
+	// 重新发布时，下线期间从索引中移除的评论也要恢复
+	if !wasPublished && post.Status == models.PostStatusPublished {
+		err = s.search.IndexPostWithComments(ctx, post)
+	} else {
+		err = s.search.IndexPost(ctx, post)
+	}
+	if err != nil {
+		s.search.RetryPost(post.ID, err)
+	}
+	return post, nil
+}
+
+// renderContent 按 format 渲染正文并保存到 ContentHTML，format 为空时使用文章原来的格式
+func renderContent(post *models.Post, format string) error {
+	if format == "" {
+		format = post.ContentFormat
+	}
+	contentHTML, err := markup.Render(format, post.Content)
+	if errors.Is(err, markup.ErrUnknownFormat) {
+		return ErrInvalidContentFormat
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render content: %w", err)
+	}
+	post.ContentFormat, post.ContentHTML = format, contentHTML
+	return nil
+}
+
+// applyTaxonomy 设置文章的分类和标签，不存在的标签自动创建
+func (s *PostService) applyTaxonomy(ctx context.Context, post *models.Post, input PostInput) error {
+	post.CategoryID, post.Category = nil, nil
+	if input.CategoryID != 0 {
+		category, err := s.taxonomy.FindCategory(ctx, input.CategoryID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrCategoryNotFound
+			}
+			return fmt.Errorf("failed to load category: %w", err)
+		}
+		post.CategoryID, post.Category = &category.ID, category
+	}
+	tags, err := s.taxonomy.EnsureTags(ctx, NormalizeTags(input.Tags))
+	if err != nil {
+		return fmt.Errorf("failed to save tags: %w", err)
+	}
+	post.Tags = tags
+	return nil
+}
+
+// Delete 删除文章，见 rbac.CanDeletePost；不再被其他文章使用的标签随之删除
+func (s *PostService) Delete(ctx context.Context, actor rbac.Actor, id uint) error {
+	post, err := s.posts.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !rbac.CanDeletePost(actor, post) {
+		return ErrForbidden
+	}
+	if err := s.posts.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := s.search.RemovePost(ctx, id); err != nil {
+		s.search.RetryPost(id, err)
+	}
+	return nil
+}
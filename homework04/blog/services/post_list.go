@@ -0,0 +1,167 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 分页参数的默认值和上限
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+// ErrInvalidCursor 游标无法解析或与当前排序不一致
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PostListInput 文章列表参数
+// Cursor 不为空时使用游标分页，否则按 Page 偏移分页
+// Category 非空时只返回该分类及其子分类下的文章
+// Viewer 为当前用户，0 表示匿名；除了已发布的文章，只会返回 Viewer 本人的文章
+type PostListInput struct {
+	Filter   repository.PostFilter
+	Viewer   uint
+	Category string
+	Sort     string
+	Desc     bool
+	Page     int
+	PageSize int
+	Cursor   string
+}
+
+// PostPage 一页文章
+type PostPage struct {
+	Posts      []models.Post
+	Total      int64
+	Page       int // 游标分页时为 0
+	PageSize   int
+	HasNext    bool
+	HasPrev    bool
+	NextCursor string
+	PrevCursor string
+}
+
+// List 分页获取文章列表
+func (s *PostService) List(ctx context.Context, input PostListInput) (*PostPage, error) {
+	if input.Sort == "" {
+		input.Sort = repository.SortCreatedAt
+	}
+	if input.PageSize <= 0 {
+		input.PageSize = DefaultPageSize
+	}
+	if input.PageSize > MaxPageSize {
+		input.PageSize = MaxPageSize
+	}
+
+	input.Filter.OnlyVisible, input.Filter.ViewerID = true, input.Viewer
+	opts := repository.PostListOptions{
+		PostFilter: input.Filter,
+		SortField:  input.Sort,
+		Desc:       input.Desc,
+		Limit:      input.PageSize + 1, // 多取一条用于判断是否还有下一页
+	}
+	page := &PostPage{PageSize: input.PageSize}
+	if input.Cursor != "" {
+		cursor, err := decodeCursor(input.Cursor)
+		if err != nil || cursor.Field != input.Sort {
+			return nil, ErrInvalidCursor
+		}
+		opts.Cursor = cursor
+	} else {
+		if input.Page <= 0 {
+			input.Page = 1
+		}
+		page.Page = input.Page
+		opts.Offset = (input.Page - 1) * input.PageSize
+	}
+
+	if input.Category != "" {
+		ids, err := s.categoryIDs(ctx, input.Category)
+		if errors.Is(err, ErrNotFound) {
+			// 分类不存在时没有任何文章
+			return page, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		opts.CategoryIDs = ids
+	}
+
+	posts, total, err := s.posts.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	page.Total = total
+
+	backward := opts.Cursor != nil && opts.Cursor.Backward
+	more := len(posts) > input.PageSize
+	if more {
+		// 向前翻页时多出的一条在最前面
+		if backward {
+			posts = posts[1:]
+		} else {
+			posts = posts[:input.PageSize]
+		}
+	}
+	page.Posts = posts
+
+	switch {
+	case backward:
+		page.HasPrev, page.HasNext = more, true
+	case opts.Cursor != nil:
+		page.HasPrev, page.HasNext = true, more
+	default:
+		page.HasPrev, page.HasNext = opts.Offset > 0, more
+	}
+	if len(posts) > 0 {
+		if page.HasNext {
+			page.NextCursor = encodeCursor(repository.CursorFor(posts[len(posts)-1], input.Sort, false))
+		}
+		if page.HasPrev {
+			page.PrevCursor = encodeCursor(repository.CursorFor(posts[0], input.Sort, true))
+		}
+	}
+	return page, nil
+}
+
+// categoryIDs 名为 name 的分类及其子孙分类的 ID
+func (s *PostService) categoryIDs(ctx context.Context, name string) ([]uint, error) {
+	category, err := s.taxonomy.FindCategoryByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.taxonomy.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	return subtreeIDs(categories, category.ID), nil
+}
+
+// encodeCursor 游标对客户端是不透明的字符串
+func encodeCursor(cursor repository.PostCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*repository.PostCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor repository.PostCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.Field != repository.SortTitle {
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return nil, err
+		}
+	}
+	return &cursor, nil
+}
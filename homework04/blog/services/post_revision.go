@@ -0,0 +1,119 @@
+package services
+
+import (
+	"blog/diff"
+	"blog/models"
+	"blog/rbac"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// 版本比较的粒度
+const (
+	DiffByLine = "line"
+	DiffByWord = "word"
+)
+
+// RevisionDiff 两个版本的差异；标题总是按词比较
+type RevisionDiff struct {
+	From    *models.PostRevision
+	To      *models.PostRevision
+	Title   diff.Result
+	Content diff.Result
+}
+
+// newRevision 文章当前内容的快照
+func newRevision(post *models.Post, editorID uint, note string) *models.PostRevision {
+	return &models.PostRevision{
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		EditorID:      editorID,
+		Note:          note,
+	}
+}
+
+// Revisions 文章的版本历史，按版本号倒序；只有可以修改文章的用户能查看，见 rbac.CanUpdatePost
+func (s *PostService) Revisions(ctx context.Context, actor rbac.Actor, postID uint) ([]models.PostRevision, error) {
+	if _, err := s.editablePost(ctx, actor, postID); err != nil {
+		return nil, err
+	}
+	revisions, err := s.posts.ListRevisions(ctx, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// DiffRevisions 比较文章的两个版本，by 为 DiffByLine 或 DiffByWord；权限同 Revisions
+func (s *PostService) DiffRevisions(ctx context.Context, actor rbac.Actor, postID uint, from, to int, by string) (*RevisionDiff, error) {
+	if _, err := s.editablePost(ctx, actor, postID); err != nil {
+		return nil, err
+	}
+	result := &RevisionDiff{}
+	var err error
+	if result.From, err = s.revision(ctx, postID, from); err != nil {
+		return nil, err
+	}
+	if result.To, err = s.revision(ctx, postID, to); err != nil {
+		return nil, err
+	}
+	result.Title = diff.Words(result.From.Title, result.To.Title)
+	if by == DiffByWord {
+		result.Content = diff.Words(result.From.Content, result.To.Content)
+	} else {
+		result.Content = diff.Lines(result.From.Content, result.To.Content)
+	}
+	return result, nil
+}
+
+// RestoreRevision 把文章的标题、正文和格式恢复为指定版本，作为一个新版本保存；
+// 分类、标签和状态保持不变，权限同 Update
+func (s *PostService) RestoreRevision(ctx context.Context, actor rbac.Actor, postID uint, number int, note string) (*models.Post, error) {
+	post, err := s.editablePost(ctx, actor, postID)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.revision(ctx, postID, number)
+	if err != nil {
+		return nil, err
+	}
+	input := PostInput{
+		Title:         revision.Title,
+		Content:       revision.Content,
+		ContentFormat: revision.ContentFormat,
+		Tags:          make([]string, len(post.Tags)),
+		Note:          note,
+	}
+	if post.CategoryID != nil {
+		input.CategoryID = *post.CategoryID
+	}
+	for i, tag := range post.Tags {
+		input.Tags[i] = tag.Name
+	}
+	return s.update(ctx, actor, postID, input, &revision.Number)
+}
+
+// editablePost 查找 actor 可以修改的文章
+func (s *PostService) editablePost(ctx context.Context, actor rbac.Actor, postID uint) (*models.Post, error) {
+	post, err := s.posts.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if !rbac.CanUpdatePost(actor, post) {
+		return nil, ErrForbidden
+	}
+	return post, nil
+}
+
+func (s *PostService) revision(ctx context.Context, postID uint, number int) (*models.PostRevision, error) {
+	revision, err := s.posts.FindRevision(ctx, postID, number)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revision: %w", err)
+	}
+	return revision, nil
+}
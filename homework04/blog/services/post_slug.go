@@ -0,0 +1,45 @@
+package services
+
+import (
+	"blog/utils"
+	"context"
+	"fmt"
+)
+
+// defaultSlug 标题中没有可以用于 slug 的字符时使用
+const defaultSlug = "post"
+
+// chooseSlug 确定文章的 slug，postID 为 0 表示新文章
+// custom 非空时使用作者指定的 slug（按 utils.Slugify 规范化），已被其他文章占用时返回 ErrSlugTaken；
+// 否则由标题生成，被占用时依次尝试 -2、-3……
+func (s *PostService) chooseSlug(ctx context.Context, postID uint, custom, title string) (string, error) {
+	if custom != "" {
+		slug := utils.Slugify(custom)
+		if slug == "" {
+			return "", ErrInvalidSlug
+		}
+		taken, err := s.posts.SlugTaken(ctx, slug, postID)
+		if err != nil {
+			return "", fmt.Errorf("failed to check slug: %w", err)
+		}
+		if taken {
+			return "", ErrSlugTaken
+		}
+		return slug, nil
+	}
+
+	base := utils.Slugify(title)
+	if base == "" {
+		base = defaultSlug
+	}
+	for n := 1; ; n++ {
+		slug := utils.SlugCandidate(base, n)
+		taken, err := s.posts.SlugTaken(ctx, slug, postID)
+		if err != nil {
+			return "", fmt.Errorf("failed to check slug: %w", err)
+		}
+		if !taken {
+			return slug, nil
+		}
+	}
+}
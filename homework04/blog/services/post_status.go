@@ -0,0 +1,90 @@
+package services
+
+import (
+	"blog/models"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// applyStatus 修改文章状态并相应设置发布时间，见 models.Post.PublishedAt
+// publishAt 只用于定时发布，必须晚于 now；发布时间统一保存为 UTC，与 PublishDue 的比较条件一致
+// （SQLite 按字符串比较时间，不同时区的值不能直接比较）
+func applyStatus(post *models.Post, status string, publishAt *time.Time, now time.Time) error {
+	// 发布过的文章（包括已归档的）保留首次发布时间
+	wasPublished := post.PublishedAt != nil && (post.Status == models.PostStatusPublished || post.Status == models.PostStatusArchived)
+	switch status {
+	case models.PostStatusDraft:
+		post.PublishedAt = nil
+	case models.PostStatusScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return ErrInvalidSchedule
+		}
+		at := publishAt.UTC()
+		post.PublishedAt = &at
+	case models.PostStatusPublished:
+		if !wasPublished {
+			at := now.UTC()
+			post.PublishedAt = &at
+		}
+	case models.PostStatusArchived:
+		if !wasPublished {
+			post.PublishedAt = nil
+		}
+	default:
+		return ErrInvalidStatus
+	}
+	post.Status = status
+	return nil
+}
+
+// PublishDue 发布计划时间不晚于 now 的定时文章并加入检索索引，返回发布的篇数
+// 多个实例同时执行时每篇文章只会被其中一个实例发布；文章发布后索引失败的留待 search-retry 重新索引
+func (s *PostService) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	posts, err := s.posts.PublishDue(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish scheduled posts: %w", err)
+	}
+	for i := range posts {
+		if err := s.search.IndexPostWithComments(ctx, &posts[i]); err != nil {
+			s.search.RetryPost(posts[i].ID, err)
+		}
+	}
+	return len(posts), nil
+}
+
+// PublishWorker 定期发布到期的定时文章，文章最多比计划时间晚 interval 发布
+func (s *PostService) PublishWorker(interval time.Duration, logger *zap.Logger) *PostPublishWorker {
+	return &PostPublishWorker{posts: s, interval: interval, logger: logger}
+}
+
+// PostPublishWorker 见 PostService.PublishWorker
+type PostPublishWorker struct {
+	posts    *PostService
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func (w *PostPublishWorker) Name() string { return "post-publisher" }
+
+func (w *PostPublishWorker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			published, err := w.posts.PublishDue(ctx, time.Now())
+			if published > 0 {
+				w.logger.Info("Published scheduled posts", zap.Int("count", published))
+			}
+			if err != nil {
+				// 数据库更新失败的文章仍是定时状态，下个周期重试
+				w.logger.Warn("Failed to publish scheduled posts", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"blog/search"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newTestPostService() (*PostService, *CommentService) {
+	store := repository.NewMemoryStore()
+	searchService := NewSearchService(search.NewMemoryIndex(), store.Users(), store.Posts(), store.Comments(), zap.NewNop())
+	return NewPostService(store.Posts(), store.Comments(), store.Taxonomy(), searchService), NewCommentService(store.Posts(), store.Comments(), searchService)
+}
+
+func TestPostService_OnlyAuthorCanModify(t *testing.T) {
+	ctx := context.Background()
+	posts, _ := newTestPostService()
+	author := rbac.Actor{UserID: 1, Role: rbac.RoleAuthor}
+	other := rbac.Actor{UserID: 2, Role: rbac.RoleAuthor}
+
+	post, err := posts.Create(ctx, 1, PostInput{Title: "hello", Content: "world"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := posts.Update(ctx, other, post.ID, PostInput{Title: "x", Content: "y"}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Update by other user: got %v, want ErrForbidden", err)
+	}
+	if err := posts.Delete(ctx, other, post.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Delete by other user: got %v, want ErrForbidden", err)
+	}
+
+	updated, err := posts.Update(ctx, author, post.ID, PostInput{Title: "new", Content: "content"})
+	if err != nil {
+		t.Fatalf("Update by author: %v", err)
+	}
+	if updated.Title != "new" || updated.Content != "content" {
+		t.Fatalf("Update by author: got %q/%q", updated.Title, updated.Content)
+	}
+
+	if err := posts.Delete(ctx, author, post.ID); err != nil {
+		t.Fatalf("Delete by author: %v", err)
+	}
+	if _, err := posts.Get(ctx, 1, post.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get deleted post: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestCommentService_Rules(t *testing.T) {
+	ctx := context.Background()
+	posts, comments := newTestPostService()
+
+	if _, err := comments.Create(ctx, 1, 42, "hi"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Create on missing post: got %v, want ErrNotFound", err)
+	}
+
+	post, err := posts.Create(ctx, 1, PostInput{Title: "hello", Content: "world"})
+	if err != nil {
+		t.Fatalf("Create post: %v", err)
+	}
+	comment, err := comments.Create(ctx, 2, post.ID, "nice post")
+	if err != nil {
+		t.Fatalf("Create comment: %v", err)
+	}
+
+	if err := comments.Delete(ctx, rbac.Actor{UserID: 1, Role: rbac.RoleAuthor}, comment.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Delete by post author: got %v, want ErrForbidden", err)
+	}
+	if err := comments.Delete(ctx, rbac.Actor{UserID: 2, Role: rbac.RoleReader}, comment.ID); err != nil {
+		t.Fatalf("Delete by commenter: %v", err)
+	}
+
+	list, err := comments.ListByPost(ctx, 0, post.ID)
+	if err != nil {
+		t.Fatalf("ListByPost: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("ListByPost after delete: got %d comments, want 0", len(list))
+	}
+}
+
+func TestPostService_EditorCanModerate(t *testing.T) {
+	ctx := context.Background()
+	posts, comments := newTestPostService()
+	editor := rbac.Actor{UserID: 3, Role: rbac.RoleEditor}
+
+	post, err := posts.Create(ctx, 1, PostInput{Title: "hello", Content: "world"})
+	if err != nil {
+		t.Fatalf("Create post: %v", err)
+	}
+	comment, err := comments.Create(ctx, 2, post.ID, "spam")
+	if err != nil {
+		t.Fatalf("Create comment: %v", err)
+	}
+
+	if _, err := posts.Update(ctx, editor, post.ID, PostInput{Title: "edited", Content: "world"}); err != nil {
+		t.Fatalf("Update by editor: %v", err)
+	}
+	if err := comments.Delete(ctx, editor, comment.ID); err != nil {
+		t.Fatalf("Delete comment by editor: %v", err)
+	}
+	if err := posts.Delete(ctx, editor, post.ID); err != nil {
+		t.Fatalf("Delete post by editor: %v", err)
+	}
+
+	// 被降级为 reader 的作者不能再修改自己的文章
+	own, err := posts.Create(ctx, 4, PostInput{Title: "mine", Content: "text"})
+	if err != nil {
+		t.Fatalf("Create post: %v", err)
+	}
+	if err := posts.Delete(ctx, rbac.Actor{UserID: 4, Role: rbac.RoleReader}, own.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Delete by reader: got %v, want ErrForbidden", err)
+	}
+}
+
+func TestPostService_Status(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	searchService := NewSearchService(search.NewMemoryIndex(), store.Users(), store.Posts(), store.Comments(), zap.NewNop())
+	posts := NewPostService(store.Posts(), store.Comments(), store.Taxonomy(), searchService)
+	comments := NewCommentService(store.Posts(), store.Comments(), searchService)
+	author := rbac.Actor{UserID: 1, Role: rbac.RoleAuthor}
+
+	draft, err := posts.Create(ctx, 1, PostInput{Title: "draft", Content: "unfinished", Status: models.PostStatusDraft})
+	if err != nil {
+		t.Fatalf("Create draft: %v", err)
+	}
+	if draft.PublishedAt != nil {
+		t.Fatalf("draft published_at: got %v, want nil", draft.PublishedAt)
+	}
+	if _, err := posts.Get(ctx, 2, draft.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get draft by other user: got %v, want ErrNotFound", err)
+	}
+	if _, err := posts.Get(ctx, 1, draft.ID); err != nil {
+		t.Fatalf("Get draft by author: %v", err)
+	}
+	if _, err := comments.Create(ctx, 1, draft.ID, "note"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Comment on draft: got %v, want ErrNotFound", err)
+	}
+	for viewer, want := range map[uint]int64{0: 0, 2: 0, 1: 1} {
+		page, err := posts.List(ctx, PostListInput{Viewer: viewer})
+		if err != nil {
+			t.Fatalf("List as %d: %v", viewer, err)
+		}
+		if page.Total != want {
+			t.Fatalf("List as %d: got %d posts, want %d", viewer, page.Total, want)
+		}
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if _, err := posts.Create(ctx, 1, PostInput{Title: "late", Content: "x", Status: models.PostStatusScheduled, PublishedAt: &past}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Fatalf("Schedule in the past: got %v, want ErrInvalidSchedule", err)
+	}
+	at := time.Now().Add(time.Hour)
+	if _, err := posts.Update(ctx, author, draft.ID, PostInput{Title: "draft", Content: "finished", Status: models.PostStatusScheduled, PublishedAt: &at}); err != nil {
+		t.Fatalf("Schedule draft: %v", err)
+	}
+
+	if published, err := posts.PublishDue(ctx, time.Now()); err != nil || published != 0 {
+		t.Fatalf("PublishDue before due: got %d, %v", published, err)
+	}
+	hits, _ := searchService.Search(ctx, search.Query{Text: "finished"})
+	if hits.Total != 0 {
+		t.Fatalf("scheduled post is searchable")
+	}
+	if published, err := posts.PublishDue(ctx, at); err != nil || published != 1 {
+		t.Fatalf("PublishDue when due: got %d, %v", published, err)
+	}
+	if published, err := posts.PublishDue(ctx, at); err != nil || published != 0 {
+		t.Fatalf("PublishDue again: got %d, %v", published, err)
+	}
+	post, err := posts.Get(ctx, 0, draft.ID)
+	if err != nil {
+		t.Fatalf("Get published post: %v", err)
+	}
+	if post.Status != models.PostStatusPublished || !post.PublishedAt.Equal(at) {
+		t.Fatalf("published post: got %s at %v, want published at %v", post.Status, post.PublishedAt, at)
+	}
+	hits, _ = searchService.Search(ctx, search.Query{Text: "finished"})
+	if hits.Total != 1 {
+		t.Fatalf("published post: got %d search hits, want 1", hits.Total)
+	}
+
+	// 归档保留首次发布时间，并从检索中移除
+	archived, err := posts.Update(ctx, author, draft.ID, PostInput{Title: "draft", Content: "finished", Status: models.PostStatusArchived})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if !archived.PublishedAt.Equal(at) {
+		t.Fatalf("archived published_at: got %v, want %v", archived.PublishedAt, at)
+	}
+	hits, _ = searchService.Search(ctx, search.Query{Text: "finished"})
+	if hits.Total != 0 {
+		t.Fatalf("archived post is searchable")
+	}
+}
+
+func TestPostService_Slugs(t *testing.T) {
+	ctx := context.Background()
+	posts, _ := newTestPostService()
+	author := rbac.Actor{UserID: 1, Role: rbac.RoleAuthor}
+
+	first, err := posts.Create(ctx, 1, PostInput{Title: "Hello World", Content: "x"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	second, err := posts.Create(ctx, 1, PostInput{Title: "hello, world", Content: "x"})
+	if err != nil {
+		t.Fatalf("Create duplicate title: %v", err)
+	}
+	if first.Slug != "hello-world" || second.Slug != "hello-world-2" {
+		t.Fatalf("slugs: got %q and %q", first.Slug, second.Slug)
+	}
+
+	// 只改内容不改标题时 slug 不变
+	updated, err := posts.Update(ctx, author, first.ID, PostInput{Title: "Hello  world", Content: "y"})
+	if err != nil {
+		t.Fatalf("Update content: %v", err)
+	}
+	if updated.Slug != "hello-world" {
+		t.Fatalf("slug after updating content: got %q", updated.Slug)
+	}
+	if _, err := posts.Update(ctx, author, first.ID, PostInput{Title: "Renamed", Content: "y"}); err != nil {
+		t.Fatalf("Update title: %v", err)
+	}
+	post, err := posts.GetBySlug(ctx, 0, "hello-world")
+	if err != nil {
+		t.Fatalf("GetBySlug old slug: %v", err)
+	}
+	if post.ID != first.ID || post.Slug != "renamed" {
+		t.Fatalf("GetBySlug old slug: got post %d with slug %q", post.ID, post.Slug)
+	}
+
+	// 已删除文章和旧 slug 都不能被其他文章占用
+	if err := posts.Delete(ctx, author, second.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, slug := range []string{"hello-world", "hello-world-2"} {
+		if _, err := posts.Create(ctx, 1, PostInput{Title: "x", Slug: slug, Content: "x"}); !errors.Is(err, ErrSlugTaken) {
+			t.Fatalf("Create with slug %q: got %v, want ErrSlugTaken", slug, err)
+		}
+	}
+	third, err := posts.Create(ctx, 1, PostInput{Title: "Hello World", Content: "x"})
+	if err != nil {
+		t.Fatalf("Create after delete: %v", err)
+	}
+	if third.Slug != "hello-world-3" {
+		t.Fatalf("slug after delete: got %q", third.Slug)
+	}
+}
+
+func TestPostService_Revisions(t *testing.T) {
+	ctx := context.Background()
+	posts, _ := newTestPostService()
+	author := rbac.Actor{UserID: 1, Role: rbac.RoleAuthor}
+	other := rbac.Actor{UserID: 2, Role: rbac.RoleAuthor}
+
+	post, err := posts.Create(ctx, 1, PostInput{Title: "v1", Content: "first", Tags: []string{"go"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := posts.Update(ctx, author, post.ID, PostInput{Title: "v2", Content: "second", Tags: []string{"go", "web"}, Note: "rewrite"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := posts.Revisions(ctx, other, post.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("Revisions by other user: got %v, want ErrForbidden", err)
+	}
+
+	restored, err := posts.RestoreRevision(ctx, author, post.ID, 1, "")
+	if err != nil {
+		t.Fatalf("RestoreRevision: %v", err)
+	}
+	// 只恢复标题和正文，标签保持不变
+	if restored.Title != "v1" || restored.Content != "first" || len(restored.Tags) != 2 {
+		t.Fatalf("restored post: got title %q, content %q, %d tags", restored.Title, restored.Content, len(restored.Tags))
+	}
+
+	revisions, err := posts.Revisions(ctx, author, post.ID)
+	if err != nil {
+		t.Fatalf("Revisions: %v", err)
+	}
+	var numbers []int
+	for _, revision := range revisions {
+		numbers = append(numbers, revision.Number)
+	}
+	if len(numbers) != 3 || numbers[0] != 3 || numbers[2] != 1 || revisions[0].RestoredFrom == nil || *revisions[0].RestoredFrom != 1 || revisions[1].Note != "rewrite" {
+		t.Fatalf("revisions: got %+v", revisions)
+	}
+	if _, err := posts.DiffRevisions(ctx, author, post.ID, 1, 4, DiffByLine); !errors.Is(err, ErrRevisionNotFound) {
+		t.Fatalf("DiffRevisions with missing revision: got %v, want ErrRevisionNotFound", err)
+	}
+}
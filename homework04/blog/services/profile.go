@@ -0,0 +1,142 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProfileInput 修改个人资料的参数，各字段整体替换，空值表示清空
+// 修改邮箱时需要提供当前密码，避免被盗用的登录会话把账号绑定到其他邮箱后重置密码
+type ProfileInput struct {
+	Email       string
+	DisplayName string
+	Bio         string
+	AvatarURL   string
+	Password    string
+}
+
+// UpdateProfile 修改邮箱和公开资料，返回修改后的用户以及邮箱是否变化
+// 邮箱变化后需要重新验证，由调用方向新邮箱发送验证邮件
+func (s *AccountService) UpdateProfile(ctx context.Context, userID uint, input ProfileInput) (*models.User, bool, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	emailChanged := input.Email != user.Email
+	if emailChanged {
+		if user.CheckPassword(input.Password) != nil {
+			return nil, false, ErrIncorrectPassword
+		}
+		if existing, err := s.users.FindByEmail(ctx, input.Email); err == nil && existing.ID != user.ID {
+			return nil, false, ErrEmailTaken
+		} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, false, err
+		}
+		user.Email = input.Email
+		user.EmailVerifiedAt = nil
+	}
+	user.DisplayName = input.DisplayName
+	user.Bio = input.Bio
+	user.AvatarURL = input.AvatarURL
+
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, false, fmt.Errorf("failed to update user: %w", err)
+	}
+	s.cache.Invalidate(user.ID)
+	return user, emailChanged, nil
+}
+
+// ChangePassword 验证当前密码后设置新密码，按当前配置的 bcrypt cost 哈希
+// 除 keepSessionID 以外的登录会话全部退出，keepSessionID 为空时包括当前会话；所有个人访问令牌被吊销
+func (s *AccountService) ChangePassword(ctx context.Context, userID uint, keepSessionID, current, password string) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.CheckPassword(current) != nil {
+		return ErrIncorrectPassword
+	}
+
+	if err := user.HashPasswordWithCost(password, s.cfg.BcryptCost); err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.users.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return s.revokeCredentials(ctx, user.ID, keepSessionID)
+}
+
+// DeleteAccount 验证密码后注销账号：按配置删除或保留其文章和评论，清除个人信息并软删除用户，退出所有登录会话
+// 数据库中的修改在一个事务中完成；提交之后再更新检索索引，保留的内容不再显示作者，索引中的作者名也随之清除
+func (s *AccountService) DeleteAccount(ctx context.Context, userID uint, password string) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.CheckPassword(password) != nil {
+		return ErrIncorrectPassword
+	}
+
+	posts, _, err := s.posts.List(ctx, repository.PostListOptions{PostFilter: repository.PostFilter{AuthorID: user.ID}})
+	if err != nil {
+		return fmt.Errorf("failed to load posts: %w", err)
+	}
+	comments, err := s.comments.ListByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load comments: %w", err)
+	}
+
+	anonymize(user)
+	err = s.users.DeleteAccount(ctx, user, repository.AccountDeletion{
+		DeletePosts:    s.cfg.DeletePosts,
+		DeleteComments: s.cfg.DeleteComments,
+		RevokedAt:      time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+	s.cache.Invalidate(user.ID)
+
+	// 用户已删除，重新索引时作者名为空；失败的留待重试，账号已经注销，不再返回错误
+	for i := range posts {
+		if s.cfg.DeletePosts {
+			err = s.search.RemovePost(ctx, posts[i].ID)
+		} else {
+			posts[i].User = models.User{}
+			err = s.search.IndexPost(ctx, &posts[i])
+		}
+		if err != nil {
+			s.search.RetryPost(posts[i].ID, err)
+		}
+	}
+	for i := range comments {
+		if s.cfg.DeleteComments {
+			err = s.search.RemoveComment(ctx, comments[i].ID)
+		} else {
+			comments[i].User = models.User{}
+			err = s.search.IndexComment(ctx, &comments[i])
+		}
+		if err != nil {
+			s.search.RetryComment(comments[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// anonymize 清除用户的个人信息；用户名和邮箱改为占位值，原用户名和邮箱可以重新注册
+func anonymize(user *models.User) {
+	user.Username = fmt.Sprintf("deleted-%d", user.ID)
+	user.Email = fmt.Sprintf("deleted-%d@deleted.invalid", user.ID)
+	user.Password = ""
+	user.DisplayName = ""
+	user.Bio = ""
+	user.AvatarURL = ""
+	user.EmailVerifiedAt = nil
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"blog/search"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestChangePassword 需要当前密码，新密码按配置的 cost 哈希；登录时旧 cost 的哈希被重新哈希
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	accounts, _ := newTestAccountServiceWithStore(t, store, AccountConfig{BcryptCost: bcrypt.MinCost})
+	user, _ := store.Users().FindByUsername(ctx, "alice")
+
+	// 测试用户使用默认 cost 哈希，登录时按配置的 cost 重新哈希
+	guard := NewLoginGuard(store.LoginAttempts(), store.Users(), LoginGuardConfig{MaxAttempts: 10, IPMaxAttempts: 10, Window: time.Minute, LockoutDuration: time.Minute})
+	auth := NewAuthService(store.Users(), accounts.tokens, nil, guard, bcrypt.MinCost+1)
+	if _, err := auth.Login(ctx, LoginInput{Username: "alice", Password: "password123"}); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	user, _ = store.Users().FindByID(ctx, user.ID)
+	if cost, _ := bcrypt.Cost([]byte(user.Password)); cost != bcrypt.MinCost+1 {
+		t.Fatalf("cost after login = %d, want %d", cost, bcrypt.MinCost+1)
+	}
+
+	if err := accounts.ChangePassword(ctx, user.ID, "", "wrong-password", "newpassword"); !errors.Is(err, ErrIncorrectPassword) {
+		t.Fatalf("ChangePassword with wrong password: got %v, want ErrIncorrectPassword", err)
+	}
+	if err := accounts.ChangePassword(ctx, user.ID, "", "password123", "newpassword"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	user, _ = store.Users().FindByID(ctx, user.ID)
+	if user.CheckPassword("newpassword") != nil {
+		t.Fatal("password not changed")
+	}
+	if cost, _ := bcrypt.Cost([]byte(user.Password)); cost != bcrypt.MinCost {
+		t.Fatalf("cost after change = %d, want %d", cost, bcrypt.MinCost)
+	}
+}
+
+// TestDeleteAccount 注销后用户名和邮箱可以重新注册，文章和评论按配置删除或匿名保留
+func TestDeleteAccount(t *testing.T) {
+	for _, remove := range []bool{false, true} {
+		ctx := context.Background()
+		store := repository.NewMemoryStore()
+		accounts, _ := newTestAccountServiceWithStore(t, store, AccountConfig{BcryptCost: bcrypt.MinCost, DeletePosts: remove, DeleteComments: remove})
+		alice, _ := store.Users().FindByUsername(ctx, "alice")
+		bob := &models.User{Username: "bob", Email: "bob@example.com", Role: "author"}
+		if err := store.Users().Create(ctx, bob); err != nil {
+			t.Fatalf("create bob: %v", err)
+		}
+		posts := NewPostService(store.Posts(), store.Comments(), store.Taxonomy(), accounts.search)
+		comments := NewCommentService(store.Posts(), store.Comments(), accounts.search)
+		own, _ := posts.Create(ctx, alice.ID, PostInput{Title: "alice post", Content: "written by alice"})
+		other, _ := posts.Create(ctx, bob.ID, PostInput{Title: "bob post", Content: "written by bob"})
+		if _, err := comments.Create(ctx, alice.ID, other.ID, "alice comment"); err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+
+		if err := accounts.DeleteAccount(ctx, alice.ID, "wrong-password"); !errors.Is(err, ErrIncorrectPassword) {
+			t.Fatalf("DeleteAccount with wrong password: got %v, want ErrIncorrectPassword", err)
+		}
+		if err := accounts.DeleteAccount(ctx, alice.ID, "password123"); err != nil {
+			t.Fatalf("DeleteAccount: %v", err)
+		}
+		if _, err := store.Users().FindByID(ctx, alice.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("deleted user still found: %v", err)
+		}
+		if _, err := store.Users().FindByEmail(ctx, "alice@example.com"); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("email of deleted user still taken: %v", err)
+		}
+
+		post, err := store.Posts().FindByID(ctx, own.ID)
+		if remove {
+			if !errors.Is(err, repository.ErrNotFound) {
+				t.Fatalf("delete policy: post still found: %v", err)
+			}
+		} else if err != nil || post.User.ID != 0 {
+			t.Fatalf("anonymize policy: post %+v, err %v", post, err)
+		}
+		left, _ := store.Comments().ListByPost(ctx, other.ID)
+		if remove == (len(left) != 0) {
+			t.Fatalf("delete=%v: %d comments left", remove, len(left))
+		}
+
+		result, err := accounts.search.Search(ctx, search.Query{Text: "alice"})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if remove && result.Total != 0 {
+			t.Fatalf("delete policy: %d search hits left", result.Total)
+		}
+		for _, hit := range result.Hits {
+			if hit.Author != "" {
+				t.Fatalf("anonymize policy: hit still shows author %q", hit.Author)
+			}
+		}
+		if !remove && result.Total != 2 {
+			t.Fatalf("anonymize policy: got %d search hits, want 2", result.Total)
+		}
+	}
+}
+
+// TestPasswordChangeRevokesPersonalTokens 修改密码和重置密码都吊销所有个人访问令牌
+func TestPasswordChangeRevokesPersonalTokens(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	accounts, m := newTestAccountServiceWithStore(t, store, AccountConfig{BaseURL: "https://blog.example.com", BcryptCost: bcrypt.MinCost, PasswordResetTTL: time.Hour})
+	user, _ := store.Users().FindByUsername(ctx, "alice")
+	personal := accounts.personal
+
+	create := func() string {
+		t.Helper()
+		_, secret, err := personal.Create(ctx, user.ID, PersonalTokenInput{Name: "ci", Scopes: []rbac.Scope{rbac.ScopeRead}})
+		if err != nil {
+			t.Fatalf("Create personal token: %v", err)
+		}
+		return secret
+	}
+	assertRevoked := func(step, secret string) {
+		t.Helper()
+		if _, err := personal.AuthenticatePersonalToken(ctx, secret); !errors.Is(err, repository.ErrNotFound) {
+			t.Fatalf("%s: personal token still valid: %v", step, err)
+		}
+	}
+
+	secret := create()
+	if err := accounts.ChangePassword(ctx, user.ID, "", "password123", "newpassword"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	assertRevoked("ChangePassword", secret)
+
+	secret = create()
+	if err := accounts.ForgotPassword(ctx, user.Email); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	if err := accounts.ResetPassword(ctx, m.lastToken(t), "otherpassword"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	assertRevoked("ResetPassword", secret)
+}
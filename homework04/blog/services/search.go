@@ -0,0 +1,319 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repository"
+	"blog/search"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// SearchService 全文检索，并在文章、评论变更时维护索引
+// 数据库写入提交之后索引更新失败时，调用方通过 RetryPost、RetryComment 记入待重试列表而不是返回错误，
+// 由 RetryWorker 按数据库中的最新状态重新索引；待重试列表只保存在本实例内存中
+type SearchService struct {
+	index    search.Index
+	users    repository.UserRepository
+	posts    repository.PostRepository
+	comments repository.CommentRepository
+	logger   *zap.Logger
+
+	mu sync.Mutex
+	// pending 等待重新索引的文章和评论，值为记入时的序号，重试期间再次记入的不会被移除
+	pending map[searchRef]uint64
+	seq     uint64
+}
+
+// searchRef 等待重新索引的一篇文章或一条评论
+type searchRef struct {
+	kind search.Kind
+	id   uint
+}
+
+func NewSearchService(index search.Index, users repository.UserRepository, posts repository.PostRepository, comments repository.CommentRepository, logger *zap.Logger) *SearchService {
+	return &SearchService{index: index, users: users, posts: posts, comments: comments, logger: logger, pending: map[searchRef]uint64{}}
+}
+
+// Search 检索文章和评论
+func (s *SearchService) Search(ctx context.Context, q search.Query) (*search.Result, error) {
+	return s.index.Search(ctx, q)
+}
+
+// IndexPost 新增或更新文章的索引；未发布的文章只对作者可见，连同评论从索引中移除
+func (s *SearchService) IndexPost(ctx context.Context, post *models.Post) error {
+	if post.Status != models.PostStatusPublished {
+		return s.RemovePost(ctx, post.ID)
+	}
+	author, err := s.authorName(ctx, post.UserID, post.User)
+	if err != nil {
+		return err
+	}
+	if err := s.index.Put(ctx, postDocument(post, author)); err != nil {
+		return fmt.Errorf("failed to index post: %w", err)
+	}
+	return nil
+}
+
+// IndexComment 新增评论的索引
+func (s *SearchService) IndexComment(ctx context.Context, comment *models.Comment) error {
+	author, err := s.authorName(ctx, comment.UserID, comment.User)
+	if err != nil {
+		return err
+	}
+	if err := s.index.Put(ctx, commentDocument(comment, author)); err != nil {
+		return fmt.Errorf("failed to index comment: %w", err)
+	}
+	return nil
+}
+
+// RemovePost 删除文章及其评论的索引
+func (s *SearchService) RemovePost(ctx context.Context, id uint) error {
+	if err := s.index.Remove(ctx, search.KindPost, id); err != nil {
+		return fmt.Errorf("failed to remove post from index: %w", err)
+	}
+	return nil
+}
+
+// RemoveComment 删除评论的索引
+func (s *SearchService) RemoveComment(ctx context.Context, id uint) error {
+	if err := s.index.Remove(ctx, search.KindComment, id); err != nil {
+		return fmt.Errorf("failed to remove comment from index: %w", err)
+	}
+	return nil
+}
+
+// IndexPostWithComments 索引已发布的文章及其全部评论，用于文章发布和重建索引
+func (s *SearchService) IndexPostWithComments(ctx context.Context, post *models.Post) error {
+	if post.Status != models.PostStatusPublished {
+		return s.RemovePost(ctx, post.ID)
+	}
+	author, err := s.authorName(ctx, post.UserID, post.User)
+	if err != nil {
+		return err
+	}
+	docs := []search.Document{postDocument(post, author)}
+	comments, err := s.comments.ListByPost(ctx, post.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load comments for indexing: %w", err)
+	}
+	for i := range comments {
+		docs = append(docs, commentDocument(&comments[i], comments[i].User.Username))
+	}
+	if err := s.index.Put(ctx, docs...); err != nil {
+		return fmt.Errorf("failed to index post %d: %w", post.ID, err)
+	}
+	return nil
+}
+
+// RetryPost 文章已经写入数据库但索引更新失败，记录日志并留待 RetryWorker 重试
+func (s *SearchService) RetryPost(id uint, err error) {
+	s.retry(searchRef{kind: search.KindPost, id: id}, err)
+}
+
+// RetryComment 评论已经写入数据库但索引更新失败，记录日志并留待 RetryWorker 重试
+func (s *SearchService) RetryComment(id uint, err error) {
+	s.retry(searchRef{kind: search.KindComment, id: id}, err)
+}
+
+func (s *SearchService) retry(ref searchRef, err error) {
+	s.logger.Warn("Failed to update search index, will retry",
+		zap.String("kind", string(ref.kind)), zap.Uint("id", ref.id), zap.Error(err))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	s.pending[ref] = s.seq
+}
+
+// pendingCount 等待重新索引的文章和评论数
+func (s *SearchService) pendingCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// retryPending 按数据库中的最新状态重新索引待重试的文章和评论，返回成功的数量；失败的留到下一次
+func (s *SearchService) retryPending(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	pending := make(map[searchRef]uint64, len(s.pending))
+	for ref, seq := range s.pending {
+		pending[ref] = seq
+	}
+	s.mu.Unlock()
+
+	done := 0
+	var errs []error
+	for ref, seq := range pending {
+		var err error
+		if ref.kind == search.KindPost {
+			err = s.syncPost(ctx, ref.id)
+		} else {
+			err = s.syncComment(ctx, ref.id)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		done++
+		s.mu.Lock()
+		if s.pending[ref] == seq {
+			delete(s.pending, ref)
+		}
+		s.mu.Unlock()
+	}
+	return done, errors.Join(errs...)
+}
+
+// syncPost 重新索引文章及其评论，文章已删除时从索引中移除
+func (s *SearchService) syncPost(ctx context.Context, id uint) error {
+	post, err := s.posts.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.RemovePost(ctx, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load post for indexing: %w", err)
+	}
+	return s.IndexPostWithComments(ctx, post)
+}
+
+// syncComment 重新索引评论，评论已删除或所属文章不再是已发布状态时从索引中移除
+func (s *SearchService) syncComment(ctx context.Context, id uint) error {
+	comment, err := s.comments.FindByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.RemoveComment(ctx, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load comment for indexing: %w", err)
+	}
+	post, err := s.posts.FindByID(ctx, comment.PostID)
+	if errors.Is(err, repository.ErrNotFound) || err == nil && post.Status != models.PostStatusPublished {
+		return s.RemoveComment(ctx, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load post for indexing: %w", err)
+	}
+	return s.IndexComment(ctx, comment)
+}
+
+// Rebuild 从数据库重新索引所有已发布的文章和评论，用于启动时填充内存索引
+func (s *SearchService) Rebuild(ctx context.Context) error {
+	posts, _, err := s.posts.List(ctx, repository.PostListOptions{
+		PostFilter: repository.PostFilter{Status: models.PostStatusPublished},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load posts for indexing: %w", err)
+	}
+	for i := range posts {
+		if err := s.IndexPostWithComments(ctx, &posts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// authorName 优先使用已加载的作者信息，否则查询用户表
+func (s *SearchService) authorName(ctx context.Context, userID uint, loaded models.User) (string, error) {
+	if loaded.ID == userID && loaded.Username != "" {
+		return loaded.Username, nil
+	}
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to load author for indexing: %w", err)
+	}
+	return user.Username, nil
+}
+
+func postDocument(post *models.Post, author string) search.Document {
+	return search.Document{
+		Kind:      search.KindPost,
+		ID:        post.ID,
+		PostID:    post.ID,
+		AuthorID:  post.UserID,
+		Author:    author,
+		Title:     post.Title,
+		Content:   post.Content,
+		Tags:      tagNames(post.Tags),
+		CreatedAt: post.CreatedAt,
+	}
+}
+
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+func commentDocument(comment *models.Comment, author string) search.Document {
+	return search.Document{
+		Kind:      search.KindComment,
+		ID:        comment.ID,
+		PostID:    comment.PostID,
+		AuthorID:  comment.UserID,
+		Author:    author,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// RebuildWorker 启动时重建索引的后台任务，重建完成后保持运行直到退出
+func (s *SearchService) RebuildWorker() *SearchRebuildWorker {
+	return &SearchRebuildWorker{search: s}
+}
+
+// SearchRebuildWorker 见 SearchService.RebuildWorker
+type SearchRebuildWorker struct {
+	search *SearchService
+}
+
+func (w *SearchRebuildWorker) Name() string { return "search-index" }
+
+func (w *SearchRebuildWorker) Run(ctx context.Context) error {
+	if err := w.search.Rebuild(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// RetryWorker 定期重试失败的索引更新
+func (s *SearchService) RetryWorker(interval time.Duration, logger *zap.Logger) *SearchRetryWorker {
+	return &SearchRetryWorker{search: s, interval: interval, logger: logger}
+}
+
+// SearchRetryWorker 见 SearchService.RetryWorker
+type SearchRetryWorker struct {
+	search   *SearchService
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func (w *SearchRetryWorker) Name() string { return "search-retry" }
+
+func (w *SearchRetryWorker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			done, err := w.search.retryPending(ctx)
+			if done > 0 {
+				w.logger.Info("Reindexed pending search documents", zap.Int("count", done))
+			}
+			if err != nil {
+				// 仍然失败的留到下个周期
+				w.logger.Warn("Failed to reindex pending search documents", zap.Int("pending", w.search.pendingCount()), zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"blog/search"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// failingIndex 在 failing 为 true 时所有写入都失败的索引
+type failingIndex struct {
+	search.Index
+	failing atomic.Bool
+}
+
+func (i *failingIndex) Put(ctx context.Context, docs ...search.Document) error {
+	if i.failing.Load() {
+		return errors.New("index unavailable")
+	}
+	return i.Index.Put(ctx, docs...)
+}
+
+func (i *failingIndex) Remove(ctx context.Context, kind search.Kind, id uint) error {
+	if i.failing.Load() {
+		return errors.New("index unavailable")
+	}
+	return i.Index.Remove(ctx, kind, id)
+}
+
+// searchTotal 检索 text 的命中数
+func searchTotal(t *testing.T, s *SearchService, text string) int64 {
+	t.Helper()
+	result, err := s.Search(context.Background(), search.Query{Text: text})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	return result.Total
+}
+
+// TestSearchIndexFailureRetried 数据库已经提交时索引失败不影响写入的结果，恢复后由重试按最新状态补齐
+func TestSearchIndexFailureRetried(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	index := &failingIndex{Index: search.NewMemoryIndex()}
+	searchService := NewSearchService(index, store.Users(), store.Posts(), store.Comments(), zap.NewNop())
+	posts := NewPostService(store.Posts(), store.Comments(), store.Taxonomy(), searchService)
+	comments := NewCommentService(store.Posts(), store.Comments(), searchService)
+	author := rbac.Actor{UserID: 1, Role: rbac.RoleAuthor}
+
+	index.failing.Store(true)
+	post, err := posts.Create(ctx, 1, PostInput{Title: "gopher", Content: "first"})
+	if err != nil {
+		t.Fatalf("Create with failing index: %v", err)
+	}
+	if _, err := comments.Create(ctx, 2, post.ID, "nice gopher"); err != nil {
+		t.Fatalf("Create comment with failing index: %v", err)
+	}
+	removed, err := posts.Create(ctx, 1, PostInput{Title: "removed", Content: "second"})
+	if err != nil {
+		t.Fatalf("Create with failing index: %v", err)
+	}
+	if err := posts.Delete(ctx, author, removed.ID); err != nil {
+		t.Fatalf("Delete with failing index: %v", err)
+	}
+	if _, err := posts.Update(ctx, author, post.ID, PostInput{Title: "gopher", Content: "updated"}); err != nil {
+		t.Fatalf("Update with failing index: %v", err)
+	}
+	if got := searchService.pendingCount(); got != 3 {
+		t.Fatalf("pending: got %d, want 3", got)
+	}
+
+	// 仍然失败时保留在待重试列表中
+	if _, err := searchService.retryPending(ctx); err == nil {
+		t.Fatal("retryPending with failing index: got nil error")
+	}
+	if got := searchService.pendingCount(); got != 3 {
+		t.Fatalf("pending after failed retry: got %d, want 3", got)
+	}
+
+	index.failing.Store(false)
+	done, err := searchService.retryPending(ctx)
+	if err != nil || done != 3 {
+		t.Fatalf("retryPending: done %d, err %v", done, err)
+	}
+	if got := searchService.pendingCount(); got != 0 {
+		t.Fatalf("pending after retry: got %d, want 0", got)
+	}
+	if got := searchTotal(t, searchService, "gopher"); got != 2 {
+		t.Fatalf("hits for gopher: got %d, want post and comment", got)
+	}
+	if got := searchTotal(t, searchService, "updated"); got != 1 {
+		t.Fatalf("hits for updated content: got %d, want 1", got)
+	}
+	if got := searchTotal(t, searchService, "removed"); got != 0 {
+		t.Fatalf("hits for deleted post: got %d, want 0", got)
+	}
+}
+
+// TestPublishDueIndexFailureRetried 定时文章发布后索引失败时仍然计为已发布，由重试补齐索引
+func TestPublishDueIndexFailureRetried(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	index := &failingIndex{Index: search.NewMemoryIndex()}
+	searchService := NewSearchService(index, store.Users(), store.Posts(), store.Comments(), zap.NewNop())
+	posts := NewPostService(store.Posts(), store.Comments(), store.Taxonomy(), searchService)
+
+	at := time.Now().Add(time.Hour)
+	if _, err := posts.Create(ctx, 1, PostInput{Title: "gopher", Content: "scheduled", Status: models.PostStatusScheduled, PublishedAt: &at}); err != nil {
+		t.Fatalf("Create scheduled post: %v", err)
+	}
+
+	index.failing.Store(true)
+	published, err := posts.PublishDue(ctx, at)
+	if err != nil || published != 1 {
+		t.Fatalf("PublishDue with failing index: published %d, err %v", published, err)
+	}
+	if got := searchService.pendingCount(); got != 1 {
+		t.Fatalf("pending: got %d, want 1", got)
+	}
+	// 已经发布，下个周期不会重复发布
+	if published, err := posts.PublishDue(ctx, at); err != nil || published != 0 {
+		t.Fatalf("PublishDue again: published %d, err %v", published, err)
+	}
+
+	index.failing.Store(false)
+	if _, err := searchService.retryPending(ctx); err != nil {
+		t.Fatalf("retryPending: %v", err)
+	}
+	if got := searchTotal(t, searchService, "gopher"); got != 1 {
+		t.Fatalf("hits after retry: got %d, want 1", got)
+	}
+}
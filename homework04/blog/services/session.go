@@ -0,0 +1,127 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// sessionTouchInterval 会话最近活动时间的更新间隔，避免每个请求都写数据库
+	sessionTouchInterval = time.Minute
+	// maxUserAgentLength 会话中保存的 User-Agent 最大长度
+	maxUserAgentLength = 255
+)
+
+// ClientInfo 发起登录或刷新的客户端，记录在会话中
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// apply 把客户端信息写入会话，IP 为空时保留原值
+func (c ClientInfo) apply(session *models.Session) {
+	if c.IP != "" {
+		session.IP = c.IP
+	}
+	if c.UserAgent != "" {
+		userAgent := []rune(c.UserAgent)
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		session.UserAgent = string(userAgent)
+	}
+}
+
+// Sessions 用户仍然有效的会话，按最近活动时间倒序
+func (s *TokenService) Sessions(ctx context.Context, userID uint) ([]models.Session, error) {
+	return s.tokens.ListSessions(ctx, userID, time.Now())
+}
+
+// RevokeSession 吊销用户自己的会话，会话的刷新令牌和访问令牌立即失效；会话不存在、已结束或属于其他用户时返回 ErrNotFound
+func (s *TokenService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	session, err := s.tokens.FindSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if session.UserID != userID || !session.Active(now) {
+		return ErrNotFound
+	}
+	return s.revokeSession(ctx, sessionID, now)
+}
+
+// RevokeSessions 吊销用户除 exceptID 以外的所有会话，exceptID 为空时全部吊销；用于退出其他设备和重置密码
+func (s *TokenService) RevokeSessions(ctx context.Context, userID uint, exceptID string) error {
+	now := time.Now()
+	if err := s.tokens.RevokeUserSessions(ctx, userID, exceptID, now); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if err := s.tokens.RevokeUserRefreshTokens(ctx, userID, exceptID, now); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// TouchSession 供认证中间件检查访问令牌所属的会话是否仍然有效，并记录最近活动时间和 IP
+func (s *TokenService) TouchSession(ctx context.Context, sessionID, ip string) (bool, error) {
+	session, err := s.tokens.FindSession(ctx, sessionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if !session.Active(now) {
+		return false, nil
+	}
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval || ip != session.IP {
+		session.LastSeenAt = now
+		ClientInfo{IP: ip}.apply(session)
+		if err := s.tokens.UpdateSession(ctx, session); err != nil {
+			return false, fmt.Errorf("failed to update session: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// revokeSession 先吊销会话使其访问令牌立即失效，再吊销会话的刷新令牌
+func (s *TokenService) revokeSession(ctx context.Context, sessionID string, now time.Time) error {
+	if _, err := s.tokens.RevokeSession(ctx, sessionID, now); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if err := s.tokens.RevokeFamily(ctx, sessionID, now); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// extendSession 刷新令牌时更新会话的活动时间和过期时间；升级前登录、没有会话记录的 family 补建会话
+func (s *TokenService) extendSession(ctx context.Context, token *models.RefreshToken, client ClientInfo, now time.Time) error {
+	session, err := s.tokens.FindSession(ctx, token.FamilyID)
+	if errors.Is(err, repository.ErrNotFound) {
+		session = &models.Session{ID: token.FamilyID, UserID: token.UserID, CreatedAt: token.CreatedAt, LastSeenAt: now, ExpiresAt: now.Add(s.refreshTTL)}
+		client.apply(session)
+		if err := s.tokens.CreateSession(ctx, session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if session.RevokedAt != nil {
+		return ErrInvalidRefreshToken
+	}
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(s.refreshTTL)
+	client.apply(session)
+	if err := s.tokens.UpdateSession(ctx, session); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
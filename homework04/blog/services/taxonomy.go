@@ -0,0 +1,214 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TaxonomyService 文章分类和标签：分类由编辑维护，标签随文章创建，不再使用时自动删除
+type TaxonomyService struct {
+	taxonomy repository.TaxonomyRepository
+}
+
+func NewTaxonomyService(taxonomy repository.TaxonomyRepository) *TaxonomyService {
+	return &TaxonomyService{taxonomy: taxonomy}
+}
+
+// CategoryInput 创建和修改分类的参数，ParentID 为空表示顶级分类
+type CategoryInput struct {
+	Name     string
+	ParentID *uint
+}
+
+// CategoryNode 分类树的一个节点，PostCount 包括子分类中的文章
+type CategoryNode struct {
+	models.Category
+	PostCount int64
+	Children  []*CategoryNode
+}
+
+// Categories 返回分类树，同级分类按名称排序
+func (s *TaxonomyService) Categories(ctx context.Context) ([]*CategoryNode, error) {
+	categories, err := s.taxonomy.ListCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	counts, err := s.taxonomy.CountPostsByCategory(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	nodes := make(map[uint]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{Category: category}
+	}
+	var roots []*CategoryNode
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if parent, ok := parentNode(nodes, category); ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	for _, root := range roots {
+		countPosts(root, counts)
+	}
+	return roots, nil
+}
+
+// parentNode 父分类的节点；父分类不存在时（不应出现）当作顶级分类
+func parentNode(nodes map[uint]*CategoryNode, category models.Category) (*CategoryNode, bool) {
+	if category.ParentID == nil {
+		return nil, false
+	}
+	parent, ok := nodes[*category.ParentID]
+	return parent, ok
+}
+
+// countPosts 累加子分类的文章数
+func countPosts(node *CategoryNode, counts map[uint]int64) int64 {
+	node.PostCount = counts[node.ID]
+	for _, child := range node.Children {
+		node.PostCount += countPosts(child, counts)
+	}
+	return node.PostCount
+}
+
+// CreateCategory 创建分类，名称不能重复
+func (s *TaxonomyService) CreateCategory(ctx context.Context, input CategoryInput) (*models.Category, error) {
+	if err := s.checkName(ctx, 0, input.Name); err != nil {
+		return nil, err
+	}
+	if input.ParentID != nil {
+		if _, err := s.findParent(ctx, *input.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	category := &models.Category{Name: input.Name, ParentID: input.ParentID}
+	if err := s.taxonomy.CreateCategory(ctx, category); err != nil {
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+	return category, nil
+}
+
+// UpdateCategory 修改分类名称或移动到其他父分类下，不能移动到自身或其子分类下
+func (s *TaxonomyService) UpdateCategory(ctx context.Context, id uint, input CategoryInput) (*models.Category, error) {
+	category, err := s.taxonomy.FindCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkName(ctx, id, input.Name); err != nil {
+		return nil, err
+	}
+	if input.ParentID != nil {
+		if _, err := s.findParent(ctx, *input.ParentID); err != nil {
+			return nil, err
+		}
+		categories, err := s.taxonomy.ListCategories(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load categories: %w", err)
+		}
+		if containsCategory(subtreeIDs(categories, id), *input.ParentID) {
+			return nil, ErrInvalidParent
+		}
+	}
+
+	category.Name = input.Name
+	category.ParentID = input.ParentID
+	if err := s.taxonomy.UpdateCategory(ctx, category); err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	return category, nil
+}
+
+// DeleteCategory 删除没有子分类的分类，其下的文章变为未分类
+func (s *TaxonomyService) DeleteCategory(ctx context.Context, id uint) error {
+	if _, err := s.taxonomy.FindCategory(ctx, id); err != nil {
+		return err
+	}
+	categories, err := s.taxonomy.ListCategories(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load categories: %w", err)
+	}
+	if len(subtreeIDs(categories, id)) > 1 {
+		return ErrCategoryHasChildren
+	}
+	if err := s.taxonomy.DeleteCategory(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	return nil
+}
+
+// Tags 有文章使用的标签及其文章数，按文章数倒序
+func (s *TaxonomyService) Tags(ctx context.Context) ([]repository.TagCount, error) {
+	tags, err := s.taxonomy.ListTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+	return tags, nil
+}
+
+// checkName 分类名称是否已被 id 以外的分类使用
+func (s *TaxonomyService) checkName(ctx context.Context, id uint, name string) error {
+	existing, err := s.taxonomy.FindCategoryByName(ctx, name)
+	if err == nil && existing.ID != id {
+		return ErrCategoryExists
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// findParent 父分类不存在时返回 ErrCategoryNotFound
+func (s *TaxonomyService) findParent(ctx context.Context, id uint) (*models.Category, error) {
+	parent, err := s.taxonomy.FindCategory(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCategoryNotFound
+	}
+	return parent, err
+}
+
+// subtreeIDs 分类 rootID 及其所有子孙分类的 ID
+func subtreeIDs(categories []models.Category, rootID uint) []uint {
+	children := map[uint][]uint{}
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+	ids := []uint{rootID}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids
+}
+
+func containsCategory(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags 标签名去掉首尾空白并转为小写，去掉空标签和重复标签
+func NormalizeTags(names []string) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		tags = append(tags, name)
+	}
+	return tags
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"blog/rbac"
+	"blog/repository"
+	"blog/search"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// TestTaxonomy 分类过滤包括子分类，不再使用的标签随文章修改和删除清理
+func TestTaxonomy(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	searchService := NewSearchService(search.NewMemoryIndex(), store.Users(), store.Posts(), store.Comments(), zap.NewNop())
+	posts := NewPostService(store.Posts(), store.Comments(), store.Taxonomy(), searchService)
+	taxonomy := NewTaxonomyService(store.Taxonomy())
+	author := rbac.Actor{UserID: 1, Role: rbac.RoleAuthor}
+
+	parent, err := taxonomy.CreateCategory(ctx, CategoryInput{Name: "tech"})
+	if err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	child, _ := taxonomy.CreateCategory(ctx, CategoryInput{Name: "go", ParentID: &parent.ID})
+	if _, err := taxonomy.UpdateCategory(ctx, parent.ID, CategoryInput{Name: "tech", ParentID: &child.ID}); !errors.Is(err, ErrInvalidParent) {
+		t.Fatalf("move category under its child: got %v, want ErrInvalidParent", err)
+	}
+
+	first, err := posts.Create(ctx, 1, PostInput{Title: "a", Content: "a", CategoryID: child.ID, Tags: []string{"Go", "go", " web "}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got := tagNames(first.Tags); !reflect.DeepEqual(got, []string{"go", "web"}) {
+		t.Fatalf("tags: got %v", got)
+	}
+	second, _ := posts.Create(ctx, 1, PostInput{Title: "b", Content: "b", Tags: []string{"go"}})
+	if _, err := posts.Create(ctx, 1, PostInput{Title: "c", Content: "c", CategoryID: 99}); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("Create in missing category: got %v, want ErrCategoryNotFound", err)
+	}
+
+	page, err := posts.List(ctx, PostListInput{Category: "tech"})
+	if err != nil || len(page.Posts) != 1 || page.Posts[0].ID != first.ID {
+		t.Fatalf("List by parent category: %+v, %v", page, err)
+	}
+	nodes, _ := taxonomy.Categories(ctx)
+	if len(nodes) != 1 || nodes[0].PostCount != 1 || len(nodes[0].Children) != 1 {
+		t.Fatalf("Categories: %+v", nodes)
+	}
+
+	if _, err := posts.Update(ctx, author, first.ID, PostInput{Title: "a", Content: "a", Tags: []string{"go"}}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := posts.Delete(ctx, author, second.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	tags, _ := taxonomy.Tags(ctx)
+	if len(tags) != 1 || tags[0].Name != "go" || tags[0].Posts != 1 {
+		t.Fatalf("Tags: %+v", tags)
+	}
+	if err := posts.Delete(ctx, author, first.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if tags, _ := store.Taxonomy().EnsureTags(ctx, []string{"go"}); tags[0].ID == first.Tags[0].ID {
+		t.Fatal("unused tag was not deleted")
+	}
+}
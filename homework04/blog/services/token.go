@@ -0,0 +1,213 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// refreshTokenBytes 刷新令牌的随机字节数
+const refreshTokenBytes = 32
+
+// TokenPair 登录或刷新后返回给客户端的令牌
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// TokenService 签发访问令牌和刷新令牌，负责轮换、吊销和重用检测，并管理令牌所属的登录会话
+type TokenService struct {
+	users      repository.UserRepository
+	tokens     repository.TokenRepository
+	jwtManager *utils.JWTManager
+	refreshTTL time.Duration
+}
+
+func NewTokenService(users repository.UserRepository, tokens repository.TokenRepository, jwtManager *utils.JWTManager, refreshTTL time.Duration) *TokenService {
+	return &TokenService{users: users, tokens: tokens, jwtManager: jwtManager, refreshTTL: refreshTTL}
+}
+
+// Issue 登录成功后创建会话并签发令牌，刷新令牌开启一个新的 family，family ID 即会话 ID
+func (s *TokenService) Issue(ctx context.Context, user *models.User, client ClientInfo) (*TokenPair, error) {
+	familyID, err := utils.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.Session{
+		ID:         familyID,
+		UserID:     user.ID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.refreshTTL),
+	}
+	client.apply(session)
+	if err := s.tokens.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return s.issue(ctx, user, familyID)
+}
+
+// Refresh 用刷新令牌换取新的令牌，旧的刷新令牌随即失效；新的访问令牌带上用户当前的角色，会话的活动时间随之更新
+// 已失效的刷新令牌再次出现说明可能被盗用，吊销整个会话并返回 ErrRefreshTokenReused
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	token, err := s.tokens.FindRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.Revoked() {
+		return nil, s.revokeReusedFamily(ctx, token, now)
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rotated, err := s.tokens.RevokeRefreshToken(ctx, token.ID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+	if !rotated {
+		// 并发请求已经抢先使用了这个令牌
+		return nil, s.revokeReusedFamily(ctx, token, now)
+	}
+
+	user, err := s.users.FindByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+	if err := s.extendSession(ctx, token, client, now); err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, token.FamilyID)
+}
+
+// Logout 吊销当前访问令牌和它所属的会话；提供刷新令牌时同时吊销其所在的会话
+func (s *TokenService) Logout(ctx context.Context, claims *utils.Claims, refreshToken string) error {
+	if err := s.RevokeAccessToken(ctx, claims); err != nil {
+		return err
+	}
+	if claims.SessionID != "" {
+		if err := s.revokeSession(ctx, claims.SessionID, time.Now()); err != nil {
+			return err
+		}
+	}
+	if refreshToken == "" {
+		return nil
+	}
+
+	token, err := s.tokens.FindRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidRefreshToken
+		}
+		return err
+	}
+	if token.UserID != claims.UserID {
+		return ErrInvalidRefreshToken
+	}
+	return s.revokeSession(ctx, token.FamilyID, time.Now())
+}
+
+// RevokeAccessToken 把访问令牌加入黑名单直到其过期
+func (s *TokenService) RevokeAccessToken(ctx context.Context, claims *utils.Claims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	if err := s.tokens.RevokeAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	return nil
+}
+
+// IsAccessTokenRevoked 供认证中间件检查 jti 是否在黑名单中
+func (s *TokenService) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	return s.tokens.IsAccessTokenRevoked(ctx, jti)
+}
+
+func (s *TokenService) issue(ctx context.Context, user *models.User, familyID string) (*TokenPair, error) {
+	accessToken, err := s.jwtManager.GenerateToken(user, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	refreshToken, err := utils.RandomToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = s.tokens.CreateRefreshToken(ctx, &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    s.jwtManager.Expiration(),
+	}, nil
+}
+
+func (s *TokenService) revokeReusedFamily(ctx context.Context, token *models.RefreshToken, now time.Time) error {
+	if err := s.revokeSession(ctx, token.FamilyID, now); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// CleanupWorker 定期清理过期的刷新令牌、黑名单记录和会话
+func (s *TokenService) CleanupWorker(interval time.Duration, logger *zap.Logger) *TokenCleanupWorker {
+	return &TokenCleanupWorker{tokens: s.tokens, interval: interval, logger: logger}
+}
+
+// TokenCleanupWorker 见 TokenService.CleanupWorker
+type TokenCleanupWorker struct {
+	tokens   repository.TokenRepository
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+func (w *TokenCleanupWorker) Name() string { return "token-cleanup" }
+
+func (w *TokenCleanupWorker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			deleted, err := w.tokens.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				// 清理失败不影响服务，下个周期重试
+				w.logger.Warn("Failed to delete expired tokens", zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				w.logger.Info("Deleted expired tokens", zap.Int64("count", deleted))
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"blog/config"
+	"blog/models"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestTokenService(t *testing.T) (*TokenService, *utils.JWTManager) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	if err := store.Users().Create(context.Background(), &models.User{Username: "alice", Role: "author"}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	jwtManager := utils.NewJWTManager(config.JWTConfig{
+		Secret:     "test-secret-0123456789abcdefghijklmnopqrstuvwxyz",
+		Issuer:     "blog",
+		Audience:   "blog-api",
+		Algorithm:  config.JWTAlgorithmHS256,
+		Expiration: config.Duration(time.Minute),
+	})
+	return NewTokenService(store.Users(), store.Tokens(), jwtManager, time.Hour), jwtManager
+}
+
+func TestTokenService_RotationAndReuseDetection(t *testing.T) {
+	ctx := context.Background()
+	tokens, _ := newTestTokenService(t)
+
+	first, err := tokens.Issue(ctx, &models.User{ID: 1}, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, err := tokens.Refresh(ctx, first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("Refresh did not rotate the refresh token")
+	}
+
+	// 旧令牌再次出现：判定为重用，整个 family 被吊销，包括刚换发的令牌
+	if _, err := tokens.Refresh(ctx, first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse of rotated token: got %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := tokens.Refresh(ctx, second.RefreshToken, ClientInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("token from revoked family: got %v, want ErrRefreshTokenReused", err)
+	}
+
+	// 其他登录会话不受影响
+	other, err := tokens.Issue(ctx, &models.User{ID: 1}, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := tokens.Refresh(ctx, other.RefreshToken, ClientInfo{}); err != nil {
+		t.Fatalf("Refresh in another family: %v", err)
+	}
+
+	if _, err := tokens.Refresh(ctx, "unknown", ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("unknown token: got %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestTokenService_Logout(t *testing.T) {
+	ctx := context.Background()
+	tokens, jwtManager := newTestTokenService(t)
+
+	pair, err := tokens.Issue(ctx, &models.User{ID: 1, Username: "alice", Role: "author"}, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	claims, err := jwtManager.ParseToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if claims.Username != "alice" || claims.Role != "author" {
+		t.Fatalf("claims: got username %q role %q", claims.Username, claims.Role)
+	}
+
+	if err := tokens.Logout(ctx, &utils.Claims{UserID: 2, RegisteredClaims: claims.RegisteredClaims}, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("logout with another user's refresh token: got %v, want ErrInvalidRefreshToken", err)
+	}
+	if err := tokens.Logout(ctx, claims, pair.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	revoked, err := tokens.IsAccessTokenRevoked(ctx, claims.ID)
+	if err != nil || !revoked {
+		t.Fatalf("access token after logout: revoked=%v err=%v", revoked, err)
+	}
+	if _, err := tokens.Refresh(ctx, pair.RefreshToken, ClientInfo{}); err == nil {
+		t.Fatal("refresh token still usable after logout")
+	}
+}
+
+func TestTokenService_Sessions(t *testing.T) {
+	ctx := context.Background()
+	tokens, jwtManager := newTestTokenService(t)
+	user := &models.User{ID: 1, Username: "alice", Role: "author"}
+
+	laptop, err := tokens.Issue(ctx, user, ClientInfo{IP: "192.0.2.1", UserAgent: "laptop"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	phone, err := tokens.Issue(ctx, user, ClientInfo{IP: "192.0.2.2", UserAgent: "phone"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	claims, err := jwtManager.ParseToken(laptop.AccessToken)
+	if err != nil || claims.SessionID == "" {
+		t.Fatalf("ParseToken: sid %q, err %v", claims.SessionID, err)
+	}
+
+	sessions, err := tokens.Sessions(ctx, user.ID)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("Sessions: got %d sessions, err %v", len(sessions), err)
+	}
+	if err := tokens.RevokeSession(ctx, 2, claims.SessionID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("RevokeSession of another user: got %v, want ErrNotFound", err)
+	}
+
+	// 退出其他设备：当前会话保留，其他会话的访问令牌和刷新令牌立即失效
+	if err := tokens.RevokeSessions(ctx, user.ID, claims.SessionID); err != nil {
+		t.Fatalf("RevokeSessions: %v", err)
+	}
+	phoneClaims, _ := jwtManager.ParseToken(phone.AccessToken)
+	if active, err := tokens.TouchSession(ctx, phoneClaims.SessionID, "192.0.2.2"); err != nil || active {
+		t.Fatalf("revoked session: active=%v err=%v", active, err)
+	}
+	if _, err := tokens.Refresh(ctx, phone.RefreshToken, ClientInfo{}); err == nil {
+		t.Fatal("refresh token of a revoked session still usable")
+	}
+	if active, err := tokens.TouchSession(ctx, claims.SessionID, "192.0.2.3"); err != nil || !active {
+		t.Fatalf("current session: active=%v err=%v", active, err)
+	}
+
+	sessions, _ = tokens.Sessions(ctx, user.ID)
+	if len(sessions) != 1 || sessions[0].IP != "192.0.2.3" || sessions[0].UserAgent != "laptop" {
+		t.Fatalf("Sessions after revoking others: %+v", sessions)
+	}
+}
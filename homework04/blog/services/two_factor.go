@@ -0,0 +1,385 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// purposeLoginChallenge 两步登录的 challenge 令牌
+	purposeLoginChallenge = "login_2fa"
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// recoveryCodeLength 恢复码长度（base32 字符，50 位随机数），显示为 xxxxx-xxxxx
+	recoveryCodeLength = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TwoFactorConfig 两步验证配置
+type TwoFactorConfig struct {
+	// Issuer 验证器应用中显示的服务名称
+	Issuer string
+	// ChallengeTTL 输入密码后完成两步验证的时限
+	ChallengeTTL time.Duration
+	// PolicyCacheTTL 角色策略的缓存时间，其他实例上的修改最多延迟这么久生效
+	PolicyCacheTTL time.Duration
+}
+
+// TwoFactorSetup 生成的 TOTP 密钥，确认前不生效
+type TwoFactorSetup struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorChallenge 密码正确但还需要验证码时返回给客户端的 challenge
+type TwoFactorChallenge struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+// TwoFactorService TOTP 两步验证（RFC 6238）：启用、关闭、恢复码、两步登录和按角色强制启用
+type TwoFactorService struct {
+	users     repository.UserRepository
+	twoFactor repository.TwoFactorRepository
+	policies  repository.PolicyRepository
+	tokens    *TokenService
+	signer    *utils.ActionTokenSigner
+	box       *utils.SecretBox
+	cache     *UserCache
+	guard     *LoginGuard
+	cfg       TwoFactorConfig
+
+	mu             sync.Mutex
+	required       map[rbac.Role]bool
+	policyLoadedAt time.Time
+}
+
+func NewTwoFactorService(users repository.UserRepository, twoFactor repository.TwoFactorRepository, policies repository.PolicyRepository, tokens *TokenService, signer *utils.ActionTokenSigner, box *utils.SecretBox, cache *UserCache, guard *LoginGuard, cfg TwoFactorConfig) *TwoFactorService {
+	return &TwoFactorService{
+		users:     users,
+		twoFactor: twoFactor,
+		policies:  policies,
+		tokens:    tokens,
+		signer:    signer,
+		box:       box,
+		cache:     cache,
+		guard:     guard,
+		cfg:       cfg,
+	}
+}
+
+// Setup 生成新的 TOTP 密钥，用户在验证器应用中添加后调用 Enable 确认；重复调用会替换未确认的密钥
+func (s *TwoFactorService) Setup(ctx context.Context, userID uint) (*TwoFactorSetup, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.box.Seal([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt TOTP secret: %w", err)
+	}
+	user.TOTPSecret = encrypted
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return &TwoFactorSetup{Secret: secret, URI: utils.TOTPURI(secret, s.cfg.Issuer, user.Username)}, nil
+}
+
+// Enable 用验证器应用生成的验证码确认密钥并启用两步验证，返回恢复码（只在此时返回一次）
+func (s *TwoFactorService) Enable(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	step, err := s.checkTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	user.TOTPLastStep = step
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	s.cache.Invalidate(user.ID)
+	return s.replaceRecoveryCodes(ctx, user.ID)
+}
+
+// Disable 关闭两步验证，需要密码和验证码（或恢复码）；角色要求两步验证时不能关闭
+func (s *TwoFactorService) Disable(ctx context.Context, userID uint, password, code string) error {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := user.CheckPassword(password); err != nil {
+		return ErrInvalidCredentials
+	}
+	required, err := s.Required(ctx, rbac.Role(user.Role))
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+	if err := s.verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	if err := s.users.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	s.cache.Invalidate(user.ID)
+	if err := s.twoFactor.ReplaceRecoveryCodes(ctx, user.ID, nil); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes 用验证码换一组新的恢复码，旧的恢复码全部作废
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+	return s.replaceRecoveryCodes(ctx, user.ID)
+}
+
+// RecoveryCodesLeft 剩余可用的恢复码数量
+func (s *TwoFactorService) RecoveryCodesLeft(ctx context.Context, userID uint) (int64, error) {
+	return s.twoFactor.CountRecoveryCodes(ctx, userID)
+}
+
+// Challenge 为已通过密码验证的用户签发 challenge 令牌
+// 令牌绑定密码哈希和 TOTP 密钥，修改密码或关闭两步验证后失效
+func (s *TwoFactorService) Challenge(user *models.User) (*TwoFactorChallenge, error) {
+	token, err := s.signer.Sign(purposeLoginChallenge, user.ID, challengeFingerprint(user), time.Now().Add(s.cfg.ChallengeTTL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign login challenge: %w", err)
+	}
+	return &TwoFactorChallenge{Token: token, ExpiresIn: s.cfg.ChallengeTTL}, nil
+}
+
+// CompleteLogin 校验 challenge 和验证码（或恢复码），成功后签发令牌
+// 验证码错误与密码错误一样计入用户名和 IP 的失败次数
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, challenge, code string, client ClientInfo) (*TokenPair, *models.User, error) {
+	claims, err := s.signer.Verify(challenge, purposeLoginChallenge, time.Now())
+	if err != nil {
+		return nil, nil, ErrInvalidChallenge
+	}
+	user, err := s.users.FindByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrInvalidChallenge
+		}
+		return nil, nil, err
+	}
+	if !user.TwoFactorEnabled() || subtle.ConstantTimeCompare([]byte(claims.Fingerprint), []byte(challengeFingerprint(user))) != 1 {
+		return nil, nil, ErrInvalidChallenge
+	}
+	if user.Disabled {
+		return nil, nil, ErrAccountDisabled
+	}
+	attempt, err := s.guard.Begin(ctx, user.Username, client.IP)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = s.verify(ctx, user, code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if failErr := attempt.Fail(ctx); failErr != nil {
+			return nil, nil, failErr
+		}
+		return nil, nil, err
+	}
+	if releaseErr := attempt.Release(ctx); releaseErr != nil {
+		return nil, nil, releaseErr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.guard.Succeed(ctx, user.Username); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.tokens.Issue(ctx, user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, user, nil
+}
+
+// verify 校验 6 位 TOTP 验证码或恢复码；每个验证码、恢复码只能使用一次
+func (s *TwoFactorService) verify(ctx context.Context, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		step, err := s.checkTOTP(user, code)
+		if err != nil {
+			return err
+		}
+		advanced, err := s.twoFactor.AdvanceTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return fmt.Errorf("failed to record TOTP step: %w", err)
+		}
+		if !advanced {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.twoFactor.UseRecoveryCode(ctx, user.ID, utils.HashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// checkTOTP 校验验证码，返回其时间步；不大于上次使用的时间步视为重放
+func (s *TwoFactorService) checkTOTP(user *models.User, code string) (int64, error) {
+	secret, err := s.box.Open(user.TOTPSecret)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	step, ok := utils.ValidateTOTP(string(secret), code, time.Now())
+	if !ok || step <= user.TOTPLastStep {
+		return 0, ErrInvalidTwoFactorCode
+	}
+	return step, nil
+}
+
+func (s *TwoFactorService) replaceRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf)[:recoveryCodeLength])
+		codes[i] = raw[:recoveryCodeLength/2] + "-" + raw[recoveryCodeLength/2:]
+		hashes[i] = utils.HashToken(raw)
+	}
+	if err := s.twoFactor.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode 忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// challengeFingerprint challenge 令牌绑定的账号状态
+func challengeFingerprint(user *models.User) string {
+	return fingerprint(purposeLoginChallenge, user)
+}
+
+// Required 角色是否要求两步验证，供认证中间件在每个请求上调用，结果缓存 PolicyCacheTTL
+func (s *TwoFactorService) Required(ctx context.Context, role rbac.Role) (bool, error) {
+	s.mu.Lock()
+	required, loadedAt := s.required, s.policyLoadedAt
+	s.mu.Unlock()
+	if required != nil && time.Since(loadedAt) < s.cfg.PolicyCacheTTL {
+		return required[role], nil
+	}
+
+	policies, err := s.policies.ListRolePolicies(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to load role policies: %w", err)
+	}
+	required = make(map[rbac.Role]bool, len(policies))
+	for _, policy := range policies {
+		required[rbac.Role(policy.Role)] = policy.RequireTwoFactor
+	}
+
+	s.mu.Lock()
+	s.required, s.policyLoadedAt = required, time.Now()
+	s.mu.Unlock()
+	return required[role], nil
+}
+
+// RolePolicies 返回所有角色的策略，没有设置过的角色使用默认值；需要 user:manage 权限
+func (s *TwoFactorService) RolePolicies(ctx context.Context, actor rbac.Actor) ([]models.RolePolicy, error) {
+	if !actor.Can(rbac.UserManage) {
+		return nil, ErrForbidden
+	}
+	stored, err := s.policies.ListRolePolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byRole := make(map[string]models.RolePolicy, len(stored))
+	for _, policy := range stored {
+		byRole[policy.Role] = policy
+	}
+
+	policies := make([]models.RolePolicy, 0, len(rbac.Roles))
+	for i := len(rbac.Roles) - 1; i >= 0; i-- {
+		role := string(rbac.Roles[i])
+		policy, ok := byRole[role]
+		if !ok {
+			policy = models.RolePolicy{Role: role}
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// SetTwoFactorRequired 设置角色是否要求两步验证；需要 user:manage 权限
+// 已登录但未启用两步验证的用户随即只能访问启用两步验证的接口
+func (s *TwoFactorService) SetTwoFactorRequired(ctx context.Context, actor rbac.Actor, role rbac.Role, required bool) (*models.RolePolicy, error) {
+	if !actor.Can(rbac.UserManage) {
+		return nil, ErrForbidden
+	}
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	policy := &models.RolePolicy{Role: string(role), RequireTwoFactor: required}
+	if err := s.policies.SaveRolePolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("failed to save role policy: %w", err)
+	}
+
+	s.mu.Lock()
+	s.required = nil
+	s.mu.Unlock()
+	return policy, nil
+}
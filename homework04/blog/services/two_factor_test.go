@@ -0,0 +1,128 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"blog/utils"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestTwoFactorService(t *testing.T) (*TwoFactorService, repository.UserRepository, *models.User) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	user := &models.User{Username: "alice", Email: "alice@example.com", Role: "editor"}
+	if err := user.HashPassword("password123"); err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if err := store.Users().Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	box, err := utils.NewSecretBox("test-secret-0123456789abcdefghijklmnopqrstuvwxyz")
+	if err != nil {
+		t.Fatalf("NewSecretBox: %v", err)
+	}
+	tokens, _ := newTestTokenService(t)
+	// 不限制重试间隔，并发提交验证码的测试不会被延迟
+	guard := NewLoginGuard(store.LoginAttempts(), store.Users(), LoginGuardConfig{MaxAttempts: 100, IPMaxAttempts: 100, Window: time.Minute, LockoutDuration: time.Minute})
+	twoFactor := NewTwoFactorService(store.Users(), store.TwoFactor(), store.Policies(), tokens,
+		utils.NewActionTokenSigner("test-secret-0123456789abcdefghijklmnopqrstuvwxyz"), box,
+		NewUserCache(store.Users(), time.Minute), guard, TwoFactorConfig{Issuer: "Blog", ChallengeTTL: time.Minute, PolicyCacheTTL: time.Minute})
+	return twoFactor, store.Users(), user
+}
+
+// enable 启用两步验证，返回密钥
+func enable(t *testing.T, s *TwoFactorService, userID uint) string {
+	t.Helper()
+	setup, err := s.Setup(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	code, _ := utils.TOTPCode(setup.Secret, utils.TOTPStep(time.Now())-1)
+	if _, err := s.Enable(context.Background(), userID, code); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	return setup.Secret
+}
+
+// TestCompleteLoginRejectsReplay 同一个验证码被并发提交时只有一次登录成功
+func TestCompleteLoginRejectsReplay(t *testing.T) {
+	s, users, user := newTestTwoFactorService(t)
+	secret := enable(t, s, user.ID)
+	user, _ = users.FindByID(context.Background(), user.ID)
+	code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < 8; i++ {
+		challenge, err := s.Challenge(user)
+		if err != nil {
+			t.Fatalf("Challenge: %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := s.CompleteLogin(context.Background(), challenge.Token, code, ClientInfo{IP: "192.0.2.1"})
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrInvalidTwoFactorCode) {
+				t.Errorf("CompleteLogin: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if succeeded != 1 {
+		t.Fatalf("%d logins succeeded with the same code, want 1", succeeded)
+	}
+}
+
+// TestChallengeBoundToPassword 修改密码后，之前签发的 challenge 失效
+func TestChallengeBoundToPassword(t *testing.T) {
+	s, users, user := newTestTwoFactorService(t)
+	secret := enable(t, s, user.ID)
+	user, _ = users.FindByID(context.Background(), user.ID)
+	challenge, err := s.Challenge(user)
+	if err != nil {
+		t.Fatalf("Challenge: %v", err)
+	}
+
+	if err := user.HashPassword("new-password"); err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if err := users.Update(context.Background(), user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if _, _, err := s.CompleteLogin(context.Background(), challenge.Token, code, ClientInfo{IP: "192.0.2.1"}); !errors.Is(err, ErrInvalidChallenge) {
+		t.Fatalf("CompleteLogin after password change: got %v, want ErrInvalidChallenge", err)
+	}
+}
+
+func TestRequiredRoleCannotDisable(t *testing.T) {
+	s, _, user := newTestTwoFactorService(t)
+	admin := rbac.Actor{UserID: 99, Role: rbac.RoleAdmin}
+	if _, err := s.SetTwoFactorRequired(context.Background(), rbac.Actor{UserID: user.ID, Role: rbac.RoleEditor}, rbac.RoleEditor, true); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("SetTwoFactorRequired as editor: got %v, want ErrForbidden", err)
+	}
+	if _, err := s.SetTwoFactorRequired(context.Background(), admin, rbac.RoleEditor, true); err != nil {
+		t.Fatalf("SetTwoFactorRequired: %v", err)
+	}
+	if required, err := s.Required(context.Background(), rbac.RoleEditor); err != nil || !required {
+		t.Fatalf("Required(editor) = %v, %v; want true", required, err)
+	}
+
+	secret := enable(t, s, user.ID)
+	code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err := s.Disable(context.Background(), user.ID, "password123", code); !errors.Is(err, ErrTwoFactorRequired) {
+		t.Fatalf("Disable: got %v, want ErrTwoFactorRequired", err)
+	}
+}
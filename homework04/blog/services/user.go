@@ -0,0 +1,55 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"context"
+	"fmt"
+)
+
+// UserService 用户管理，供管理员使用
+type UserService struct {
+	users repository.UserRepository
+	cache *UserCache
+}
+
+func NewUserService(users repository.UserRepository, cache *UserCache) *UserService {
+	return &UserService{users: users, cache: cache}
+}
+
+// SetRole 修改用户角色，需要 user:manage 权限；认证中间件按数据库中的角色鉴权，修改立即生效
+func (s *UserService) SetRole(ctx context.Context, actor rbac.Actor, userID uint, role rbac.Role) (*models.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	if actor.UserID == userID {
+		return nil, ErrOwnRole
+	}
+	return s.update(ctx, actor, userID, func(user *models.User) { user.Role = string(role) })
+}
+
+// SetDisabled 禁用或启用用户，需要 user:manage 权限；被禁用的用户不能登录，已签发的令牌也随即失效
+func (s *UserService) SetDisabled(ctx context.Context, actor rbac.Actor, userID uint, disabled bool) (*models.User, error) {
+	if actor.UserID == userID {
+		return nil, ErrOwnAccount
+	}
+	return s.update(ctx, actor, userID, func(user *models.User) { user.Disabled = disabled })
+}
+
+func (s *UserService) update(ctx context.Context, actor rbac.Actor, userID uint, apply func(*models.User)) (*models.User, error) {
+	if !actor.Can(rbac.UserManage) {
+		return nil, ErrForbidden
+	}
+
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	apply(user)
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	s.cache.Invalidate(userID)
+	return user, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"blog/models"
+	"blog/repository"
+	"context"
+	"sync"
+	"time"
+)
+
+// userCacheMaxEntries 缓存的用户数上限，超过后先淘汰过期条目，仍然超过则清空
+const userCacheMaxEntries = 10000
+
+// UserCache 按 ID 查询用户的短期缓存，供认证中间件在每个请求上加载当前用户
+// 本实例内修改用户时调用 Invalidate 立即生效；其他实例上的修改最多延迟 ttl 生效
+type UserCache struct {
+	users repository.UserRepository
+	ttl   time.Duration
+
+	mu      sync.Mutex
+	entries map[uint]userCacheEntry
+}
+
+type userCacheEntry struct {
+	user      models.User
+	expiresAt time.Time
+}
+
+func NewUserCache(users repository.UserRepository, ttl time.Duration) *UserCache {
+	return &UserCache{users: users, ttl: ttl, entries: map[uint]userCacheEntry{}}
+}
+
+// LoadUser 返回用户的副本；用户不存在或已被删除时返回 ErrNotFound，不存在的结果不缓存
+func (c *UserCache) LoadUser(ctx context.Context, id uint) (*models.User, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		user := entry.user
+		return &user, nil
+	}
+
+	user, err := c.users.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= userCacheMaxEntries {
+		c.evict(now)
+	}
+	c.entries[id] = userCacheEntry{user: *user, expiresAt: now.Add(c.ttl)}
+	return user, nil
+}
+
+// Invalidate 删除用户的缓存，修改角色、禁用或删除用户后调用
+func (c *UserCache) Invalidate(id uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+}
+
+// evict 淘汰过期条目，调用方需持有锁
+func (c *UserCache) evict(now time.Time) {
+	for id, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+	if len(c.entries) >= userCacheMaxEntries {
+		c.entries = map[uint]userCacheEntry{}
+	}
+}
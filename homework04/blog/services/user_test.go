@@ -0,0 +1,51 @@
+package services
+
+import (
+	"blog/models"
+	"blog/rbac"
+	"blog/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUserService_SetDisabledInvalidatesCache(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryStore()
+	cache := NewUserCache(store.Users(), time.Hour)
+	users := NewUserService(store.Users(), cache)
+
+	bob := &models.User{Username: "bob", Role: string(rbac.RoleAuthor)}
+	if err := store.Users().Create(ctx, bob); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	loaded, err := cache.LoadUser(ctx, bob.ID)
+	if err != nil {
+		t.Fatalf("LoadUser: %v", err)
+	}
+	// 返回的是副本，调用方修改不影响缓存
+	loaded.Disabled = true
+	if again, _ := cache.LoadUser(ctx, bob.ID); again.Disabled {
+		t.Fatal("LoadUser returned a shared value")
+	}
+
+	admin := rbac.Actor{UserID: 99, Role: rbac.RoleAdmin}
+	if _, err := users.SetDisabled(ctx, rbac.Actor{UserID: 98, Role: rbac.RoleEditor}, bob.ID, true); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("SetDisabled by editor: got %v, want ErrForbidden", err)
+	}
+	if _, err := users.SetDisabled(ctx, admin, admin.UserID, true); !errors.Is(err, ErrOwnAccount) {
+		t.Fatalf("SetDisabled on self: got %v, want ErrOwnAccount", err)
+	}
+	if _, err := users.SetDisabled(ctx, admin, bob.ID, true); err != nil {
+		t.Fatalf("SetDisabled: %v", err)
+	}
+	if again, _ := cache.LoadUser(ctx, bob.ID); !again.Disabled {
+		t.Fatal("cache was not invalidated after SetDisabled")
+	}
+
+	if _, err := cache.LoadUser(ctx, 42); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("LoadUser missing: got %v, want ErrNotFound", err)
+	}
+}
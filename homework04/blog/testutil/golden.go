@@ -0,0 +1,73 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current responses")
+
+var (
+	timestampPattern = regexp.MustCompile(`"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})"`)
+	jwtPattern       = regexp.MustCompile(`"eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+"`)
+	refreshPattern   = regexp.MustCompile(`("refresh_token":\s*)"[A-Za-z0-9_-]+"`)
+	challengePattern = regexp.MustCompile(`("challenge_token":\s*)"[^"]+"`)
+	personalPattern  = regexp.MustCompile(`"blog_pat_[A-Za-z0-9_-]+"`)
+	// 会话 ID 是随机字符串，其余对象的 id 都是数字
+	sessionPattern = regexp.MustCompile(`("id":\s*)"[A-Za-z0-9_-]{22}"`)
+)
+
+// normalize 替换时间戳、令牌等每次运行都不同的值，并格式化 JSON
+func normalize(t *testing.T, body []byte) []byte {
+	t.Helper()
+	body = timestampPattern.ReplaceAll(body, []byte(`"<timestamp>"`))
+	body = jwtPattern.ReplaceAll(body, []byte(`"<jwt>"`))
+	body = refreshPattern.ReplaceAll(body, []byte(`$1"<refresh_token>"`))
+	body = challengePattern.ReplaceAll(body, []byte(`$1"<challenge_token>"`))
+	body = personalPattern.ReplaceAll(body, []byte(`"<personal_token>"`))
+	body = sessionPattern.ReplaceAll(body, []byte(`$1"<session_id>"`))
+
+	// 重新编码使对象的键按字母排序，golden 文件不受结构体字段顺序影响
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		t.Fatalf("response is not valid JSON: %v (body %s)", err, body)
+	}
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		t.Fatalf("encode normalized response: %v", err)
+	}
+	return out.Bytes()
+}
+
+// AssertGolden 将响应体与 testdata/golden/<name>.json 比较，使用 -update 重新生成
+func AssertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+	got := normalize(t, body)
+	path := filepath.Join("testdata", "golden", name+".json")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file %s: %v (run go test with -update to create it)", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response does not match %s\n--- got\n%s--- want\n%s", path, got, want)
+	}
+}
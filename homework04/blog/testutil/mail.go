@@ -0,0 +1,89 @@
+package testutil
+
+import (
+	"io"
+	"mime"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// linkPattern 邮件正文中的链接
+var linkPattern = regexp.MustCompile(`https?://\S+`)
+
+// Mail 文件投递方式保存的一封邮件
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mails 按发送顺序返回发给 to 的邮件
+func (s *Server) Mails(to string) []Mail {
+	s.t.Helper()
+	entries, err := os.ReadDir(s.Config.Mail.Dir)
+	if err != nil {
+		s.t.Fatalf("read mail directory: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	var mails []Mail
+	for _, name := range names {
+		m := readMail(s.t, filepath.Join(s.Config.Mail.Dir, name))
+		if m.To == to {
+			mails = append(mails, m)
+		}
+	}
+	return mails
+}
+
+// LastMailToken 返回最近一封发给 to 的邮件中链接的 token 参数
+func (s *Server) LastMailToken(to string) string {
+	s.t.Helper()
+	mails := s.Mails(to)
+	if len(mails) == 0 {
+		s.t.Fatalf("no mail sent to %s", to)
+	}
+	link := linkPattern.FindString(mails[len(mails)-1].Body)
+	u, err := url.Parse(link)
+	if err != nil || u.Query().Get("token") == "" {
+		s.t.Fatalf("no token link in mail to %s: %q", to, mails[len(mails)-1].Body)
+	}
+	return u.Query().Get("token")
+}
+
+func readMail(t *testing.T, path string) Mail {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open mail: %v", err)
+	}
+	defer file.Close()
+
+	msg, err := mail.ReadMessage(file)
+	if err != nil {
+		t.Fatalf("parse mail %s: %v", path, err)
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatalf("read mail %s: %v", path, err)
+	}
+	to, err := mail.ParseAddress(msg.Header.Get("To"))
+	if err != nil {
+		t.Fatalf("parse recipient of %s: %v", path, err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject of %s: %v", path, err)
+	}
+	return Mail{To: to.Address, Subject: subject, Body: strings.ReplaceAll(string(body), "\r\n", "\n")}
+}
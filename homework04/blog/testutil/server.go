@@ -0,0 +1,328 @@
+// Package testutil 提供 HTTP 集成测试的公共设施：
+// 基于嵌入式 SQLite 内存库启动真实的 gin 路由，并提供注册、登录、请求和 golden 文件比对等辅助函数。
+package testutil
+
+import (
+	"blog/config"
+	"blog/migrations"
+	"blog/routes"
+	"blog/utils"
+	"blog/workers"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// TestJWTSecret 测试用 JWT 密钥
+const TestJWTSecret = "test-secret-0123456789abcdefghijklmnopqrstuvwxyz"
+
+var (
+	dbCounter   atomic.Int64
+	ginModeOnce sync.Once
+)
+
+// Server 一个独立的测试服务：每个 Server 使用自己的内存数据库
+type Server struct {
+	t       *testing.T
+	Engine  *gin.Engine
+	DB      *gorm.DB
+	Config  *config.Config
+	Workers *workers.Manager
+}
+
+// NewConfig 返回指向独立 SQLite 内存库的测试配置
+func NewConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Server.Mode = gin.TestMode
+	cfg.Database.Driver = config.DriverSQLite
+	cfg.Database.Path = fmt.Sprintf("file:blogtest%d?mode=memory&cache=shared", dbCounter.Add(1))
+	cfg.JWT.Secret = TestJWTSecret
+	cfg.JWT.Expiration = config.Duration(time.Hour)
+	cfg.JWT.RefreshExpiration = config.Duration(24 * time.Hour)
+	cfg.Mail.Driver = config.MailDriverFile
+	// 测试请求都来自同一个 IP，默认不要求失败后等待，防暴力破解的测试单独开启
+	cfg.Auth.Lockout.BaseDelay = 0
+	// 测试不需要抗暴力破解的哈希强度，使用最小 cost 加快注册和登录
+	cfg.Auth.BcryptCost = bcrypt.MinCost
+	return cfg
+}
+
+// NewServer 启动测试服务，可通过 configure 调整配置
+func NewServer(t *testing.T, configure ...func(*config.Config)) *Server {
+	t.Helper()
+	ginModeOnce.Do(func() { gin.SetMode(gin.TestMode) })
+
+	cfg := NewConfig()
+	cfg.Mail.Dir = t.TempDir()
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+
+	db, err := config.OpenDB(cfg.Database)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	db.Logger = logger.Default.LogMode(logger.Silent)
+	if _, err := migrations.NewMigrator(db).Up(context.Background()); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+
+	workerManager := workers.NewManager(zap.NewNop())
+	engine := gin.New()
+	engine.Use(recordRoute)
+	if err := routes.SetupRoutes(engine, db, cfg, workerManager); err != nil {
+		t.Fatalf("setup routes: %v", err)
+	}
+	recordRegistered(engine)
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		workerManager.Stop(ctx)
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return &Server{t: t, Engine: engine, DB: db, Config: cfg, Workers: workerManager}
+}
+
+// Response 测试响应
+type Response struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+// Envelope utils.Response 统一响应结构
+type Envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Meta    *utils.Meta     `json:"meta,omitempty"`
+}
+
+// Envelope 解析统一响应结构
+func (r *Response) Envelope(t *testing.T) Envelope {
+	t.Helper()
+	var env Envelope
+	if err := json.Unmarshal(r.Body, &env); err != nil {
+		t.Fatalf("decode response envelope: %v (body %s)", err, r.Body)
+	}
+	return env
+}
+
+// Decode 将响应中的 data 解析到 v
+func (r *Response) Decode(t *testing.T, v interface{}) {
+	t.Helper()
+	env := r.Envelope(t)
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		t.Fatalf("decode response data: %v (body %s)", err, r.Body)
+	}
+}
+
+// Request 请求参数
+type Request struct {
+	Method  string
+	Path    string
+	Body    interface{}
+	Token   string
+	Headers map[string]string
+}
+
+// Do 发送请求。Body 为 string 时原样发送，否则编码为 JSON
+func (s *Server) Do(req Request) *Response {
+	s.t.Helper()
+
+	var body io.Reader
+	switch b := req.Body.(type) {
+	case nil:
+	case string:
+		body = bytes.NewBufferString(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			s.t.Fatalf("encode request body: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq := httptest.NewRequest(req.Method, req.Path, body)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+req.Token)
+	}
+	for key, value := range req.Headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	w := httptest.NewRecorder()
+	s.Engine.ServeHTTP(w, httpReq)
+	return &Response{Code: w.Code, Header: w.Header(), Body: w.Body.Bytes()}
+}
+
+// User 测试用户
+type User struct {
+	ID       uint
+	Username string
+	Password string
+	Email    string
+	Token    string
+	// RefreshToken 最近一次登录得到的刷新令牌
+	RefreshToken string
+}
+
+// Register 注册用户，失败时终止测试
+func (s *Server) Register(username string) *User {
+	s.t.Helper()
+	user := &User{Username: username, Password: "password123", Email: username + "@example.com"}
+	resp := s.Do(Request{Method: http.MethodPost, Path: "/api/auth/register", Body: map[string]string{
+		"username": user.Username,
+		"password": user.Password,
+		"email":    user.Email,
+	}})
+	if resp.Code != http.StatusOK {
+		s.t.Fatalf("register %s: status %d, body %s", username, resp.Code, resp.Body)
+	}
+	var data struct {
+		ID uint `json:"id"`
+	}
+	resp.Decode(s.t, &data)
+	user.ID = data.ID
+	return user
+}
+
+// Login 登录并把令牌保存到 user.Token
+func (s *Server) Login(user *User) string {
+	s.t.Helper()
+	resp := s.Do(Request{Method: http.MethodPost, Path: "/api/auth/login", Body: map[string]string{
+		"username": user.Username,
+		"password": user.Password,
+	}})
+	if resp.Code != http.StatusOK {
+		s.t.Fatalf("login %s: status %d, body %s", user.Username, resp.Code, resp.Body)
+	}
+	var data struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	resp.Decode(s.t, &data)
+	user.Token = data.Token
+	user.RefreshToken = data.RefreshToken
+	return data.Token
+}
+
+// RegisterAndLogin 注册并登录
+func (s *Server) RegisterAndLogin(username string) *User {
+	s.t.Helper()
+	user := s.Register(username)
+	s.Login(user)
+	return user
+}
+
+// SetRole 直接在数据库中修改用户角色并重新登录，使新令牌带上新角色
+func (s *Server) SetRole(user *User, role string) {
+	s.t.Helper()
+	if err := s.DB.Table("users").Where("id = ?", user.ID).Update("role", role).Error; err != nil {
+		s.t.Fatalf("set role of %s: %v", user.Username, err)
+	}
+	s.Login(user)
+}
+
+// CreatePost 以 user 身份创建文章，返回文章 ID
+func (s *Server) CreatePost(user *User, title, content string) uint {
+	s.t.Helper()
+	resp := s.Do(Request{Method: http.MethodPost, Path: "/api/posts", Token: user.Token, Body: map[string]string{
+		"title":   title,
+		"content": content,
+	}})
+	if resp.Code != http.StatusOK {
+		s.t.Fatalf("create post: status %d, body %s", resp.Code, resp.Body)
+	}
+	var data struct {
+		ID uint `json:"id"`
+	}
+	resp.Decode(s.t, &data)
+	return data.ID
+}
+
+// CreateComment 以 user 身份发表评论，返回评论 ID
+func (s *Server) CreateComment(user *User, postID uint, content string) uint {
+	s.t.Helper()
+	resp := s.Do(Request{Method: http.MethodPost, Path: fmt.Sprintf("/api/posts/%d/comments", postID), Token: user.Token, Body: map[string]string{
+		"content": content,
+	}})
+	if resp.Code != http.StatusOK {
+		s.t.Fatalf("create comment: status %d, body %s", resp.Code, resp.Body)
+	}
+	var data struct {
+		ID uint `json:"id"`
+	}
+	resp.Decode(s.t, &data)
+	return data.ID
+}
+
+var (
+	coveredMu  sync.Mutex
+	covered    = map[string]bool{}
+	registered = map[string]bool{}
+)
+
+// recordRegistered 记录测试服务注册的路由（方法 + 路由模板）
+func recordRegistered(engine *gin.Engine) {
+	coveredMu.Lock()
+	defer coveredMu.Unlock()
+	for _, route := range engine.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+}
+
+// recordRoute 记录被请求过的路由（方法 + 路由模板）
+func recordRoute(c *gin.Context) {
+	c.Next()
+	if path := c.FullPath(); path != "" {
+		coveredMu.Lock()
+		covered[c.Request.Method+" "+path] = true
+		coveredMu.Unlock()
+	}
+}
+
+// UncoveredRoutes 返回测试服务注册过、但从未被测试请求过的路由
+func UncoveredRoutes() []string {
+	coveredMu.Lock()
+	defer coveredMu.Unlock()
+	var missing []string
+	for key := range registered {
+		if !covered[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Routes 返回 routes.SetupRoutes 注册的所有路由
+func Routes(t *testing.T) gin.RoutesInfo {
+	t.Helper()
+	return NewServer(t).Engine.Routes()
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidActionToken 令牌格式错误、签名不符、用途不符或已过期
+var ErrInvalidActionToken = errors.New("invalid action token")
+
+// actionTokenKeyLabel 从 jwt.secret 派生签名密钥时使用的标签，与 JWT 签名密钥区分开
+const actionTokenKeyLabel = "blog action token"
+
+// ActionClaims 邮件链接中的一次性令牌内容
+// Fingerprint 由签发时的账号状态计算，状态变化（邮箱已验证、密码已修改）后令牌随之失效，因此无需在服务端保存
+type ActionClaims struct {
+	Purpose     string `json:"p"`
+	UserID      uint   `json:"u"`
+	Fingerprint string `json:"f"`
+	ExpiresAt   int64  `json:"e"`
+}
+
+// ActionTokenSigner 签发和验证邮箱验证、重置密码等一次性令牌
+// 令牌格式为 base64url(JSON 内容).base64url(HMAC-SHA256)
+type ActionTokenSigner struct {
+	key []byte
+}
+
+func NewActionTokenSigner(secret string) *ActionTokenSigner {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(actionTokenKeyLabel))
+	return &ActionTokenSigner{key: mac.Sum(nil)}
+}
+
+// Sign 签发令牌
+func (s *ActionTokenSigner) Sign(purpose string, userID uint, fingerprint string, expiresAt time.Time) (string, error) {
+	payload, err := json.Marshal(ActionClaims{
+		Purpose:     purpose,
+		UserID:      userID,
+		Fingerprint: fingerprint,
+		ExpiresAt:   expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), nil
+}
+
+// Verify 校验签名、用途和有效期；Fingerprint 由调用方与账号当前状态比较
+func (s *ActionTokenSigner) Verify(token, purpose string, now time.Time) (*ActionClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidActionToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return nil, ErrInvalidActionToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidActionToken
+	}
+
+	var claims ActionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidActionToken
+	}
+	if claims.Purpose != purpose || now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidActionToken
+	}
+	return &claims, nil
+}
+
+func (s *ActionTokenSigner) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
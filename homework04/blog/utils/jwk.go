@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"blog/config"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// rsaKeyBits RS256 密钥长度
+const rsaKeyBits = 2048
+
+// SigningKey JWT 签名密钥，由 kid 标识；Private 为 nil 的密钥只用于验证
+// HS256 的 Private、Public 都是 []byte；RS256 为 *rsa.PrivateKey、*rsa.PublicKey；EdDSA 为 ed25519.PrivateKey、ed25519.PublicKey
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   interface{}
+	Public    interface{}
+}
+
+// GenerateSigningKey 生成新的 RS256 或 EdDSA 密钥，kid 随机生成
+func GenerateSigningKey(algorithm string) (*SigningKey, error) {
+	kid, err := RandomToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	var private crypto.Signer
+	switch algorithm {
+	case config.JWTAlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case config.JWTAlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate %s key: %w", algorithm, err)
+	}
+	return &SigningKey{ID: kid, Algorithm: algorithm, Private: private, Public: private.Public()}, nil
+}
+
+// MarshalPrivateKey 以 PKCS#8 DER 编码私钥
+func (k *SigningKey) MarshalPrivateKey() ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(k.Private)
+}
+
+// MarshalPublicKey 以 PEM 编码公钥
+func (k *SigningKey) MarshalPublicKey() (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(k.Public)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// ParseSigningKey 由 PKCS#8 DER 编码的私钥恢复密钥
+func ParseSigningKey(kid, algorithm string, privateDER []byte) (*SigningKey, error) {
+	parsed, err := x509.ParsePKCS8PrivateKey(privateDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", kid, err)
+	}
+
+	var ok bool
+	switch algorithm {
+	case config.JWTAlgorithmRS256:
+		_, ok = parsed.(*rsa.PrivateKey)
+	case config.JWTAlgorithmEdDSA:
+		_, ok = parsed.(ed25519.PrivateKey)
+	}
+	if !ok {
+		return nil, fmt.Errorf("key %s is not a valid %s key", kid, algorithm)
+	}
+	private := parsed.(crypto.Signer)
+	return &SigningKey{ID: kid, Algorithm: algorithm, Private: private, Public: private.Public()}, nil
+}
+
+// JWK RFC 7517 公钥，只包含 RSA 和 Ed25519 需要的字段
+type JWK struct {
+	Kty string `json:"kty" doc:"RSA 或 OKP"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty" doc:"OKP 密钥的曲线，固定为 Ed25519"`
+	X   string `json:"x,omitempty" doc:"Ed25519 公钥"`
+	N   string `json:"n,omitempty" doc:"RSA 模数"`
+	E   string `json:"e,omitempty" doc:"RSA 指数"`
+}
+
+// JWKSet RFC 7517 JWK Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK 返回公钥的 JWK 表示，对称密钥不能公开，返回错误
+func (k *SigningKey) JWK() (JWK, error) {
+	jwk := JWK{Use: "sig", Alg: k.Algorithm, Kid: k.ID}
+	switch public := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return JWK{}, errors.New("symmetric keys cannot be published")
+	}
+	return jwk, nil
+}
@@ -1,43 +1,148 @@
 package utils
 
 import (
+	"blog/config"
+	"blog/models"
 	"errors"
-	"os"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// hs256KeyID HS256 共享密钥的 kid
+const hs256KeyID = "hs256"
+
+// ErrNoSigningKey 还没有可用于签名的密钥
+var ErrNoSigningKey = errors.New("no active signing key")
 
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	// Role 签发时用户的角色，仅供客户端参考；服务端鉴权使用认证中间件加载的用户的当前角色
+	Role string `json:"role"`
+	// SessionID 令牌所属的登录会话，会话被吊销后令牌立即失效
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT令牌
-func GenerateToken(userID uint) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // 24小时过期
+// JWTManager 负责签发和解析JWT令牌
+// 签名使用当前密钥，令牌头中的 kid 标识密钥；已轮换下来的密钥只用于验证，直到其签发的令牌全部过期
+type JWTManager struct {
+	issuer     string
+	audience   string
+	expiration time.Duration
+
+	mu      sync.RWMutex
+	signing *SigningKey
+	keys    map[string]*SigningKey
+	// unknownKey 遇到未知 kid 时调用，用于从存储中重新加载其他实例轮换出的新密钥
+	unknownKey func()
+}
+
+// NewJWTManager 根据配置创建JWTManager
+// 使用 HS256 时以 Secret 作为唯一的密钥；使用 RS256/EdDSA 时需要由 SetKeys 设置密钥
+func NewJWTManager(cfg config.JWTConfig) *JWTManager {
+	m := &JWTManager{
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		expiration: cfg.Expiration.Std(),
+		keys:       map[string]*SigningKey{},
+	}
+	if cfg.Algorithm == config.JWTAlgorithmHS256 {
+		secret := []byte(cfg.Secret)
+		m.SetKeys(&SigningKey{ID: hs256KeyID, Algorithm: config.JWTAlgorithmHS256, Private: secret, Public: secret}, nil)
+	}
+	return m
+}
+
+// SetKeys 替换全部密钥：signing 用于签名和验证，verifyOnly 只用于验证
+func (m *JWTManager) SetKeys(signing *SigningKey, verifyOnly []*SigningKey) {
+	keys := make(map[string]*SigningKey, len(verifyOnly)+1)
+	for _, key := range verifyOnly {
+		keys[key.ID] = key
+	}
+	if signing != nil {
+		keys[signing.ID] = signing
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.signing = signing
+	m.keys = keys
+}
+
+// OnUnknownKey 设置遇到未知 kid 时的回调，回调返回后会再查找一次
+func (m *JWTManager) OnUnknownKey(fn func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unknownKey = fn
+}
+
+// Expiration 访问令牌有效期
+func (m *JWTManager) Expiration() time.Duration {
+	return m.expiration
+}
+
+// GenerateToken 生成JWT令牌，每个令牌带有唯一的 jti，用于吊销；sessionID 为令牌所属的登录会话，可以为空
+func (m *JWTManager) GenerateToken(user *models.User, sessionID string) (string, error) {
+	m.mu.RLock()
+	key := m.signing
+	m.mu.RUnlock()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 
 	claims := &Claims{
-		UserID: userID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "blog",
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.expiration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    m.issuer,
+			Audience:  jwt.ClaimStrings{m.audience},
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
-// ParseToken 解析JWT令牌
-func ParseToken(tokenString string) (*Claims, error) {
+// ParseToken 解析JWT令牌，校验签名、有效期、签发者和受众
+// 只接受当前持有的密钥所用的算法，且令牌头中的 alg 必须与 kid 对应密钥的算法一致
+func (m *JWTManager) ParseToken(tokenString string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(m.algorithms()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(m.issuer),
+	}
+	if m.audience != "" {
+		options = append(options, jwt.WithAudience(m.audience))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		key := m.verificationKey(kid)
+		if key == nil {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("key %q does not use algorithm %s", kid, token.Method.Alg())
+		}
+		return key.Public, nil
+	}, options...)
 
 	if err != nil {
 		return nil, err
@@ -49,3 +154,61 @@ func ParseToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// JWKS 返回所有可用于验证的公钥，签名密钥排在最前
+func (m *JWTManager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range m.sortedKeys() {
+		if jwk, err := key.JWK(); err == nil {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// sortedKeys 签名密钥在前，其余按 kid 排序，调用方需持有读锁
+func (m *JWTManager) sortedKeys() []*SigningKey {
+	keys := make([]*SigningKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == m.signing) != (keys[j] == m.signing) {
+			return keys[i] == m.signing
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
+
+// algorithms 当前密钥使用的算法，不在其中的算法（包括 none）一律拒绝
+func (m *JWTManager) algorithms() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := map[string]bool{}
+	var algorithms []string
+	for _, key := range m.keys {
+		if !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algorithms = append(algorithms, key.Algorithm)
+		}
+	}
+	return algorithms
+}
+
+func (m *JWTManager) verificationKey(kid string) *SigningKey {
+	m.mu.RLock()
+	key, reload := m.keys[kid], m.unknownKey
+	m.mu.RUnlock()
+	if key != nil || reload == nil {
+		return key
+	}
+
+	reload()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keys[kid]
+}
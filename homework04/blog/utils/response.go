@@ -6,6 +6,23 @@ type Response struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	Meta    *Meta       `json:"meta,omitempty"`
+}
+
+// Meta 列表接口的分页信息
+type Meta struct {
+	Total      int64  `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Links      Links  `json:"links"`
+}
+
+// Links 上一页、下一页的完整链接，没有时为空
+type Links struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
 }
 
 // Success 成功响应
@@ -17,6 +34,26 @@ func Success(c *gin.Context, data interface{}) {
 	})
 }
 
+// SuccessWithMeta 带分页信息的成功响应
+func SuccessWithMeta(c *gin.Context, data interface{}, meta *Meta) {
+	c.JSON(200, Response{
+		Code:    200,
+		Message: "success",
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
+// MovedPermanently 301 响应，资源已永久移动到 location
+func MovedPermanently(c *gin.Context, location string, data interface{}) {
+	c.Header("Location", location)
+	c.JSON(301, Response{
+		Code:    301,
+		Message: "moved permanently",
+		Data:    data,
+	})
+}
+
 // Error 错误响应
 func Error(c *gin.Context, code int, message string) {
 	c.JSON(code, Response{
@@ -45,6 +82,11 @@ func NotFound(c *gin.Context, message string) {
 	Error(c, 404, message)
 }
 
+// TooManyRequests 429错误
+func TooManyRequests(c *gin.Context, message string) {
+	Error(c, 429, message)
+}
+
 // InternalServerError 500错误
 func InternalServerError(c *gin.Context, message string) {
 	Error(c, 500, message)
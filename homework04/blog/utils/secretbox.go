@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SecretBox 用 AES-256-GCM 加密保存在数据库中的密钥（JWT 私钥、TOTP 密钥），加密密钥由 jwt.secret 派生
+// 修改 jwt.secret 后已加密的数据无法解密
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+func NewSecretBox(secret string) (*SecretBox, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal 加密，返回 base64(nonce || 密文)
+func (b *SecretBox) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open 解密 Seal 的结果
+func (b *SecretBox) Open(encoded string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < b.aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	return b.aead.Open(nil, nonce, ciphertext, nil)
+}
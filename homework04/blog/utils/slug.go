@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength slug 的最大长度（字节）
+const MaxSlugLength = 80
+
+var (
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	// pinyinArgs 不带声调的拼音，多音字取最常用的读音
+	pinyinArgs = pinyin.NewArgs()
+)
+
+// Slugify 由标题生成 URL 友好的 slug，只包含小写字母、数字和连字符：
+// 汉字转换为不带声调的拼音，拉丁字母去掉变音符号，撇号直接去掉，其他字符都作为单词分隔符。
+// 超过 MaxSlugLength 时在单词边界截断；标题中没有可用的字符时返回空字符串
+func Slugify(title string) string {
+	var (
+		words []string
+		word  strings.Builder
+	)
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range norm.NFD.String(title) {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’':
+			// NFD 分解出的变音符号，以及 don't 中的撇号
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if syllables := pinyin.SinglePinyin(r, pinyinArgs); len(syllables) > 0 {
+				// ü 的拼音写作 v
+				words = append(words, strings.ReplaceAll(syllables[0], "ü", "v"))
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	var slug strings.Builder
+	for _, w := range words {
+		if slug.Len() == 0 {
+			if len(w) > MaxSlugLength {
+				w = w[:MaxSlugLength]
+			}
+			slug.WriteString(w)
+			continue
+		}
+		if slug.Len()+1+len(w) > MaxSlugLength {
+			break
+		}
+		slug.WriteByte('-')
+		slug.WriteString(w)
+	}
+	return slug.String()
+}
+
+// ValidSlug slug 是否只由连字符分隔的小写字母和数字组成，且不超过 MaxSlugLength
+func ValidSlug(slug string) bool {
+	return len(slug) <= MaxSlugLength && slugPattern.MatchString(slug)
+}
+
+// SlugCandidate slug 重复时依次尝试的候选：n <= 1 时为 base 本身，否则追加 -n，必要时截短 base 以满足长度限制
+func SlugCandidate(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	suffix := "-" + strconv.Itoa(n)
+	if len(base)+len(suffix) > MaxSlugLength {
+		base = strings.TrimRight(base[:MaxSlugLength-len(suffix)], "-")
+	}
+	return base + suffix
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"  Web servers in Go  ", "web-servers-in-go"},
+		{"Don't panic", "dont-panic"},
+		{"Café & crème brûlée", "cafe-creme-brulee"},
+		{"Go 语言入门", "go-yu-yan-ru-men"},
+		{"绿色", "lv-se"},
+		{"2024 年终总结", "2024-nian-zhong-zong-jie"},
+		{"!!!", ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.title); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+		if got := Slugify(tt.title); got != "" && !ValidSlug(got) {
+			t.Errorf("Slugify(%q) = %q is not a valid slug", tt.title, got)
+		}
+	}
+
+	long := Slugify(strings.Repeat("word ", 30))
+	if len(long) > MaxSlugLength || strings.HasSuffix(long, "-") || !strings.HasPrefix(long, "word-word") {
+		t.Errorf("Slugify(long title) = %q", long)
+	}
+}
+
+func TestSlugCandidate(t *testing.T) {
+	if got := SlugCandidate("hello", 1); got != "hello" {
+		t.Errorf("SlugCandidate(hello, 1) = %q", got)
+	}
+	if got := SlugCandidate("hello", 3); got != "hello-3" {
+		t.Errorf("SlugCandidate(hello, 3) = %q", got)
+	}
+	base := strings.Repeat("a", MaxSlugLength)
+	if got := SlugCandidate(base, 12); len(got) != MaxSlugLength || !strings.HasSuffix(got, "a-12") {
+		t.Errorf("SlugCandidate(long, 12) = %q", got)
+	}
+}
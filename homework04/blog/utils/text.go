@@ -0,0 +1,13 @@
+package utils
+
+import "strings"
+
+// Excerpt 截取摘要：合并空白字符，超过 n 个字符时截断并追加省略号
+func Excerpt(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:n])) + "…"
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTOTPCode RFC 6238 附录 B 的 SHA-1 测试向量（取 8 位结果的后 6 位）
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	step := TOTPStep(now)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		code, _ := TOTPCode(secret, step+offset)
+		if got, ok := ValidateTOTP(secret, code, now); !ok || got != step+offset {
+			t.Errorf("code for step %+d: got (%d, %v)", offset, got, ok)
+		}
+	}
+	for _, offset := range []int64{-2, 2} {
+		code, _ := TOTPCode(secret, step+offset)
+		if _, ok := ValidateTOTP(secret, code, now); ok {
+			t.Errorf("code for step %+d accepted", offset)
+		}
+	}
+	if _, ok := ValidateTOTP(secret, "12345", now); ok {
+		t.Error("short code accepted")
+	}
+
+	uri := TOTPURI(secret, "My Blog", "alice")
+	if !strings.HasPrefix(uri, "otpauth://totp/My%20Blog:alice?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("TOTPURI: %s", uri)
+	}
+}
@@ -0,0 +1,145 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Worker 后台任务，Run 应在 ctx 取消后尽快返回
+type Worker interface {
+	Name() string
+	Run(ctx context.Context) error
+}
+
+// State 后台任务的运行状态
+type State string
+
+const (
+	StateIdle    State = "idle"
+	StateRunning State = "running"
+	StateStopped State = "stopped"
+	StateFailed  State = "failed"
+)
+
+// Status 后台任务状态快照
+type Status struct {
+	Name      string     `json:"name"`
+	State     State      `json:"state"`
+	Error     string     `json:"error,omitempty"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+}
+
+type entry struct {
+	worker Worker
+	status Status
+}
+
+// Manager 管理后台任务的启动、停止和状态
+type Manager struct {
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	entries []*entry
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewManager 创建后台任务管理器
+func NewManager(logger *zap.Logger) *Manager {
+	return &Manager{logger: logger}
+}
+
+// Logger 后台任务共用的日志
+func (m *Manager) Logger() *zap.Logger {
+	return m.logger
+}
+
+// Register 注册后台任务，需在 Start 之前调用
+func (m *Manager) Register(w Worker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, &entry{
+		worker: w,
+		status: Status{Name: w.Name(), State: StateIdle},
+	})
+}
+
+// Start 在独立的 goroutine 中启动所有已注册的任务
+func (m *Manager) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cancel = cancel
+
+	for _, e := range m.entries {
+		now := time.Now()
+		e.status.State = StateRunning
+		e.status.StartedAt = &now
+		e.status.StoppedAt = nil
+		e.status.Error = ""
+
+		m.wg.Add(1)
+		go m.run(ctx, e)
+	}
+}
+
+// run 执行单个任务并记录其退出状态
+func (m *Manager) run(ctx context.Context, e *entry) {
+	defer m.wg.Done()
+
+	err := e.worker.Run(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	e.status.StoppedAt = &now
+	if err != nil && !errors.Is(err, context.Canceled) {
+		e.status.State = StateFailed
+		e.status.Error = err.Error()
+		m.logger.Error("Background worker failed", zap.String("worker", e.status.Name), zap.Error(err))
+		return
+	}
+	e.status.State = StateStopped
+	m.logger.Info("Background worker stopped", zap.String("worker", e.status.Name))
+}
+
+// Stop 通知所有任务退出，并等待它们结束或 ctx 超时
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.RLock()
+	cancel := m.cancel
+	m.mu.RUnlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Statuses 返回所有任务的状态快照
+func (m *Manager) Statuses() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	statuses := make([]Status, 0, len(m.entries))
+	for _, e := range m.entries {
+		statuses = append(statuses, e.status)
+	}
+	return statuses
+}